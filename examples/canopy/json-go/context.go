@@ -0,0 +1,73 @@
+// This file was generated from examples/canopy/json.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package jsongoparser
+
+import (
+	"context"
+	"fmt"
+)
+
+// contextCheckInterval is how many rules a parser run by ParseContext
+// applies between checks of its context. It is a power of two.
+const contextCheckInterval = 1024
+
+// ContextError is returned by ParseContext when the context is done before
+// the parse is. Err is the context's error, so errors.Is reports whether it
+// was context.Canceled or context.DeadlineExceeded.
+type ContextError struct {
+	Err    error
+	Offset int // the furthest offset the parse had reached
+}
+
+func (e *ContextError) Error() string {
+	return fmt.Sprintf("parse stopped at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *ContextError) Unwrap() error {
+	return e.Err
+}
+
+// ParseContext is like Parse, but gives up with a *ContextError once ctx is
+// done.
+func ParseContext(ctx context.Context, input string, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	parser := New(input, actions, opts...)
+	if types != nil {
+		parser.types = types
+	}
+	return parser.ParseContext(ctx)
+}
+
+// ParseContext is like Parse, but gives up with a *ContextError once ctx is
+// done. The context is checked every so often as rules are applied, so a
+// parser created by NewReader still waits for reads to return. A context
+// that can never be done, such as context.Background(), costs nothing.
+func (p *JsonGoParser) ParseContext(ctx context.Context) (TreeNode, error) {
+	if p.stream != nil {
+		return nil, fmt.Errorf("ParseContext cannot be used with a parser created by NewStream")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, &ContextError{Err: err, Offset: p.reached()}
+	}
+	if ctx.Done() != nil {
+		p.ctx, p.guarded = ctx, true
+		defer func() {
+			p.ctx, p.guarded = nil, p.opts.limits != (Limits{})
+		}()
+	}
+	return p.Parse()
+}
+
+// contextDone reports whether the context passed to ParseContext is done,
+// checking it once every contextCheckInterval rules.
+func (p *JsonGoParser) contextDone() bool {
+	if p.ctx == nil || p.steps&(contextCheckInterval-1) != 0 {
+		return false
+	}
+	err := p.ctx.Err()
+	if err == nil {
+		return false
+	}
+	p.stopErr = &ContextError{Err: err, Offset: p.reached()}
+	return true
+}
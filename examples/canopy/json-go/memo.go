@@ -0,0 +1,498 @@
+// This file was generated from examples/canopy/json.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package jsongoparser
+
+import (
+	"fmt"
+	"math"
+)
+
+// cacheEntry records the result of applying one rule at one offset: the node
+// it produced (nil on failure) and the offset the parser reached afterwards.
+// silent is set if the rule started inside a named expression or lookahead,
+// where failures are not recorded, so the entry only stands in for the rule
+// where they are not recorded either.
+type cacheEntry struct {
+	key    int
+	node   TreeNode
+	offset int
+	silent bool
+}
+
+// memoTable is the packrat memo. It is an open-addressed hash table keyed by
+// rule ID and offset, stored in a single slice, so lookups hash no strings
+// and storing a result never allocates unless the table has to grow.
+//
+// Only rules enabled in memo are stored. By default these are the rules not
+// annotated @nomemo in the grammar; WithoutMemo, WithMemoRules and
+// WithMemoProfile override that choice for a single parser.
+//
+// A parser fed by Feed may run out of input part way through a pass. Rules
+// that finish after that point might match differently once more input
+// arrives, so truncated is set and the keys they store are kept in pending,
+// for discardPending to remove before the next pass.
+//
+// Once Reparse has been called, reaches records how far the parse had looked
+// when each entry was stored, in the slot matching the entry's. reach is past
+// every byte the parse has looked at so far, and reusedReach the furthest
+// reach of the entries kept from an earlier parse that it has found. kept is
+// only used by edit and forget, and saved so that later calls need not
+// allocate it.
+// edits counts the calls to edit, so that each can tell which nodes it has
+// moved.
+//
+// put stores no more than limit entries, and sets full instead once there
+// are that many, for the parser to stop with a *LimitError.
+//
+// A cut sets floor once the parse can no longer go back before it. When the
+// table is half full, put drops the entries before floor, unless it has not
+// moved since they were last dropped, and only grows the table if that
+// leaves it more than a quarter full. Likewise, once limit is reached, it
+// drops them and only sets full if that leaves more than three quarters of
+// the limit in use, so that a grammar with cuts can parse any length of
+// input with a bounded table.
+type memoTable struct {
+	entries     []cacheEntry
+	count       int
+	shift       uint
+	memo        [numRules]bool
+	profile     *MemoProfile
+	truncated   bool
+	pending     []int
+	reaches     []entryReach
+	reach       int
+	reusedReach int
+	kept        []keptEntry
+	edits       int
+	limit       int
+	full        bool
+	floor       int
+	dropped     int
+}
+
+// entryReach is what reaches holds for one entry. No byte at or past reach
+// was looked at to produce the entry, so Reparse can keep it if an edit
+// starts there. reused marks entries kept from an earlier parse.
+type entryReach struct {
+	reach  int
+	reused bool
+}
+
+// keptEntry holds an entry while edit rebuilds the table.
+type keptEntry struct {
+	entry cacheEntry
+	reach entryReach
+}
+
+const minMemoSize = 64
+
+// maxPooledMemoSize is the most slots a table returned to a Parser's pool
+// keeps. A larger one is let go, so that one large input does not make
+// every later parse clear its slots.
+const maxPooledMemoSize = 1 << 16
+
+// newMemoTable returns a table with no slots. Its size is chosen by reserve,
+// which must be called before it is used.
+func newMemoTable(opts *options) memoTable {
+	m := memoTable{memo: memoDefaults, limit: math.MaxInt}
+	if opts.limits.MaxMemoEntries > 0 {
+		m.limit = opts.limits.MaxMemoEntries
+	}
+	if opts.memo != nil {
+		m.memo = *opts.memo
+	}
+	if opts.profile != nil {
+		for rule := range m.memo {
+			m.memo[rule] = true
+		}
+		m.profile = opts.profile
+	}
+	return m
+}
+
+// reserve grows an empty table to suit a parse of inputLength bytes. Most
+// grammars attempt a handful of rules at each offset, so Parse starts at the
+// input length to avoid the first few rounds of growth. Items only keeps the
+// entries for one item at a time, so it starts from the minimum size.
+func (m *memoTable) reserve(inputLength int) {
+	if m.entries == nil || m.count == 0 && len(m.entries) < inputLength*2 {
+		m.resize(inputLength)
+	}
+}
+
+func memoKey(rule Rule, offset int) int {
+	return offset*numRules + int(rule) + 1
+}
+
+func (m *memoTable) slot(key int) int {
+	return int(uint64(key) * 0x9e3779b97f4a7c15 >> m.shift)
+}
+
+// get returns the entry for rule at offset, if it may be used by a rule
+// started with failures silenced or not as silent says. An entry stored
+// while they were silenced recorded none, so it is not returned where they
+// are recorded, and put replaces it once the rule has run again.
+func (m *memoTable) get(rule Rule, offset int, silent bool) (cacheEntry, bool) {
+	if !m.memo[rule] {
+		return cacheEntry{}, false
+	}
+	key := memoKey(rule, offset)
+	mask := len(m.entries) - 1
+	for i := m.slot(key); ; i = (i + 1) & mask {
+		entry := m.entries[i]
+		if entry.key == key && entry.silent && !silent {
+			m.profile.record(rule, false)
+			return cacheEntry{}, false
+		}
+		if entry.key == key {
+			m.profile.record(rule, true)
+			if m.reaches != nil {
+				m.found(i)
+			}
+			return entry, true
+		}
+		if entry.key == 0 {
+			m.profile.record(rule, false)
+			return cacheEntry{}, false
+		}
+	}
+}
+
+func (m *memoTable) put(rule Rule, offset int, node TreeNode, end int, silent bool) {
+	if !m.memo[rule] {
+		return
+	}
+	if m.count >= m.limit {
+		m.dropBeforeFloor()
+		if 4*m.count > 3*m.limit {
+			m.full = true
+			return
+		}
+	}
+	if (m.count+1)*2 > len(m.entries) {
+		m.dropBeforeFloor()
+		if (m.count+1)*4 > len(m.entries) {
+			m.resize(len(m.entries))
+		}
+	}
+	key := memoKey(rule, offset)
+	mask := len(m.entries) - 1
+	i := m.slot(key)
+	for m.entries[i].key != 0 && m.entries[i].key != key {
+		i = (i + 1) & mask
+	}
+	if m.entries[i].key == 0 {
+		m.count++
+	}
+	m.entries[i] = cacheEntry{key: key, node: node, offset: end, silent: silent}
+	if m.reaches != nil {
+		m.reaches[i] = entryReach{reach: m.reach}
+	}
+	if m.truncated {
+		m.pending = append(m.pending, key)
+	}
+}
+
+// discardPending removes the entries stored since the pass was truncated.
+func (m *memoTable) discardPending() {
+	for _, key := range m.pending {
+		m.remove(key)
+	}
+	m.pending = m.pending[:0]
+	m.truncated = false
+}
+
+// remove deletes key from the table, then moves later entries in the same
+// run of occupied slots back into the gap where that keeps them reachable
+// from their home slot.
+func (m *memoTable) remove(key int) {
+	mask := len(m.entries) - 1
+	i := m.slot(key)
+	for m.entries[i].key != key {
+		if m.entries[i].key == 0 {
+			return
+		}
+		i = (i + 1) & mask
+	}
+	for j := (i + 1) & mask; m.entries[j].key != 0; j = (j + 1) & mask {
+		home := m.slot(m.entries[j].key)
+		if (j-home)&mask >= (j-i)&mask {
+			m.entries[i] = m.entries[j]
+			if m.reaches != nil {
+				m.reaches[i] = m.reaches[j]
+			}
+			i = j
+		}
+	}
+	m.entries[i] = cacheEntry{}
+	if m.reaches != nil {
+		m.reaches[i] = entryReach{}
+	}
+	m.count--
+}
+
+// resize rehashes the table into at least twice minimum slots, rounded up to
+// a power of two.
+func (m *memoTable) resize(minimum int) {
+	size, shift := minMemoSize, uint(64-6)
+	for size < minimum*2 {
+		size *= 2
+		shift--
+	}
+	old, reaches := m.entries, m.reaches
+	m.entries = make([]cacheEntry, size)
+	m.shift = shift
+	if reaches != nil {
+		m.reaches = make([]entryReach, size)
+	}
+	for i, entry := range old {
+		if entry.key == 0 {
+			continue
+		}
+		var reach entryReach
+		if reaches != nil {
+			reach = reaches[i]
+		}
+		m.place(entry, reach)
+	}
+}
+
+// place stores an entry whose key is not in the table.
+func (m *memoTable) place(entry cacheEntry, reach entryReach) {
+	mask := len(m.entries) - 1
+	i := m.slot(entry.key)
+	for m.entries[i].key != 0 {
+		i = (i + 1) & mask
+	}
+	m.entries[i] = entry
+	if m.reaches != nil {
+		m.reaches[i] = reach
+	}
+}
+
+// found records how far the entry in slot i looked, now that the parse has
+// used it.
+func (m *memoTable) found(i int) {
+	reach := m.reaches[i]
+	m.reach = max(m.reach, reach.reach)
+	if reach.reused {
+		m.reusedReach = max(m.reusedReach, reach.reach)
+	}
+}
+
+// reset empties the table.
+func (m *memoTable) reset() {
+	clear(m.entries)
+	clear(m.reaches)
+	m.count = 0
+	m.reach, m.reusedReach = 0, 0
+	m.full = false
+	m.floor, m.dropped = 0, 0
+}
+
+// release empties the table for a parser going back to a pool, letting go of
+// its slots if there are more than maxPooledMemoSize, so that reserve sizes
+// it afresh for the next input.
+func (m *memoTable) release() {
+	if len(m.entries) > maxPooledMemoSize {
+		m.entries = nil
+	}
+	m.reset()
+}
+
+// setFloor lets put drop the entries for offsets before floor.
+func (m *memoTable) setFloor(floor int) {
+	m.floor = max(m.floor, floor)
+}
+
+// dropBeforeFloor drops the entries before floor if it has moved since they
+// were last dropped.
+func (m *memoTable) dropBeforeFloor() {
+	if m.floor > m.dropped {
+		m.forget(m.floor)
+		m.dropped = m.floor
+	}
+}
+
+// track empties the table and makes it record reaches from now on.
+func (m *memoTable) track() {
+	if m.reaches == nil {
+		m.reaches = make([]entryReach, len(m.entries))
+	}
+	m.reset()
+}
+
+// edit updates the table after the bytes between start and oldEnd have been
+// replaced, changing the length of the input by delta. Entries that looked
+// at nothing from start onwards are kept as they are. Entries from oldEnd
+// onwards are moved by delta if move reports that their nodes could be
+// moved as well. Every other entry is dropped, and the ones kept are marked
+// as reused. Before the first edit nothing recorded how far the entries
+// looked, so all of them are dropped.
+func (m *memoTable) edit(start, oldEnd, delta int, move func(TreeNode) bool) {
+	if m.reaches == nil {
+		m.track()
+		return
+	}
+	m.edits++
+	kept := m.kept[:0]
+	for i, entry := range m.entries {
+		if entry.key == 0 {
+			continue
+		}
+		reach := m.reaches[i]
+		offset := (entry.key - 1) / numRules
+		switch {
+		case reach.reach <= start:
+		case offset >= oldEnd && move(entry.node):
+			entry.key += delta * numRules
+			entry.offset += delta
+			reach.reach += delta
+		default:
+			continue
+		}
+		reach.reused = true
+		kept = append(kept, keptEntry{entry, reach})
+	}
+	m.track()
+	for _, k := range kept {
+		m.place(k.entry, k.reach)
+	}
+	m.count = len(kept)
+	clear(kept)
+	m.kept = kept[:0]
+}
+
+// forget drops the entries for offsets before offset. Items calls it after
+// each item, since the parse never goes back before the end of one, so that
+// the table only holds the entries for the item being parsed.
+func (m *memoTable) forget(offset int) {
+	kept := m.kept[:0]
+	for i, entry := range m.entries {
+		if entry.key == 0 || (entry.key-1)/numRules < offset {
+			continue
+		}
+		var reach entryReach
+		if m.reaches != nil {
+			reach = m.reaches[i]
+		}
+		kept = append(kept, keptEntry{entry, reach})
+	}
+	clear(m.entries)
+	clear(m.reaches)
+	for _, k := range kept {
+		m.place(k.entry, k.reach)
+	}
+	m.count = len(kept)
+	clear(kept)
+	m.kept = kept[:0]
+}
+
+// WithoutMemo turns packrat memoization off for every rule. The parse result
+// is unchanged, but a rule may be evaluated more than once at the same
+// offset, which can make parsing slower or, for grammars that backtrack a
+// lot, exponential.
+func WithoutMemo() Option {
+	return func(o *options) {
+		o.memo, o.err = &[numRules]bool{}, nil
+	}
+}
+
+// WithMemoRules memoizes only the named rules, overriding any @nomemo
+// annotations in the grammar. Naming a rule the grammar does not define
+// makes the parse fail with an error, unless a later WithMemoRules or
+// WithoutMemo replaces the choice.
+func WithMemoRules(rules ...string) Option {
+	return func(o *options) {
+		o.err = nil
+		memo := [numRules]bool{}
+		for _, name := range rules {
+			rule, ok := ruleID(name)
+			if !ok {
+				o.err = fmt.Errorf("unknown rule %q", name)
+				return
+			}
+			memo[rule] = true
+		}
+		o.memo = &memo
+	}
+}
+
+// WithMemoProfile memoizes every rule and records how often each rule's
+// results are found in the memo table. Use the profile's Suggest method to
+// decide which rules to annotate @nomemo or pass to WithMemoRules.
+func WithMemoProfile(profile *MemoProfile) Option {
+	return func(o *options) {
+		o.profile = profile
+	}
+}
+
+// MemoProfile accumulates memo statistics over one or more parses. It is not
+// safe for use by concurrent parses.
+type MemoProfile struct {
+	lookups [numRules]int
+	hits    [numRules]int
+}
+
+// MemoStats describes how the memo table was used for a single rule.
+type MemoStats struct {
+	Rule     string // the rule name
+	Memoized bool   // false if the grammar annotates the rule @nomemo
+	Lookups  int    // times the rule was applied
+	Hits     int    // times the result was already in the memo table
+}
+
+// HitRate returns the fraction of lookups answered by the memo table.
+func (s MemoStats) HitRate() float64 {
+	if s.Lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Lookups)
+}
+
+func (prof *MemoProfile) record(rule Rule, hit bool) {
+	if prof == nil {
+		return
+	}
+	prof.lookups[rule]++
+	if hit {
+		prof.hits[rule]++
+	}
+}
+
+// Stats returns the statistics for every rule, in grammar order.
+func (prof *MemoProfile) Stats() []MemoStats {
+	stats := make([]MemoStats, numRules)
+	for rule := range stats {
+		stats[rule] = MemoStats{
+			Rule:     ruleNames[rule],
+			Memoized: memoDefaults[rule],
+			Lookups:  prof.lookups[rule],
+			Hits:     prof.hits[rule],
+		}
+	}
+	return stats
+}
+
+// Suggest returns the rules, in grammar order, whose hit rate is at least
+// minHitRate. Storing results for the remaining rules costs more than it
+// saves, so they are candidates for @nomemo.
+func (prof *MemoProfile) Suggest(minHitRate float64) []string {
+	var rules []string
+	for _, stats := range prof.Stats() {
+		if stats.Lookups > 0 && stats.HitRate() >= minHitRate {
+			rules = append(rules, stats.Rule)
+		}
+	}
+	return rules
+}
+
+func ruleID(name string) (Rule, bool) {
+	for rule, ruleName := range ruleNames {
+		if ruleName == name {
+			return Rule(rule), true
+		}
+	}
+	return 0, false
+}
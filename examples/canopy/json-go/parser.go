@@ -4,49 +4,45 @@
 package jsongoparser
 
 import (
-		"fmt"
-	"regexp"
+		"context"
+	"fmt"
+	"io"
+	"slices"
+	"unicode/utf8"
 )
 
 type NodeExtender func(TreeNode) TreeNode
 
-type expectation struct {
-	rule string
-	expected string
-}
-
 type failureState struct {
 	offset int
-	expected []expectation
-}
-
-type cacheEntry struct {
-	node TreeNode
-	offset int
-}
-
-type ParseError struct {
-	Input string
-	Offset int
-	Line int
-	Column int
-	Expected []expectation
-	Message string
-}
-
-func (e *ParseError) Error() string {
-	return e.Message
+	expected []Expectation
 }
 
 type JsonGoParser struct {
-	input []rune
-	inputString string
+	input string
+	offsets offsetIndex
+	lines *LineIndex
+	reader io.Reader
+	stream *streamState
+	open bool
+	buf []byte
+	readErr error
 	actions Actions
 	types map[string]NodeExtender
+	opts options
 	offset int
-	cache map[string]map[int]cacheEntry
+	seen int
+	cache memoTable
 	failure failureState
+	silent int
+	lookahead int
+	points []backtrackPoint
 	actionErr error
+	ctx context.Context
+	guarded bool
+	depth int
+	steps int
+	stopErr error
 }
 
 
@@ -56,9 +52,9 @@ type Node1 struct {
 
 var _ TreeNode = (*Node1)(nil)
 
-func newNode1(text string, start int, elements []TreeNode) TreeNode {
+func newNode1(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node1{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	return node
 }
@@ -71,9 +67,9 @@ type Node2 struct {
 
 var _ TreeNode = (*Node2)(nil)
 
-func newNode2(text string, start int, elements []TreeNode) TreeNode {
+func newNode2(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node2{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Pair = elements[1]
 	return node
@@ -87,9 +83,9 @@ type Node3 struct {
 
 var _ TreeNode = (*Node3)(nil)
 
-func newNode3(text string, start int, elements []TreeNode) TreeNode {
+func newNode3(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node3{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Pair = elements[1]
 	return node
@@ -102,9 +98,9 @@ type Node4 struct {
 
 var _ TreeNode = (*Node4)(nil)
 
-func newNode4(text string, start int, elements []TreeNode) TreeNode {
+func newNode4(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node4{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	return node
 }
@@ -118,9 +114,9 @@ type Node5 struct {
 
 var _ TreeNode = (*Node5)(nil)
 
-func newNode5(text string, start int, elements []TreeNode) TreeNode {
+func newNode5(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node5{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.String = elements[1]
 	node.Value = elements[4]
@@ -135,9 +131,9 @@ type Node6 struct {
 
 var _ TreeNode = (*Node6)(nil)
 
-func newNode6(text string, start int, elements []TreeNode) TreeNode {
+func newNode6(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node6{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Value = elements[1]
 	return node
@@ -151,9 +147,9 @@ type Node7 struct {
 
 var _ TreeNode = (*Node7)(nil)
 
-func newNode7(text string, start int, elements []TreeNode) TreeNode {
+func newNode7(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node7{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Value = elements[1]
 	return node
@@ -166,9 +162,9 @@ type Node8 struct {
 
 var _ TreeNode = (*Node8)(nil)
 
-func newNode8(text string, start int, elements []TreeNode) TreeNode {
+func newNode8(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node8{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	return node
 }
@@ -180,31 +176,48 @@ type Node9 struct {
 
 var _ TreeNode = (*Node9)(nil)
 
-func newNode9(text string, start int, elements []TreeNode) TreeNode {
+func newNode9(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node9{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	return node
 }
 
 
-var REGEX_1 = regexp.MustCompile(`^[^"]`)
-var REGEX_2 = regexp.MustCompile(`^[1-9]`)
-var REGEX_3 = regexp.MustCompile(`^[0-9]`)
-var REGEX_4 = regexp.MustCompile(`^[0-9]`)
-var REGEX_5 = regexp.MustCompile(`^[0-9]`)
-var REGEX_6 = regexp.MustCompile(`^[\s]`)
+// [^"]
+var charClass1 = charClass{
+	ascii: [2]uint64{0xfffffffbffffffff, 0xffffffffffffffff},
+	ranges: []rune{0x80, 0x10ffff},
+}
+
+// [1-9]
+var charClass2 = charClass{
+	ascii: [2]uint64{0x3fe000000000000, 0x0},
+}
+
+// [0-9]
+var charClass3 = charClass{
+	ascii: [2]uint64{0x3ff000000000000, 0x0},
+}
+
+// [\s]
+var charClass4 = charClass{
+	ascii: [2]uint64{0x100003600, 0x0},
+}
+
 
 func (p *JsonGoParser) _read_document() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address0 TreeNode = nil
 	var index0 int = p.offset
-	var cache0 map[int]cacheEntry = p.cache["document"]
-	if cache0 == nil {
-		cache0 = make(map[int]cacheEntry)
-		p.cache["document"] = cache0
-	}
-	if entry, ok := cache0[index0]; ok {
+	var silent0 int = p.silent
+	if entry, ok := p.cache.get(RuleDocument, index0, silent0 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index1 int = p.offset
@@ -244,44 +257,46 @@ func (p *JsonGoParser) _read_document() TreeNode {
 	if elements0 == nil {
 		address0 = nil
 	} else {
-		address0 = newNode1(p.slice(index1, p.offset), index1, elements0)
+		address0 = newNode1(p.slice(index1, p.offset), p.offsets.span(index1, p.offset), elements0)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleDocument, index0, address0, p.offset, silent0 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache0[index0] = cacheEntry{node: address0, offset: p.offset}
 	return address0
 }
 
 func (p *JsonGoParser) _read_object() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address4 TreeNode = nil
 	var index3 int = p.offset
-	var cache1 map[int]cacheEntry = p.cache["object"]
-	if cache1 == nil {
-		cache1 = make(map[int]cacheEntry)
-		p.cache["object"] = cache1
-	}
-	if entry, ok := cache1[index3]; ok {
+	var silent1 int = p.silent
+	if entry, ok := p.cache.get(RuleObject, index3, silent1 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index4 int = p.offset
 	var index5 int = p.offset
 	var elements1 []TreeNode = make([]TreeNode, 4)
 	var address5 TreeNode = nil
-	var chunk0 string = ""
-	var max0 int = p.offset + 1
-	if max0 <= len(p.input) {
-		chunk0 = string(p.input[p.offset:max0])
-	}
-	if chunk0 == "{" {
-		address5 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '{' {
+		address5 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address5 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::object", expected: "\"{\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::object", Expected: "\"{\""})
 		}
 	}
 	if address5 != nil {
@@ -298,22 +313,17 @@ func (p *JsonGoParser) _read_object() TreeNode {
 				var index7 int = p.offset
 				var elements3 []TreeNode = make([]TreeNode, 2)
 				var address9 TreeNode = nil
-				var chunk1 string = ""
-				var max1 int = p.offset + 1
-				if max1 <= len(p.input) {
-					chunk1 = string(p.input[p.offset:max1])
-				}
-				if chunk1 == "," {
-					address9 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == ',' {
+					address9 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address9 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::object", expected: "\",\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::object", Expected: "\",\""})
 					}
 				}
 				if address9 != nil {
@@ -333,7 +343,7 @@ func (p *JsonGoParser) _read_object() TreeNode {
 				if elements3 == nil {
 					address8 = nil
 				} else {
-					address8 = newNode3(p.slice(index7, p.offset), index7, elements3)
+					address8 = newNode3(p.slice(index7, p.offset), p.offsets.span(index7, p.offset), elements3)
 				}
 				if address8 != nil {
 					elements2 = append(elements2, address8)
@@ -342,29 +352,24 @@ func (p *JsonGoParser) _read_object() TreeNode {
 				}
 			}
 			if len(elements2) >= 0 {
-				address7 = &BaseNode{text: p.slice(index6, p.offset), offset: index6, children: elements2}
+				address7 = &BaseNode{text: p.slice(index6, p.offset), span: p.offsets.span(index6, p.offset), children: elements2}
 			} else {
 				address7 = nil
 			}
 			if address7 != nil {
 				elements1[2] = address7
 				var address11 TreeNode = nil
-				var chunk2 string = ""
-				var max2 int = p.offset + 1
-				if max2 <= len(p.input) {
-					chunk2 = string(p.input[p.offset:max2])
-				}
-				if chunk2 == "}" {
-					address11 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == '}' {
+					address11 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address11 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::object", expected: "\"}\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::object", Expected: "\"}\""})
 					}
 				}
 				if address11 != nil {
@@ -388,29 +393,24 @@ func (p *JsonGoParser) _read_object() TreeNode {
 	if elements1 == nil {
 		address4 = nil
 	} else {
-		address4 = newNode2(p.slice(index5, p.offset), index5, elements1)
+		address4 = newNode2(p.slice(index5, p.offset), p.offsets.span(index5, p.offset), elements1)
 	}
 	if address4 == nil {
 		p.offset = index4
 		var index8 int = p.offset
 		var elements4 []TreeNode = make([]TreeNode, 3)
 		var address12 TreeNode = nil
-		var chunk3 string = ""
-		var max3 int = p.offset + 1
-		if max3 <= len(p.input) {
-			chunk3 = string(p.input[p.offset:max3])
-		}
-		if chunk3 == "{" {
-			address12 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+		if p.avail(1) && p.input[p.offset] == '{' {
+			address12 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 			p.offset = p.offset + 1
 		} else {
 			address12 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::object", expected: "\"{\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::object", Expected: "\"{\""})
 			}
 		}
 		if address12 != nil {
@@ -420,22 +420,17 @@ func (p *JsonGoParser) _read_object() TreeNode {
 			if address13 != nil {
 				elements4[1] = address13
 				var address14 TreeNode = nil
-				var chunk4 string = ""
-				var max4 int = p.offset + 1
-				if max4 <= len(p.input) {
-					chunk4 = string(p.input[p.offset:max4])
-				}
-				if chunk4 == "}" {
-					address14 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == '}' {
+					address14 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address14 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::object", expected: "\"}\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::object", Expected: "\"}\""})
 					}
 				}
 				if address14 != nil {
@@ -455,26 +450,33 @@ func (p *JsonGoParser) _read_object() TreeNode {
 		if elements4 == nil {
 			address4 = nil
 		} else {
-			address4 = newNode4(p.slice(index8, p.offset), index8, elements4)
+			address4 = newNode4(p.slice(index8, p.offset), p.offsets.span(index8, p.offset), elements4)
 		}
 		if address4 == nil {
 			p.offset = index4
 		}
 	}
-	cache1[index3] = cacheEntry{node: address4, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleObject, index3, address4, p.offset, silent1 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address4
 }
 
 func (p *JsonGoParser) _read_pair() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address15 TreeNode = nil
 	var index9 int = p.offset
-	var cache2 map[int]cacheEntry = p.cache["pair"]
-	if cache2 == nil {
-		cache2 = make(map[int]cacheEntry)
-		p.cache["pair"] = cache2
-	}
-	if entry, ok := cache2[index9]; ok {
+	var silent2 int = p.silent
+	if entry, ok := p.cache.get(RulePair, index9, silent2 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index10 int = p.offset
@@ -492,22 +494,17 @@ func (p *JsonGoParser) _read_pair() TreeNode {
 			if address18 != nil {
 				elements5[2] = address18
 				var address19 TreeNode = nil
-				var chunk5 string = ""
-				var max5 int = p.offset + 1
-				if max5 <= len(p.input) {
-					chunk5 = string(p.input[p.offset:max5])
-				}
-				if chunk5 == ":" {
-					address19 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == ':' {
+					address19 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address19 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::pair", expected: "\":\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::pair", Expected: "\":\""})
 					}
 				}
 				if address19 != nil {
@@ -539,44 +536,46 @@ func (p *JsonGoParser) _read_pair() TreeNode {
 	if elements5 == nil {
 		address15 = nil
 	} else {
-		address15 = newNode5(p.slice(index10, p.offset), index10, elements5)
+		address15 = newNode5(p.slice(index10, p.offset), p.offsets.span(index10, p.offset), elements5)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RulePair, index9, address15, p.offset, silent2 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache2[index9] = cacheEntry{node: address15, offset: p.offset}
 	return address15
 }
 
 func (p *JsonGoParser) _read_array() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address21 TreeNode = nil
 	var index11 int = p.offset
-	var cache3 map[int]cacheEntry = p.cache["array"]
-	if cache3 == nil {
-		cache3 = make(map[int]cacheEntry)
-		p.cache["array"] = cache3
-	}
-	if entry, ok := cache3[index11]; ok {
+	var silent3 int = p.silent
+	if entry, ok := p.cache.get(RuleArray, index11, silent3 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index12 int = p.offset
 	var index13 int = p.offset
 	var elements6 []TreeNode = make([]TreeNode, 4)
 	var address22 TreeNode = nil
-	var chunk6 string = ""
-	var max6 int = p.offset + 1
-	if max6 <= len(p.input) {
-		chunk6 = string(p.input[p.offset:max6])
-	}
-	if chunk6 == "[" {
-		address22 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '[' {
+		address22 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address22 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::array", expected: "\"[\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::array", Expected: "\"[\""})
 		}
 	}
 	if address22 != nil {
@@ -593,22 +592,17 @@ func (p *JsonGoParser) _read_array() TreeNode {
 				var index15 int = p.offset
 				var elements8 []TreeNode = make([]TreeNode, 2)
 				var address26 TreeNode = nil
-				var chunk7 string = ""
-				var max7 int = p.offset + 1
-				if max7 <= len(p.input) {
-					chunk7 = string(p.input[p.offset:max7])
-				}
-				if chunk7 == "," {
-					address26 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == ',' {
+					address26 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address26 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::array", expected: "\",\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::array", Expected: "\",\""})
 					}
 				}
 				if address26 != nil {
@@ -628,7 +622,7 @@ func (p *JsonGoParser) _read_array() TreeNode {
 				if elements8 == nil {
 					address25 = nil
 				} else {
-					address25 = newNode7(p.slice(index15, p.offset), index15, elements8)
+					address25 = newNode7(p.slice(index15, p.offset), p.offsets.span(index15, p.offset), elements8)
 				}
 				if address25 != nil {
 					elements7 = append(elements7, address25)
@@ -637,29 +631,24 @@ func (p *JsonGoParser) _read_array() TreeNode {
 				}
 			}
 			if len(elements7) >= 0 {
-				address24 = &BaseNode{text: p.slice(index14, p.offset), offset: index14, children: elements7}
+				address24 = &BaseNode{text: p.slice(index14, p.offset), span: p.offsets.span(index14, p.offset), children: elements7}
 			} else {
 				address24 = nil
 			}
 			if address24 != nil {
 				elements6[2] = address24
 				var address28 TreeNode = nil
-				var chunk8 string = ""
-				var max8 int = p.offset + 1
-				if max8 <= len(p.input) {
-					chunk8 = string(p.input[p.offset:max8])
-				}
-				if chunk8 == "]" {
-					address28 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == ']' {
+					address28 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address28 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::array", expected: "\"]\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::array", Expected: "\"]\""})
 					}
 				}
 				if address28 != nil {
@@ -683,29 +672,24 @@ func (p *JsonGoParser) _read_array() TreeNode {
 	if elements6 == nil {
 		address21 = nil
 	} else {
-		address21 = newNode6(p.slice(index13, p.offset), index13, elements6)
+		address21 = newNode6(p.slice(index13, p.offset), p.offsets.span(index13, p.offset), elements6)
 	}
 	if address21 == nil {
 		p.offset = index12
 		var index16 int = p.offset
 		var elements9 []TreeNode = make([]TreeNode, 3)
 		var address29 TreeNode = nil
-		var chunk9 string = ""
-		var max9 int = p.offset + 1
-		if max9 <= len(p.input) {
-			chunk9 = string(p.input[p.offset:max9])
-		}
-		if chunk9 == "[" {
-			address29 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+		if p.avail(1) && p.input[p.offset] == '[' {
+			address29 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 			p.offset = p.offset + 1
 		} else {
 			address29 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::array", expected: "\"[\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::array", Expected: "\"[\""})
 			}
 		}
 		if address29 != nil {
@@ -715,22 +699,17 @@ func (p *JsonGoParser) _read_array() TreeNode {
 			if address30 != nil {
 				elements9[1] = address30
 				var address31 TreeNode = nil
-				var chunk10 string = ""
-				var max10 int = p.offset + 1
-				if max10 <= len(p.input) {
-					chunk10 = string(p.input[p.offset:max10])
-				}
-				if chunk10 == "]" {
-					address31 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == ']' {
+					address31 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address31 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::array", expected: "\"]\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::array", Expected: "\"]\""})
 					}
 				}
 				if address31 != nil {
@@ -750,26 +729,33 @@ func (p *JsonGoParser) _read_array() TreeNode {
 		if elements9 == nil {
 			address21 = nil
 		} else {
-			address21 = newNode8(p.slice(index16, p.offset), index16, elements9)
+			address21 = newNode8(p.slice(index16, p.offset), p.offsets.span(index16, p.offset), elements9)
 		}
 		if address21 == nil {
 			p.offset = index12
 		}
 	}
-	cache3[index11] = cacheEntry{node: address21, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleArray, index11, address21, p.offset, silent3 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address21
 }
 
 func (p *JsonGoParser) _read_value() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address32 TreeNode = nil
 	var index17 int = p.offset
-	var cache4 map[int]cacheEntry = p.cache["value"]
-	if cache4 == nil {
-		cache4 = make(map[int]cacheEntry)
-		p.cache["value"] = cache4
-	}
-	if entry, ok := cache4[index17]; ok {
+	var silent4 int = p.silent
+	if entry, ok := p.cache.get(RuleValue, index17, silent4 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index18 int = p.offset
@@ -825,43 +811,45 @@ func (p *JsonGoParser) _read_value() TreeNode {
 	if elements10 == nil {
 		address32 = nil
 	} else {
-		address32 = newNode9(p.slice(index18, p.offset), index18, elements10)
+		address32 = newNode9(p.slice(index18, p.offset), p.offsets.span(index18, p.offset), elements10)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleValue, index17, address32, p.offset, silent4 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache4[index17] = cacheEntry{node: address32, offset: p.offset}
 	return address32
 }
 
 func (p *JsonGoParser) _read_string() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address36 TreeNode = nil
 	var index20 int = p.offset
-	var cache5 map[int]cacheEntry = p.cache["string"]
-	if cache5 == nil {
-		cache5 = make(map[int]cacheEntry)
-		p.cache["string"] = cache5
-	}
-	if entry, ok := cache5[index20]; ok {
+	var silent5 int = p.silent
+	if entry, ok := p.cache.get(RuleString, index20, silent5 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index21 int = p.offset
 	var elements11 []TreeNode = make([]TreeNode, 3)
 	var address37 TreeNode = nil
-	var chunk11 string = ""
-	var max11 int = p.offset + 1
-	if max11 <= len(p.input) {
-		chunk11 = string(p.input[p.offset:max11])
-	}
-	if chunk11 == "\"" {
-		address37 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '"' {
+		address37 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address37 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::string", expected: "'\"'"})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::string", Expected: "'\"'"})
 		}
 	}
 	if address37 != nil {
@@ -875,38 +863,33 @@ func (p *JsonGoParser) _read_string() TreeNode {
 			var index24 int = p.offset
 			var elements13 []TreeNode = make([]TreeNode, 2)
 			var address40 TreeNode = nil
-			var chunk12 string = ""
-			var max12 int = p.offset + 1
-			if max12 <= len(p.input) {
-				chunk12 = string(p.input[p.offset:max12])
-			}
-			if chunk12 == "\\" {
-				address40 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '\\' {
+				address40 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address40 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::string", expected: "\"\\\\\""})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::string", Expected: "\"\\\\\""})
 				}
 			}
 			if address40 != nil {
 				elements13[0] = address40
 				var address41 TreeNode = nil
-				if p.offset < len(p.input) {
-					address41 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-					p.offset = p.offset + 1
+				if p.avail(1) {
+					address41 = &BaseNode{text: p.slice(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), span: p.offsets.span(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), children: nil}
+					p.offset = p.offset + runeWidth(p.peekRune(), p.offset)
 				} else {
 					address41 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::string", expected: "<any char>"})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::string", Expected: "<any char>"})
 					}
 				}
 				if address41 != nil {
@@ -922,26 +905,22 @@ func (p *JsonGoParser) _read_string() TreeNode {
 			if elements13 == nil {
 				address39 = nil
 			} else {
-				address39 = &BaseNode{text: p.slice(index24, p.offset), offset: index24, children: elements13}
+				address39 = &BaseNode{text: p.slice(index24, p.offset), span: p.offsets.span(index24, p.offset), children: elements13}
 			}
 			if address39 == nil {
 				p.offset = index23
-				var chunk13 string = ""
-				var max13 int = p.offset + 1
-				if max13 <= len(p.input) {
-					chunk13 = string(p.input[p.offset:max13])
-				}
-				if REGEX_1.MatchString(chunk13) {
-					address39 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-					p.offset = p.offset + 1
+				var end0 int = charClass1.match(p.peekRune(), p.offset)
+				if end0 >= 0 {
+					address39 = &BaseNode{text: p.slice(p.offset, end0), span: p.offsets.span(p.offset, end0), children: nil}
+					p.offset = end0
 				} else {
 					address39 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::string", expected: "[^\"]"})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::string", Expected: "[^\"]"})
 					}
 				}
 				if address39 == nil {
@@ -955,29 +934,24 @@ func (p *JsonGoParser) _read_string() TreeNode {
 			}
 		}
 		if len(elements12) >= 0 {
-			address38 = &BaseNode{text: p.slice(index22, p.offset), offset: index22, children: elements12}
+			address38 = &BaseNode{text: p.slice(index22, p.offset), span: p.offsets.span(index22, p.offset), children: elements12}
 		} else {
 			address38 = nil
 		}
 		if address38 != nil {
 			elements11[1] = address38
 			var address42 TreeNode = nil
-			var chunk14 string = ""
-			var max14 int = p.offset + 1
-			if max14 <= len(p.input) {
-				chunk14 = string(p.input[p.offset:max14])
-			}
-			if chunk14 == "\"" {
-				address42 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '"' {
+				address42 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address42 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::string", expected: "'\"'"})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::string", Expected: "'\"'"})
 				}
 			}
 			if address42 != nil {
@@ -997,70 +971,67 @@ func (p *JsonGoParser) _read_string() TreeNode {
 	if elements11 == nil {
 		address36 = nil
 	} else {
-		address36 = &BaseNode{text: p.slice(index21, p.offset), offset: index21, children: elements11}
+		address36 = &BaseNode{text: p.slice(index21, p.offset), span: p.offsets.span(index21, p.offset), children: elements11}
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleString, index20, address36, p.offset, silent5 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache5[index20] = cacheEntry{node: address36, offset: p.offset}
 	return address36
 }
 
 func (p *JsonGoParser) _read_number() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address43 TreeNode = nil
 	var index25 int = p.offset
-	var cache6 map[int]cacheEntry = p.cache["number"]
-	if cache6 == nil {
-		cache6 = make(map[int]cacheEntry)
-		p.cache["number"] = cache6
-	}
-	if entry, ok := cache6[index25]; ok {
+	var silent6 int = p.silent
+	if entry, ok := p.cache.get(RuleNumber, index25, silent6 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index26 int = p.offset
 	var elements14 []TreeNode = make([]TreeNode, 4)
 	var address44 TreeNode = nil
 	var index27 int = p.offset
-	var chunk15 string = ""
-	var max15 int = p.offset + 1
-	if max15 <= len(p.input) {
-		chunk15 = string(p.input[p.offset:max15])
-	}
-	if chunk15 == "-" {
-		address44 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '-' {
+		address44 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address44 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "\"-\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "\"-\""})
 		}
 	}
 	if address44 == nil {
-		address44 = &BaseNode{text: p.slice(index27, index27), offset: index27, children: nil}
+		address44 = &BaseNode{text: p.slice(index27, index27), span: p.offsets.span(index27, index27), children: nil}
 		p.offset = index27
 	}
 	if address44 != nil {
 		elements14[0] = address44
 		var address45 TreeNode = nil
 		var index28 int = p.offset
-		var chunk16 string = ""
-		var max16 int = p.offset + 1
-		if max16 <= len(p.input) {
-			chunk16 = string(p.input[p.offset:max16])
-		}
-		if chunk16 == "0" {
-			address45 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+		if p.avail(1) && p.input[p.offset] == '0' {
+			address45 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 			p.offset = p.offset + 1
 		} else {
 			address45 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "\"0\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "\"0\""})
 			}
 		}
 		if address45 == nil {
@@ -1068,22 +1039,18 @@ func (p *JsonGoParser) _read_number() TreeNode {
 			var index29 int = p.offset
 			var elements15 []TreeNode = make([]TreeNode, 2)
 			var address46 TreeNode = nil
-			var chunk17 string = ""
-			var max17 int = p.offset + 1
-			if max17 <= len(p.input) {
-				chunk17 = string(p.input[p.offset:max17])
-			}
-			if REGEX_2.MatchString(chunk17) {
-				address46 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-				p.offset = p.offset + 1
+			var end1 int = charClass2.match(p.peekRune(), p.offset)
+			if end1 >= 0 {
+				address46 = &BaseNode{text: p.slice(p.offset, end1), span: p.offsets.span(p.offset, end1), children: nil}
+				p.offset = end1
 			} else {
 				address46 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "[1-9]"})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "[1-9]"})
 				}
 			}
 			if address46 != nil {
@@ -1093,22 +1060,18 @@ func (p *JsonGoParser) _read_number() TreeNode {
 				var elements16 []TreeNode = nil
 				var address48 TreeNode = nil
 				for {
-					var chunk18 string = ""
-					var max18 int = p.offset + 1
-					if max18 <= len(p.input) {
-						chunk18 = string(p.input[p.offset:max18])
-					}
-					if REGEX_3.MatchString(chunk18) {
-						address48 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-						p.offset = p.offset + 1
+					var end2 int = charClass3.match(p.peekRune(), p.offset)
+					if end2 >= 0 {
+						address48 = &BaseNode{text: p.slice(p.offset, end2), span: p.offsets.span(p.offset, end2), children: nil}
+						p.offset = end2
 					} else {
 						address48 = nil
-						if p.offset > p.failure.offset {
-							p.failure.offset = p.offset
-							p.failure.expected = nil
-						}
-						if p.offset == p.failure.offset {
-							p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "[0-9]"})
+						if p.offset >= p.failure.offset && p.silent == 0 {
+							if p.offset > p.failure.offset {
+								p.failure.offset = p.offset
+								p.failure.expected = p.failure.expected[:0]
+							}
+							p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "[0-9]"})
 						}
 					}
 					if address48 != nil {
@@ -1118,7 +1081,7 @@ func (p *JsonGoParser) _read_number() TreeNode {
 					}
 				}
 				if len(elements16) >= 0 {
-					address47 = &BaseNode{text: p.slice(index30, p.offset), offset: index30, children: elements16}
+					address47 = &BaseNode{text: p.slice(index30, p.offset), span: p.offsets.span(index30, p.offset), children: elements16}
 				} else {
 					address47 = nil
 				}
@@ -1135,7 +1098,7 @@ func (p *JsonGoParser) _read_number() TreeNode {
 			if elements15 == nil {
 				address45 = nil
 			} else {
-				address45 = &BaseNode{text: p.slice(index29, p.offset), offset: index29, children: elements15}
+				address45 = &BaseNode{text: p.slice(index29, p.offset), span: p.offsets.span(index29, p.offset), children: elements15}
 			}
 			if address45 == nil {
 				p.offset = index28
@@ -1148,22 +1111,17 @@ func (p *JsonGoParser) _read_number() TreeNode {
 			var index32 int = p.offset
 			var elements17 []TreeNode = make([]TreeNode, 2)
 			var address50 TreeNode = nil
-			var chunk19 string = ""
-			var max19 int = p.offset + 1
-			if max19 <= len(p.input) {
-				chunk19 = string(p.input[p.offset:max19])
-			}
-			if chunk19 == "." {
-				address50 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '.' {
+				address50 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address50 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "\".\""})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "\".\""})
 				}
 			}
 			if address50 != nil {
@@ -1173,22 +1131,18 @@ func (p *JsonGoParser) _read_number() TreeNode {
 				var elements18 []TreeNode = nil
 				var address52 TreeNode = nil
 				for {
-					var chunk20 string = ""
-					var max20 int = p.offset + 1
-					if max20 <= len(p.input) {
-						chunk20 = string(p.input[p.offset:max20])
-					}
-					if REGEX_4.MatchString(chunk20) {
-						address52 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-						p.offset = p.offset + 1
+					var end3 int = charClass3.match(p.peekRune(), p.offset)
+					if end3 >= 0 {
+						address52 = &BaseNode{text: p.slice(p.offset, end3), span: p.offsets.span(p.offset, end3), children: nil}
+						p.offset = end3
 					} else {
 						address52 = nil
-						if p.offset > p.failure.offset {
-							p.failure.offset = p.offset
-							p.failure.expected = nil
-						}
-						if p.offset == p.failure.offset {
-							p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "[0-9]"})
+						if p.offset >= p.failure.offset && p.silent == 0 {
+							if p.offset > p.failure.offset {
+								p.failure.offset = p.offset
+								p.failure.expected = p.failure.expected[:0]
+							}
+							p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "[0-9]"})
 						}
 					}
 					if address52 != nil {
@@ -1198,7 +1152,7 @@ func (p *JsonGoParser) _read_number() TreeNode {
 					}
 				}
 				if len(elements18) >= 1 {
-					address51 = &BaseNode{text: p.slice(index33, p.offset), offset: index33, children: elements18}
+					address51 = &BaseNode{text: p.slice(index33, p.offset), span: p.offsets.span(index33, p.offset), children: elements18}
 				} else {
 					address51 = nil
 				}
@@ -1215,10 +1169,10 @@ func (p *JsonGoParser) _read_number() TreeNode {
 			if elements17 == nil {
 				address49 = nil
 			} else {
-				address49 = &BaseNode{text: p.slice(index32, p.offset), offset: index32, children: elements17}
+				address49 = &BaseNode{text: p.slice(index32, p.offset), span: p.offsets.span(index32, p.offset), children: elements17}
 			}
 			if address49 == nil {
-				address49 = &BaseNode{text: p.slice(index31, index31), offset: index31, children: nil}
+				address49 = &BaseNode{text: p.slice(index31, index31), span: p.offsets.span(index31, index31), children: nil}
 				p.offset = index31
 			}
 			if address49 != nil {
@@ -1229,42 +1183,32 @@ func (p *JsonGoParser) _read_number() TreeNode {
 				var elements19 []TreeNode = make([]TreeNode, 3)
 				var address54 TreeNode = nil
 				var index36 int = p.offset
-				var chunk21 string = ""
-				var max21 int = p.offset + 1
-				if max21 <= len(p.input) {
-					chunk21 = string(p.input[p.offset:max21])
-				}
-				if chunk21 == "e" {
-					address54 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == 'e' {
+					address54 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address54 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "\"e\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "\"e\""})
 					}
 				}
 				if address54 == nil {
 					p.offset = index36
-					var chunk22 string = ""
-					var max22 int = p.offset + 1
-					if max22 <= len(p.input) {
-						chunk22 = string(p.input[p.offset:max22])
-					}
-					if chunk22 == "E" {
-						address54 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+					if p.avail(1) && p.input[p.offset] == 'E' {
+						address54 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 						p.offset = p.offset + 1
 					} else {
 						address54 = nil
-						if p.offset > p.failure.offset {
-							p.failure.offset = p.offset
-							p.failure.expected = nil
-						}
-						if p.offset == p.failure.offset {
-							p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "\"E\""})
+						if p.offset >= p.failure.offset && p.silent == 0 {
+							if p.offset > p.failure.offset {
+								p.failure.offset = p.offset
+								p.failure.expected = p.failure.expected[:0]
+							}
+							p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "\"E\""})
 						}
 					}
 					if address54 == nil {
@@ -1275,62 +1219,47 @@ func (p *JsonGoParser) _read_number() TreeNode {
 					elements19[0] = address54
 					var address55 TreeNode = nil
 					var index37 int = p.offset
-					var chunk23 string = ""
-					var max23 int = p.offset + 1
-					if max23 <= len(p.input) {
-						chunk23 = string(p.input[p.offset:max23])
-					}
-					if chunk23 == "+" {
-						address55 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+					if p.avail(1) && p.input[p.offset] == '+' {
+						address55 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 						p.offset = p.offset + 1
 					} else {
 						address55 = nil
-						if p.offset > p.failure.offset {
-							p.failure.offset = p.offset
-							p.failure.expected = nil
-						}
-						if p.offset == p.failure.offset {
-							p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "\"+\""})
+						if p.offset >= p.failure.offset && p.silent == 0 {
+							if p.offset > p.failure.offset {
+								p.failure.offset = p.offset
+								p.failure.expected = p.failure.expected[:0]
+							}
+							p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "\"+\""})
 						}
 					}
 					if address55 == nil {
 						p.offset = index37
-						var chunk24 string = ""
-						var max24 int = p.offset + 1
-						if max24 <= len(p.input) {
-							chunk24 = string(p.input[p.offset:max24])
-						}
-						if chunk24 == "-" {
-							address55 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+						if p.avail(1) && p.input[p.offset] == '-' {
+							address55 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 							p.offset = p.offset + 1
 						} else {
 							address55 = nil
-							if p.offset > p.failure.offset {
-								p.failure.offset = p.offset
-								p.failure.expected = nil
-							}
-							if p.offset == p.failure.offset {
-								p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "\"-\""})
+							if p.offset >= p.failure.offset && p.silent == 0 {
+								if p.offset > p.failure.offset {
+									p.failure.offset = p.offset
+									p.failure.expected = p.failure.expected[:0]
+								}
+								p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "\"-\""})
 							}
 						}
 						if address55 == nil {
 							p.offset = index37
-							var chunk25 string = ""
-							var max25 int = p.offset + 0
-							if max25 <= len(p.input) {
-								chunk25 = string(p.input[p.offset:max25])
-							}
-							if chunk25 == "" {
-								address55 = &BaseNode{text: p.slice(p.offset, p.offset + 0), offset: p.offset, children: nil}
+							if p.avail(0) && p.input[p.offset:p.offset+0] == "" {
+								address55 = &BaseNode{text: p.slice(p.offset, p.offset + 0), span: p.offsets.span(p.offset, p.offset + 0), children: nil}
 								p.offset = p.offset + 0
 							} else {
 								address55 = nil
-								if p.offset > p.failure.offset {
-									p.failure.offset = p.offset
-									p.failure.expected = nil
-								}
-								if p.offset == p.failure.offset {
-									p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "\"\""})
+								if p.offset >= p.failure.offset && p.silent == 0 {
+									if p.offset > p.failure.offset {
+										p.failure.offset = p.offset
+										p.failure.expected = p.failure.expected[:0]
+									}
+									p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "\"\""})
 								}
 							}
 							if address55 == nil {
@@ -1345,22 +1274,18 @@ func (p *JsonGoParser) _read_number() TreeNode {
 						var elements20 []TreeNode = nil
 						var address57 TreeNode = nil
 						for {
-							var chunk26 string = ""
-							var max26 int = p.offset + 1
-							if max26 <= len(p.input) {
-								chunk26 = string(p.input[p.offset:max26])
-							}
-							if REGEX_5.MatchString(chunk26) {
-								address57 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-								p.offset = p.offset + 1
+							var end4 int = charClass3.match(p.peekRune(), p.offset)
+							if end4 >= 0 {
+								address57 = &BaseNode{text: p.slice(p.offset, end4), span: p.offsets.span(p.offset, end4), children: nil}
+								p.offset = end4
 							} else {
 								address57 = nil
-								if p.offset > p.failure.offset {
-									p.failure.offset = p.offset
-									p.failure.expected = nil
-								}
-								if p.offset == p.failure.offset {
-									p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::number", expected: "[0-9]"})
+								if p.offset >= p.failure.offset && p.silent == 0 {
+									if p.offset > p.failure.offset {
+										p.failure.offset = p.offset
+										p.failure.expected = p.failure.expected[:0]
+									}
+									p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::number", Expected: "[0-9]"})
 								}
 							}
 							if address57 != nil {
@@ -1370,7 +1295,7 @@ func (p *JsonGoParser) _read_number() TreeNode {
 							}
 						}
 						if len(elements20) >= 1 {
-							address56 = &BaseNode{text: p.slice(index38, p.offset), offset: index38, children: elements20}
+							address56 = &BaseNode{text: p.slice(index38, p.offset), span: p.offsets.span(index38, p.offset), children: elements20}
 						} else {
 							address56 = nil
 						}
@@ -1391,10 +1316,10 @@ func (p *JsonGoParser) _read_number() TreeNode {
 				if elements19 == nil {
 					address53 = nil
 				} else {
-					address53 = &BaseNode{text: p.slice(index35, p.offset), offset: index35, children: elements19}
+					address53 = &BaseNode{text: p.slice(index35, p.offset), span: p.offsets.span(index35, p.offset), children: elements19}
 				}
 				if address53 == nil {
-					address53 = &BaseNode{text: p.slice(index34, index34), offset: index34, children: nil}
+					address53 = &BaseNode{text: p.slice(index34, index34), span: p.offsets.span(index34, index34), children: nil}
 					p.offset = index34
 				}
 				if address53 != nil {
@@ -1418,137 +1343,139 @@ func (p *JsonGoParser) _read_number() TreeNode {
 	if elements14 == nil {
 		address43 = nil
 	} else {
-		address43 = &BaseNode{text: p.slice(index26, p.offset), offset: index26, children: elements14}
+		address43 = &BaseNode{text: p.slice(index26, p.offset), span: p.offsets.span(index26, p.offset), children: elements14}
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleNumber, index25, address43, p.offset, silent6 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache6[index25] = cacheEntry{node: address43, offset: p.offset}
 	return address43
 }
 
 func (p *JsonGoParser) _read_boolean_() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address58 TreeNode = nil
 	var index39 int = p.offset
-	var cache7 map[int]cacheEntry = p.cache["boolean_"]
-	if cache7 == nil {
-		cache7 = make(map[int]cacheEntry)
-		p.cache["boolean_"] = cache7
-	}
-	if entry, ok := cache7[index39]; ok {
+	var silent7 int = p.silent
+	if entry, ok := p.cache.get(RuleBoolean, index39, silent7 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index40 int = p.offset
-	var chunk27 string = ""
-	var max27 int = p.offset + 4
-	if max27 <= len(p.input) {
-		chunk27 = string(p.input[p.offset:max27])
-	}
-	if chunk27 == "true" {
-		address58 = &BaseNode{text: p.slice(p.offset, p.offset + 4), offset: p.offset, children: nil}
+	if p.avail(4) && p.input[p.offset:p.offset+4] == "true" {
+		address58 = &BaseNode{text: p.slice(p.offset, p.offset + 4), span: p.offsets.span(p.offset, p.offset + 4), children: nil}
 		p.offset = p.offset + 4
 	} else {
 		address58 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::boolean_", expected: "\"true\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::boolean_", Expected: "\"true\""})
 		}
 	}
 	if address58 == nil {
 		p.offset = index40
-		var chunk28 string = ""
-		var max28 int = p.offset + 5
-		if max28 <= len(p.input) {
-			chunk28 = string(p.input[p.offset:max28])
-		}
-		if chunk28 == "false" {
-			address58 = &BaseNode{text: p.slice(p.offset, p.offset + 5), offset: p.offset, children: nil}
+		if p.avail(5) && p.input[p.offset:p.offset+5] == "false" {
+			address58 = &BaseNode{text: p.slice(p.offset, p.offset + 5), span: p.offsets.span(p.offset, p.offset + 5), children: nil}
 			p.offset = p.offset + 5
 		} else {
 			address58 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::boolean_", expected: "\"false\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::boolean_", Expected: "\"false\""})
 			}
 		}
 		if address58 == nil {
 			p.offset = index40
 		}
 	}
-	cache7[index39] = cacheEntry{node: address58, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleBoolean, index39, address58, p.offset, silent7 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address58
 }
 
 func (p *JsonGoParser) _read_null_() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address59 TreeNode = nil
 	var index41 int = p.offset
-	var cache8 map[int]cacheEntry = p.cache["null_"]
-	if cache8 == nil {
-		cache8 = make(map[int]cacheEntry)
-		p.cache["null_"] = cache8
-	}
-	if entry, ok := cache8[index41]; ok {
+	var silent8 int = p.silent
+	if entry, ok := p.cache.get(RuleNull, index41, silent8 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
-	var chunk29 string = ""
-	var max29 int = p.offset + 4
-	if max29 <= len(p.input) {
-		chunk29 = string(p.input[p.offset:max29])
-	}
-	if chunk29 == "null" {
-		address59 = &BaseNode{text: p.slice(p.offset, p.offset + 4), offset: p.offset, children: nil}
+	if p.avail(4) && p.input[p.offset:p.offset+4] == "null" {
+		address59 = &BaseNode{text: p.slice(p.offset, p.offset + 4), span: p.offsets.span(p.offset, p.offset + 4), children: nil}
 		p.offset = p.offset + 4
 	} else {
 		address59 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::null_", expected: "\"null\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::null_", Expected: "\"null\""})
 		}
 	}
-	cache8[index41] = cacheEntry{node: address59, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleNull, index41, address59, p.offset, silent8 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address59
 }
 
 func (p *JsonGoParser) _read___() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address60 TreeNode = nil
 	var index42 int = p.offset
-	var cache9 map[int]cacheEntry = p.cache["__"]
-	if cache9 == nil {
-		cache9 = make(map[int]cacheEntry)
-		p.cache["__"] = cache9
-	}
-	if entry, ok := cache9[index42]; ok {
+	var silent9 int = p.silent
+	if entry, ok := p.cache.get(rule9, index42, silent9 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index43 int = p.offset
 	var elements21 []TreeNode = nil
 	var address61 TreeNode = nil
 	for {
-		var chunk30 string = ""
-		var max30 int = p.offset + 1
-		if max30 <= len(p.input) {
-			chunk30 = string(p.input[p.offset:max30])
-		}
-		if REGEX_6.MatchString(chunk30) {
-			address61 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-			p.offset = p.offset + 1
+		var end5 int = charClass4.match(p.peekRune(), p.offset)
+		if end5 >= 0 {
+			address61 = &BaseNode{text: p.slice(p.offset, end5), span: p.offsets.span(p.offset, end5), children: nil}
+			p.offset = end5
 		} else {
 			address61 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson::__", expected: "[\\s]"})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyJson::__", Expected: "[\\s]"})
 			}
 		}
 		if address61 != nil {
@@ -1558,21 +1485,101 @@ func (p *JsonGoParser) _read___() TreeNode {
 		}
 	}
 	if len(elements21) >= 0 {
-		address60 = &BaseNode{text: p.slice(index43, p.offset), offset: index43, children: elements21}
+		address60 = &BaseNode{text: p.slice(index43, p.offset), span: p.offsets.span(index43, p.offset), children: elements21}
 	} else {
 		address60 = nil
 	}
-	cache9[index42] = cacheEntry{node: address60, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(rule9, index42, address60, p.offset, silent9 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address60
 }
 
-func New(input string, actions Actions) *JsonGoParser {
-	return &JsonGoParser{
-		input: []rune(input),
-		inputString: input,
+// Rule identifies one of the rules in the grammar.
+type Rule int
+
+const (
+	RuleDocument Rule = iota
+	RuleObject
+	RulePair
+	RuleArray
+	RuleValue
+	RuleString
+	RuleNumber
+	RuleBoolean
+	RuleNull
+	rule9
+	numRules = iota
+)
+
+const minReadSize = 4096
+
+const seenAhead = 16
+
+var ruleNames = [numRules]string{
+	"document",
+	"object",
+	"pair",
+	"array",
+	"value",
+	"string",
+	"number",
+	"boolean_",
+	"null_",
+	"__",
+}
+
+// ruleReaders holds the method that matches each rule.
+var ruleReaders = [numRules]func(*JsonGoParser) TreeNode{
+	(*JsonGoParser)._read_document,
+	(*JsonGoParser)._read_object,
+	(*JsonGoParser)._read_pair,
+	(*JsonGoParser)._read_array,
+	(*JsonGoParser)._read_value,
+	(*JsonGoParser)._read_string,
+	(*JsonGoParser)._read_number,
+	(*JsonGoParser)._read_boolean_,
+	(*JsonGoParser)._read_null_,
+	(*JsonGoParser)._read___,
+}
+
+// String returns the name of the rule in the grammar.
+func (r Rule) String() string {
+	if r < 0 || r >= numRules {
+		return fmt.Sprintf("Rule(%d)", int(r))
+	}
+	return ruleNames[r]
+}
+
+var memoDefaults = [numRules]bool{
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+}
+
+func New(input string, actions Actions, opts ...Option) *JsonGoParser {
+	p := &JsonGoParser{
+		input: input,
 		actions: actions,
-		cache: make(map[string]map[int]cacheEntry),
+		failure: failureState{expected: make([]Expectation, 0, 8)},
+	}
+	for _, opt := range opts {
+		opt(&p.opts)
 	}
+	p.offsets = newOffsetIndex(input, p.opts.byteOffsets)
+	p.cache = newMemoTable(&p.opts)
+	p.guarded = p.opts.limits != (Limits{})
+	return p
 }
 
 func (p *JsonGoParser) WithTypes(types map[string]NodeExtender) *JsonGoParser {
@@ -1580,72 +1587,263 @@ func (p *JsonGoParser) WithTypes(types map[string]NodeExtender) *JsonGoParser {
 	return p
 }
 
-func Parse(input string, actions Actions, types map[string]NodeExtender) (TreeNode, error) {
-	parser := New(input, actions)
+func Parse(input string, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	parser := New(input, actions, opts...)
 	if types != nil {
 		parser.types = types
 	}
 	return parser.Parse()
 }
 
+// ParseBytes parses a UTF-8 encoded byte slice. The input is copied once,
+// so the slice may be reused after ParseBytes returns; the text of the
+// returned nodes refers to the copy.
+func ParseBytes(input []byte, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	return Parse(string(input), actions, types, opts...)
+}
+
+// NewReader returns a parser that reads its input from r. Input is read
+// as the parser needs it, and read errors are returned by Parse in place
+// of a *ParseError.
+func NewReader(r io.Reader, actions Actions, opts ...Option) *JsonGoParser {
+	p := New("", actions, opts...)
+	p.reader = r
+	p.open = true
+	return p
+}
+
+// ParseReader parses UTF-8 encoded input read from r. See NewReader.
+func ParseReader(r io.Reader, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	parser := NewReader(r, actions, opts...)
+	if types != nil {
+		parser.types = types
+	}
+	return parser.Parse()
+}
+
+// ParseRule parses input starting from rule, rather than from the first
+// rule in the grammar.
+func ParseRule(rule Rule, input string, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	parser := New(input, actions, opts...)
+	if types != nil {
+		parser.types = types
+	}
+	return parser.ParseRule(rule)
+}
+
 func (p *JsonGoParser) Parse() (TreeNode, error) {
-	node := p._read_document()
+	return p.parseRule(RuleDocument)
+}
+
+// ParseRule is like Parse, but starts from rule. It may be used with a
+// parser created by New or NewReader.
+func (p *JsonGoParser) ParseRule(rule Rule) (TreeNode, error) {
+	if p.stream != nil {
+		return nil, fmt.Errorf("ParseRule cannot be used with a parser created by NewStream")
+	}
+	if rule < 0 || rule >= numRules {
+		return nil, fmt.Errorf("unknown rule %d", int(rule))
+	}
+	return p.parseRule(rule)
+}
+
+// parseRule parses the whole input from rule. Each parse starts afresh,
+// except the passes of a parser created by NewStream, which carry on
+// from the state resume restores.
+func (p *JsonGoParser) parseRule(rule Rule) (TreeNode, error) {
+	if p.stream == nil {
+		p.startOver()
+	}
+	return p.parseInput(rule)
+}
+
+func (p *JsonGoParser) parseInput(rule Rule) (TreeNode, error) {
+	if err := p.begin(); err != nil {
+		return nil, err
+	}
+	p.cache.reserve(len(p.input))
+	node := ruleReaders[rule](p)
+	if err := p.finish(rule, node != nil, false); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// finish returns the error for a parse from start that matched if matched,
+// or nil if it succeeded. A prefix parse succeeds if start matched; any
+// other parse must also have reached the end of the input.
+func (p *JsonGoParser) finish(start Rule, matched, prefix bool) error {
+	if p.stopErr != nil {
+		return p.stopErr
+	}
+	complete := matched && (prefix || !p.avail(1))
+	if p.cache.truncated {
+		return ErrIncomplete
+	}
+	if p.readErr != nil {
+		return p.readErr
+	}
 	if p.actionErr != nil {
-		return nil, p.actionErr
+		return p.actionErr
 	}
-	if node != nil && p.offset == len(p.input) {
-		return node, nil
+	if complete {
+		return nil
+	}
+	if matched && p.failure.offset < p.offset {
+		// The failures before the end of the match were all recovered from,
+		// so the error is the input left over.
+		p.failure.offset = p.offset
+		p.failure.expected = p.failure.expected[:0]
 	}
 	if len(p.failure.expected) == 0 {
+		rule := "CanopyJson"
+		if start != RuleDocument {
+			rule += "::" + ruleNames[start]
+		}
 		p.failure.offset = p.offset
-		p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyJson", expected: "<EOF>"})
+		p.failure.expected = append(p.failure.expected, Expectation{Rule: rule, Expected: "<EOF>"})
 	}
-	return nil, p.newParseError()
+	return p.newParseError(matched)
 }
 
-func (p *JsonGoParser) newParseError() error {
-	line, column := 1, 1
-	for i, r := range p.input {
-		if i >= p.failure.offset {
-			break
-		}
-		if r == '\n' {
-			line++
-			column = 1
-		} else {
-			column++
-		}
+// newParseError describes the furthest failure. stopped reports that the
+// root rule matched, and the parse only failed because input was left over.
+func (p *JsonGoParser) newParseError(stopped bool) error {
+	pos := p.lineIndex().position(p.failure.offset)
+	status := "parse error"
+	if stopped {
+		status = "parse stopped early"
 	}
-	message := fmt.Sprintf("parse error at line %d, column %d", line, column)
-	if len(p.failure.expected) > 0 {
-		message += ": expected "
-		for i, exp := range p.failure.expected {
-			if i > 0 {
-				if i == len(p.failure.expected)-1 {
-					message += " or "
-				} else {
-					message += ", "
-				}
-			}
-			message += fmt.Sprintf("%s from %s", exp.expected, exp.rule)
-		}
+	expected := sortExpectations(p.failure.expected, pos.Offset)
+	message := fmt.Sprintf("%s at line %d, column %d", status, pos.Line, pos.Column)
+	if len(expected) > 0 {
+		message += ": " + describeExpected(expected)
+	}
+	if p.open && p.stream == nil {
+		// Read far enough past the failure to describe what is there.
+		p.fill(p.failure.offset + maxFoundRunes*utf8.UTFMax)
+	}
+	found := describeFound(p.input[p.failure.offset:])
+	message += " but found " + found
+	kind, end := UnexpectedInput, 0
+	if stopped {
+		kind, end = TrailingInput, p.offsets.convert(p.offset)
+	}
+	if p.failure.offset == len(p.input) {
+		kind = UnexpectedEOF
 	}
-	expected := make([]expectation, len(p.failure.expected))
-	copy(expected, p.failure.expected)
 	return &ParseError{
-		Input: p.inputString,
-		Offset: p.failure.offset,
-		Line: line,
-		Column: column,
+		Input: p.input,
+		Offset: pos.Offset,
+		Line: pos.Line,
+		Column: pos.Column,
+		UTF16Column: pos.UTF16Column,
 		Expected: expected,
+		Found: found,
 		Message: message,
+		Kind: kind,
+		End: end,
+	}
+}
+
+// Position returns the line and column of offset, which is in the same
+// units as node offsets. The line index is built on the first call.
+func (p *JsonGoParser) Position(offset int) Position {
+	return p.lineIndex().Position(offset)
+}
+
+func (p *JsonGoParser) lineIndex() *LineIndex {
+	if p.lines == nil {
+		p.lines = newLineIndex(&p.offsets)
+	}
+	return p.lines
+}
+
+// peek returns the input, first making n bytes at the current offset
+// available if it can.
+func (p *JsonGoParser) peek(n int) string {
+	if p.offset+n > p.seen {
+		p.fill(p.offset + n)
+	}
+	return p.input
+}
+
+// avail reports whether n bytes of input are available at the current
+// offset.
+func (p *JsonGoParser) avail(n int) bool {
+	return p.offset+n <= p.seen || p.fill(p.offset+n)
+}
+
+// peekRune is like peek, but makes available only the bytes of the rune
+// at the current offset, so that a parser fed by Feed does not wait for
+// input it does not need.
+func (p *JsonGoParser) peekRune() string {
+	if p.offset+utf8.UTFMax > p.seen {
+		p.fillRune()
+	}
+	return p.input
+}
+
+func (p *JsonGoParser) fillRune() {
+	if p.avail(1) && p.input[p.offset] >= utf8.RuneSelf {
+		p.avail(leadWidth(p.input[p.offset]))
+	}
+}
+
+// fill makes the input before want available, first reading more if it
+// may follow, and reports whether it is there. Terminals only call fill
+// once they look past p.seen, which it moves seenAhead bytes beyond want,
+// so p.cache.reach can record how far the parse has looked without
+// slowing down the rest.
+func (p *JsonGoParser) fill(want int) bool {
+	if want > len(p.input) && p.open {
+		p.read(want)
+	}
+	p.seen = min(want+seenAhead, len(p.input))
+	p.cache.reach = max(p.cache.reach, p.seen, want)
+	return want <= len(p.input)
+}
+
+// read reads until the input holds want bytes or the reader is exhausted.
+// Each read at least doubles the input, so that copying the buffer into
+// p.input costs linear time overall. A parser created by NewStream gets
+// its input from Feed instead.
+func (p *JsonGoParser) read(want int) {
+	if p.stream != nil {
+		p.more(want)
+		return
+	}
+	target := max(want, 2*len(p.buf), minReadSize)
+	if limit := p.opts.limits.MaxInputSize; limit > 0 {
+		// One byte past the limit is enough to tell the input is too long.
+		target = min(target, limit+1)
+	}
+	for len(p.buf) < target && p.reader != nil {
+		if len(p.buf) == cap(p.buf) {
+			p.buf = slices.Grow(p.buf, target-len(p.buf))
+		}
+		n, err := p.reader.Read(p.buf[len(p.buf):cap(p.buf)])
+		p.buf = p.buf[:len(p.buf)+n]
+		if err != nil {
+			if err != io.EOF {
+				p.readErr = err
+			}
+			p.reader = nil
+			p.open = false
+		}
+	}
+	if p.inputTooLong(len(p.buf)) {
+		p.reader = nil
+		p.open = false
 	}
+	p.input = string(p.buf)
+	p.offsets.extend(p.input, !p.open)
 }
 
 func (p *JsonGoParser) slice(start, end int) string {
 	if start < 0 { start = 0 }
 	if end > len(p.input) { end = len(p.input) }
 	if start > end { start = end }
-	return string(p.input[start:end])
+	return p.input[start:end]
 }
 
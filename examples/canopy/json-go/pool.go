@@ -0,0 +1,82 @@
+// This file was generated from examples/canopy/json.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package jsongoparser
+
+import (
+	"io"
+	"sync"
+)
+
+// Parser parses input with a fixed set of actions, types and options. Unlike
+// the parser returned by New, it may be used for any number of parses, and
+// is safe for concurrent use as long as its actions and types are. A
+// MemoProfile is not, so WithMemoProfile should only be given to a Parser
+// that parses one input at a time.
+//
+// Each parse gets its state from a pool, so the memo table, which takes up
+// most of the memory a parse needs, is recycled by later parses.
+type Parser struct {
+	actions Actions
+	types   map[string]NodeExtender
+	opts    options
+	pool    sync.Pool
+}
+
+// NewParser returns a Parser that passes actions and types to every parse.
+func NewParser(actions Actions, types map[string]NodeExtender, opts ...Option) *Parser {
+	p := &Parser{actions: actions, types: types}
+	for _, opt := range opts {
+		opt(&p.opts)
+	}
+	return p
+}
+
+// Parse parses input, returning what the package function Parse would.
+func (p *Parser) Parse(input string) (TreeNode, error) {
+	parser := p.get(input)
+	defer p.put(parser)
+	return parser.Parse()
+}
+
+// ParseReader parses input read from r. See NewReader.
+func (p *Parser) ParseReader(r io.Reader) (TreeNode, error) {
+	parser := p.get("")
+	defer p.put(parser)
+	parser.reader = r
+	parser.open = true
+	return parser.Parse()
+}
+
+func (p *Parser) get(input string) *JsonGoParser {
+	parser, _ := p.pool.Get().(*JsonGoParser)
+	if parser == nil {
+		parser = &JsonGoParser{
+			actions: p.actions,
+			types:   p.types,
+			opts:    p.opts,
+			failure: failureState{expected: make([]Expectation, 0, 8)},
+		}
+		parser.cache = newMemoTable(&parser.opts)
+	}
+	parser.input = input
+	parser.offsets = newOffsetIndex(input, p.opts.byteOffsets)
+	parser.guarded = p.opts.limits != (Limits{})
+	return parser
+}
+
+// put returns a parser to the pool, keeping its memo table, unless a large
+// input grew it, and its expectation list, but dropping everything that
+// refers to the input or the tree, so that the pool does not keep them
+// alive.
+func (p *Parser) put(parser *JsonGoParser) {
+	parser.cache.release()
+	*parser = JsonGoParser{
+		actions: p.actions,
+		types:   p.types,
+		opts:    p.opts,
+		cache:   parser.cache,
+		failure: failureState{expected: parser.failure.expected[:0]},
+	}
+	p.pool.Put(parser)
+}
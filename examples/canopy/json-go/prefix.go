@@ -0,0 +1,88 @@
+// This file was generated from examples/canopy/json.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package jsongoparser
+
+import "fmt"
+
+// ParsePrefix parses the start of input, stopping wherever the root rule
+// stops matching rather than failing unless it reaches the end. It returns
+// the tree along with the number of characters it consumed, or bytes if
+// opts include WithByteOffsets.
+func ParsePrefix(input string, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, int, error) {
+	parser := New(input, actions, opts...)
+	if types != nil {
+		parser.types = types
+	}
+	return parser.ParsePrefix()
+}
+
+// ParseAt is like ParsePrefix, but starts at offset in input, for example to
+// parse an expression embedded in a larger document. Offsets in the tree and
+// in errors still count from the start of input.
+func ParseAt(input string, offset int, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, int, error) {
+	parser := New(input, actions, opts...)
+	if types != nil {
+		parser.types = types
+	}
+	return parser.ParseAt(offset)
+}
+
+// ParsePrefix is like Parse, but only the root rule has to match, not the
+// whole input. It returns the number of characters consumed along with the
+// tree. A parser created by NewReader reads no more of its input than the
+// root rule needs.
+func (p *JsonGoParser) ParsePrefix() (TreeNode, int, error) {
+	if p.stream != nil {
+		return nil, 0, fmt.Errorf("ParsePrefix cannot be used with a parser created by NewStream")
+	}
+	p.startOver()
+	return p.parsePrefix()
+}
+
+// ParseAt is like ParsePrefix, but starts at offset, which is in the same
+// units as node offsets. It may be called more than once on a parser created
+// by New, and results memoized by one call are reused by the next.
+func (p *JsonGoParser) ParseAt(offset int) (TreeNode, int, error) {
+	if p.open || p.buf != nil || p.stream != nil {
+		return nil, 0, fmt.Errorf("ParseAt requires a parser created by New")
+	}
+	if offset < 0 || offset > p.offsets.convert(len(p.input)) {
+		return nil, 0, fmt.Errorf("offset %d is outside the input", offset)
+	}
+	if p.actionErr != nil || p.stopErr != nil {
+		// Results that failed because an action did, or because the parse
+		// gave up, are in the memo as failures, so none of them can be
+		// trusted.
+		p.cache.reset()
+	}
+	reused := p.cache.count > 0
+	node, n, err := p.parseFrom(p.offsets.byteOffset(offset))
+	if _, ok := err.(*ParseError); ok && reused {
+		// Failures found in the memo are not recorded again, so parse again
+		// without the results of earlier calls to report the same error as
+		// a new parser would.
+		p.cache.reset()
+		node, n, err = p.parseFrom(p.offsets.byteOffset(offset))
+	}
+	return node, n, err
+}
+
+// parseFrom starts a prefix parse at the given byte offset.
+func (p *JsonGoParser) parseFrom(start int) (TreeNode, int, error) {
+	p.restart(start)
+	return p.parsePrefix()
+}
+
+func (p *JsonGoParser) parsePrefix() (TreeNode, int, error) {
+	if err := p.begin(); err != nil {
+		return nil, 0, err
+	}
+	start := p.offset
+	p.cache.reserve(len(p.input))
+	node := p._read_document()
+	if err := p.finish(RuleDocument, node != nil, true); err != nil {
+		return nil, 0, err
+	}
+	return node, p.offsets.convert(p.offset) - p.offsets.convert(start), nil
+}
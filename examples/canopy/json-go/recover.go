@@ -0,0 +1,76 @@
+// This file was generated from examples/canopy/json.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package jsongoparser
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// ActionError is returned when an action or a NodeExtender panics. The parse
+// stops as it does when an action returns an error, and the panic is
+// recovered unless the parser was created with WithPanics.
+type ActionError struct {
+	Action   string // the action's name in the grammar, or the type name for a NodeExtender
+	Extender bool   // whether Action names a NodeExtender rather than an action
+	Rule     Rule   // the rule the action or type belongs to
+	Start    int    // the offsets of the text passed to the action or extender
+	End      int
+	Line     int // the line and column of Start
+	Column   int
+	Value    any    // the value passed to panic
+	Stack    []byte // the stack of the goroutine that panicked
+}
+
+func (e *ActionError) Error() string {
+	kind := "action"
+	if e.Extender {
+		kind = "node extender"
+	}
+	return fmt.Sprintf("%s %s in rule %s panicked at line %d, column %d: %v", kind, e.Action, e.Rule, e.Line, e.Column, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, such as a runtime.Error.
+func (e *ActionError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// WithPanics lets panics in actions and NodeExtenders propagate out of the
+// parse, rather than returning them as an *ActionError.
+func WithPanics() Option {
+	return func(o *options) {
+		o.panics = true
+	}
+}
+
+// recoverAction is deferred around calls to actions and extenders, and turns
+// a panic into an *ActionError. start and end are converted offsets.
+func (p *JsonGoParser) recoverAction(action string, extender bool, rule Rule, start, end int, err *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	pos := p.lineIndex().Position(start)
+	*err = &ActionError{
+		Action:   action,
+		Extender: extender,
+		Rule:     rule,
+		Start:    start,
+		End:      end,
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Value:    value,
+		Stack:    debug.Stack(),
+	}
+}
+
+// callExtender applies a NodeExtender to a node that ends at the current
+// offset.
+func (p *JsonGoParser) callExtender(extender NodeExtender, name string, rule Rule, node TreeNode) (extended TreeNode, err error) {
+	if !p.opts.panics {
+		defer p.recoverAction(name, true, rule, node.Offset(), p.offsets.convert(p.offset), &err)
+	}
+	return extender(node), nil
+}
@@ -11,11 +11,26 @@ type TreeNode interface {
 	Children() []TreeNode
 }
 
+// Span locates a node in the input. Start and End are in the same units as
+// Offset: runes, or bytes if the parser was created with WithByteOffsets.
+// ByteStart and ByteEnd are always byte offsets, for slicing the input.
+type Span struct {
+	Start     int
+	End       int
+	ByteStart int
+	ByteEnd   int
+}
+
 // BaseNode is embedded by generated nodes to implement TreeNode.
+//
+// Nodes returned by actions can embed a zero BaseNode too. The parser fills
+// in its span with the span the action matched, so End and Span work without
+// the action computing byte offsets itself.
 type BaseNode struct {
 	text     string
-	offset   int
+	span     Span
 	children []TreeNode
+	moved    int
 }
 
 // Text returns the source substring matched by the node.
@@ -23,12 +38,55 @@ func (n *BaseNode) Text() string {
 	return n.text
 }
 
-// Offset returns the rune offset where the node starts.
+// Offset returns the rune offset where the node starts, or the byte offset if
+// the parser was created with WithByteOffsets.
 func (n *BaseNode) Offset() int {
-	return n.offset
+	return n.span.Start
+}
+
+// End returns the offset just past the end of the node, in the same units as
+// Offset.
+func (n *BaseNode) End() int {
+	return n.span.End
+}
+
+// Span returns the node's start and end offsets in runes and in bytes.
+func (n *BaseNode) Span() Span {
+	return n.span
 }
 
 // Children returns the node's child list.
 func (n *BaseNode) Children() []TreeNode {
 	return n.children
 }
+
+func (n *BaseNode) setDefaultSpan(span Span) {
+	if n.span == (Span{}) {
+		n.span = span
+	}
+}
+
+// spanDefaulter is implemented by nodes that embed BaseNode.
+type spanDefaulter interface {
+	setDefaultSpan(span Span)
+}
+
+// shiftSpan moves the node for the edit numbered edit, and reports whether
+// it had not already been moved for that edit.
+func (n *BaseNode) shiftSpan(offsets, bytes, edit int) bool {
+	if n.moved == edit {
+		return false
+	}
+	n.moved = edit
+	n.span.Start += offsets
+	n.span.End += offsets
+	n.span.ByteStart += bytes
+	n.span.ByteEnd += bytes
+	return true
+}
+
+// spanShifter is implemented by nodes that embed BaseNode, so that Reparse
+// can move the nodes it reuses from after an edit.
+type spanShifter interface {
+	shiftSpan(offsets, bytes, edit int) bool
+}
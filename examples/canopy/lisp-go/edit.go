@@ -0,0 +1,113 @@
+// This file was generated from examples/canopy/lisp.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package lispgoparser
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Edit describes a change to the input: the text between Start and OldEnd is
+// replaced by NewText. Offsets are in the same units as node offsets.
+type Edit struct {
+	Start   int
+	OldEnd  int
+	NewText string
+}
+
+// Reparse applies edit to the input of a parser created by New, then parses
+// the new input and returns the same result Parse would. Memoized results
+// that did not look at the edited text are reused, along with their nodes:
+// those before the edit as they are, and those after it with their offsets
+// moved to match the new input. Since they are shared with the new tree,
+// the tree returned by the previous parse must not be used afterwards.
+//
+// Parse does not record what Reparse needs to tell which results an edit
+// leaves alone, so the first call to Reparse parses everything again.
+//
+// Actions are only called for the parts of the input that are parsed again,
+// and may have used the offsets they were given. So if the parser has
+// actions or types, results after the edit are only reused if the edit does
+// not change the length of the input.
+func (p *LispGoParser) Reparse(edit Edit) (TreeNode, error) {
+	if p.open || p.buf != nil {
+		return nil, fmt.Errorf("Reparse requires a parser created by New")
+	}
+	if edit.Start < 0 || edit.Start > edit.OldEnd || edit.OldEnd > p.offsets.convert(len(p.input)) {
+		return nil, fmt.Errorf("edit from %d to %d is outside the input", edit.Start, edit.OldEnd)
+	}
+	start, oldEnd := p.offsets.byteOffset(edit.Start), p.offsets.byteOffset(edit.OldEnd)
+	bytes := len(edit.NewText) - (oldEnd - start)
+	offsets := bytes
+	if !p.opts.byteOffsets {
+		offsets = utf8.RuneCountInString(edit.NewText) - (edit.OldEnd - edit.Start)
+	}
+
+	p.input = p.input[:start] + edit.NewText + p.input[oldEnd:]
+	p.offsets = newOffsetIndex(p.input, p.opts.byteOffsets)
+	p.lines = nil
+	if p.actionErr != nil || p.stopErr != nil {
+		// Results that failed because an action did, or because the parse
+		// gave up, are in the memo as failures, so none of them can be
+		// trusted.
+		p.cache.track()
+	} else {
+		p.cache.edit(start, oldEnd, bytes, p.mover(offsets, bytes))
+	}
+
+	node, err := p.reparse()
+	if _, ok := err.(*ParseError); ok && p.missedFailures() {
+		p.cache.track()
+		node, err = p.reparse()
+	}
+	return node, err
+}
+
+// missedFailures reports whether failures recorded by reused results, which
+// were not run again, could have changed the error, in which case Reparse
+// parses from scratch to report the same error as Parse. Those failures are
+// all before reusedReach, so they only matter if that is past the furthest
+// failure recorded, or if none were recorded and Parse reported the end of
+// input instead.
+func (p *LispGoParser) missedFailures() bool {
+	if p.cache.reusedReach == 0 {
+		return false
+	}
+	expected := p.failure.expected
+	return p.cache.reusedReach > p.failure.offset || len(expected) == 1 && expected[0].Expected == "<EOF>"
+}
+
+func (p *LispGoParser) reparse() (TreeNode, error) {
+	p.restart(0)
+	return p.parseInput(RuleProgram)
+}
+
+// mover returns the function memoTable.edit uses to move the nodes of
+// results after an edit by the given number of offsets and bytes. Nodes
+// returned by actions or NodeExtenders may not embed BaseNode, and may
+// depend on their offsets, so those parsers only keep such results if the
+// edit leaves their offsets alone.
+func (p *LispGoParser) mover(offsets, bytes int) func(TreeNode) bool {
+	if offsets == 0 && bytes == 0 {
+		return func(TreeNode) bool { return true }
+	}
+	if p.actions != nil || p.types != nil {
+		return func(TreeNode) bool { return false }
+	}
+	return func(node TreeNode) bool {
+		shiftNode(node, offsets, bytes, p.cache.edits)
+		return true
+	}
+}
+
+// shiftNode moves node and its descendants. Memoized subtrees are shared, so
+// a node the edit has already moved is skipped, along with its descendants.
+func shiftNode(node TreeNode, offsets, bytes, edit int) {
+	if node == nil || !node.(spanShifter).shiftSpan(offsets, bytes, edit) {
+		return
+	}
+	for _, child := range node.Children() {
+		shiftNode(child, offsets, bytes, edit)
+	}
+}
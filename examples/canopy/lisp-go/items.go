@@ -0,0 +1,55 @@
+// This file was generated from examples/canopy/lisp.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+//go:build go1.23
+
+package lispgoparser
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Items parses the input like Parse, but instead of returning the tree
+// once the whole input has matched, it yields each item the root rule
+// repeats as soon as the item has been parsed. The parse stops if the
+// loop breaks. Otherwise, if the rest of the input does not match, the
+// last pair yielded holds the error Parse would return, after the items
+// before it. The root rule's own node is never built.
+//
+// Memoized results from before the end of each item are dropped once it
+// has been yielded, so the memory a parse needs, apart from the input
+// itself, depends on the size of its largest item. Items may be used
+// instead of Parse with a parser created by New or NewReader.
+//
+// Items is only built with Go 1.23 or later, which added the iter package,
+// so that the rest of the parser still builds with Go 1.22.
+func (p *LispGoParser) Items() iter.Seq2[TreeNode, error] {
+	return func(yield func(TreeNode, error) bool) {
+		if p.stream != nil {
+			yield(nil, fmt.Errorf("Items cannot be used with a parser created by NewStream"))
+			return
+		}
+		p.startOver()
+		if err := p.begin(); err != nil {
+			yield(nil, err)
+			return
+		}
+		p.cache.reserve(0)
+		count := 0
+		for {
+			node := p.readItem()
+			if node == nil || p.readErr != nil || p.actionErr != nil || p.stopErr != nil {
+				break
+			}
+			count++
+			if !yield(node, nil) {
+				return
+			}
+			p.cache.forget(p.offset)
+		}
+		if err := p.finish(RuleProgram, count >= 1, false); err != nil {
+			yield(nil, err)
+		}
+	}
+}
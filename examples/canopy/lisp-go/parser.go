@@ -4,49 +4,45 @@
 package lispgoparser
 
 import (
-		"fmt"
-	"regexp"
+		"context"
+	"fmt"
+	"io"
+	"slices"
+	"unicode/utf8"
 )
 
 type NodeExtender func(TreeNode) TreeNode
 
-type expectation struct {
-	rule string
-	expected string
-}
-
 type failureState struct {
 	offset int
-	expected []expectation
-}
-
-type cacheEntry struct {
-	node TreeNode
-	offset int
-}
-
-type ParseError struct {
-	Input string
-	Offset int
-	Line int
-	Column int
-	Expected []expectation
-	Message string
-}
-
-func (e *ParseError) Error() string {
-	return e.Message
+	expected []Expectation
 }
 
 type LispGoParser struct {
-	input []rune
-	inputString string
+	input string
+	offsets offsetIndex
+	lines *LineIndex
+	reader io.Reader
+	stream *streamState
+	open bool
+	buf []byte
+	readErr error
 	actions Actions
 	types map[string]NodeExtender
+	opts options
 	offset int
-	cache map[string]map[int]cacheEntry
+	seen int
+	cache memoTable
 	failure failureState
+	silent int
+	lookahead int
+	points []backtrackPoint
 	actionErr error
+	ctx context.Context
+	guarded bool
+	depth int
+	steps int
+	stopErr error
 }
 
 
@@ -57,9 +53,9 @@ type Node1 struct {
 
 var _ TreeNode = (*Node1)(nil)
 
-func newNode1(text string, start int, elements []TreeNode) TreeNode {
+func newNode1(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node1{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Data = elements[1]
 	return node
@@ -73,30 +69,49 @@ type Node2 struct {
 
 var _ TreeNode = (*Node2)(nil)
 
-func newNode2(text string, start int, elements []TreeNode) TreeNode {
+func newNode2(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node2{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Cells = elements[1]
 	return node
 }
 
 
-var REGEX_1 = regexp.MustCompile(`^[1-9]`)
-var REGEX_2 = regexp.MustCompile(`^[0-9]`)
-var REGEX_3 = regexp.MustCompile(`^[^"]`)
-var REGEX_4 = regexp.MustCompile(`^[\s]`)
+// [1-9]
+var charClass1 = charClass{
+	ascii: [2]uint64{0x3fe000000000000, 0x0},
+}
+
+// [0-9]
+var charClass2 = charClass{
+	ascii: [2]uint64{0x3ff000000000000, 0x0},
+}
+
+// [^"]
+var charClass3 = charClass{
+	ascii: [2]uint64{0xfffffffbffffffff, 0xffffffffffffffff},
+	ranges: []rune{0x80, 0x10ffff},
+}
+
+// [\s]
+var charClass4 = charClass{
+	ascii: [2]uint64{0x100003600, 0x0},
+}
+
 
 func (p *LispGoParser) _read_program() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address0 TreeNode = nil
 	var index0 int = p.offset
-	var cache0 map[int]cacheEntry = p.cache["program"]
-	if cache0 == nil {
-		cache0 = make(map[int]cacheEntry)
-		p.cache["program"] = cache0
-	}
-	if entry, ok := cache0[index0]; ok {
+	var silent0 int = p.silent
+	if entry, ok := p.cache.get(RuleProgram, index0, silent0 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index1 int = p.offset
@@ -111,24 +126,31 @@ func (p *LispGoParser) _read_program() TreeNode {
 		}
 	}
 	if len(elements0) >= 1 {
-		address0 = &BaseNode{text: p.slice(index1, p.offset), offset: index1, children: elements0}
+		address0 = &BaseNode{text: p.slice(index1, p.offset), span: p.offsets.span(index1, p.offset), children: elements0}
 	} else {
 		address0 = nil
 	}
-	cache0[index0] = cacheEntry{node: address0, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleProgram, index0, address0, p.offset, silent0 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address0
 }
 
 func (p *LispGoParser) _read_cell() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address2 TreeNode = nil
 	var index2 int = p.offset
-	var cache1 map[int]cacheEntry = p.cache["cell"]
-	if cache1 == nil {
-		cache1 = make(map[int]cacheEntry)
-		p.cache["cell"] = cache1
-	}
-	if entry, ok := cache1[index2]; ok {
+	var silent1 int = p.silent
+	if entry, ok := p.cache.get(RuleCell, index2, silent1 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index3 int = p.offset
@@ -146,7 +168,7 @@ func (p *LispGoParser) _read_cell() TreeNode {
 		}
 	}
 	if len(elements2) >= 0 {
-		address3 = &BaseNode{text: p.slice(index4, p.offset), offset: index4, children: elements2}
+		address3 = &BaseNode{text: p.slice(index4, p.offset), span: p.offsets.span(index4, p.offset), children: elements2}
 	} else {
 		address3 = nil
 	}
@@ -177,7 +199,7 @@ func (p *LispGoParser) _read_cell() TreeNode {
 				}
 			}
 			if len(elements3) >= 0 {
-				address6 = &BaseNode{text: p.slice(index6, p.offset), offset: index6, children: elements3}
+				address6 = &BaseNode{text: p.slice(index6, p.offset), span: p.offsets.span(index6, p.offset), children: elements3}
 			} else {
 				address6 = nil
 			}
@@ -198,43 +220,45 @@ func (p *LispGoParser) _read_cell() TreeNode {
 	if elements1 == nil {
 		address2 = nil
 	} else {
-		address2 = newNode1(p.slice(index3, p.offset), index3, elements1)
+		address2 = newNode1(p.slice(index3, p.offset), p.offsets.span(index3, p.offset), elements1)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleCell, index2, address2, p.offset, silent1 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache1[index2] = cacheEntry{node: address2, offset: p.offset}
 	return address2
 }
 
 func (p *LispGoParser) _read_list() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address8 TreeNode = nil
 	var index7 int = p.offset
-	var cache2 map[int]cacheEntry = p.cache["list"]
-	if cache2 == nil {
-		cache2 = make(map[int]cacheEntry)
-		p.cache["list"] = cache2
-	}
-	if entry, ok := cache2[index7]; ok {
+	var silent2 int = p.silent
+	if entry, ok := p.cache.get(RuleList, index7, silent2 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index8 int = p.offset
 	var elements4 []TreeNode = make([]TreeNode, 3)
 	var address9 TreeNode = nil
-	var chunk0 string = ""
-	var max0 int = p.offset + 1
-	if max0 <= len(p.input) {
-		chunk0 = string(p.input[p.offset:max0])
-	}
-	if chunk0 == "(" {
-		address9 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '(' {
+		address9 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address9 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::list", expected: "\"(\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::list", Expected: "\"(\""})
 		}
 	}
 	if address9 != nil {
@@ -252,29 +276,24 @@ func (p *LispGoParser) _read_list() TreeNode {
 			}
 		}
 		if len(elements5) >= 1 {
-			address10 = &BaseNode{text: p.slice(index9, p.offset), offset: index9, children: elements5}
+			address10 = &BaseNode{text: p.slice(index9, p.offset), span: p.offsets.span(index9, p.offset), children: elements5}
 		} else {
 			address10 = nil
 		}
 		if address10 != nil {
 			elements4[1] = address10
 			var address12 TreeNode = nil
-			var chunk1 string = ""
-			var max1 int = p.offset + 1
-			if max1 <= len(p.input) {
-				chunk1 = string(p.input[p.offset:max1])
-			}
-			if chunk1 == ")" {
-				address12 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == ')' {
+				address12 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address12 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::list", expected: "\")\""})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::list", Expected: "\")\""})
 				}
 			}
 			if address12 != nil {
@@ -294,22 +313,29 @@ func (p *LispGoParser) _read_list() TreeNode {
 	if elements4 == nil {
 		address8 = nil
 	} else {
-		address8 = newNode2(p.slice(index8, p.offset), index8, elements4)
+		address8 = newNode2(p.slice(index8, p.offset), p.offsets.span(index8, p.offset), elements4)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleList, index7, address8, p.offset, silent2 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache2[index7] = cacheEntry{node: address8, offset: p.offset}
 	return address8
 }
 
 func (p *LispGoParser) _read_atom() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address13 TreeNode = nil
 	var index10 int = p.offset
-	var cache3 map[int]cacheEntry = p.cache["atom"]
-	if cache3 == nil {
-		cache3 = make(map[int]cacheEntry)
-		p.cache["atom"] = cache3
-	}
-	if entry, ok := cache3[index10]; ok {
+	var silent3 int = p.silent
+	if entry, ok := p.cache.get(RuleAtom, index10, silent3 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index11 int = p.offset
@@ -329,100 +355,100 @@ func (p *LispGoParser) _read_atom() TreeNode {
 			}
 		}
 	}
-	cache3[index10] = cacheEntry{node: address13, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleAtom, index10, address13, p.offset, silent3 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address13
 }
 
 func (p *LispGoParser) _read_boolean_() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address14 TreeNode = nil
 	var index12 int = p.offset
-	var cache4 map[int]cacheEntry = p.cache["boolean_"]
-	if cache4 == nil {
-		cache4 = make(map[int]cacheEntry)
-		p.cache["boolean_"] = cache4
-	}
-	if entry, ok := cache4[index12]; ok {
+	var silent4 int = p.silent
+	if entry, ok := p.cache.get(RuleBoolean, index12, silent4 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index13 int = p.offset
-	var chunk2 string = ""
-	var max2 int = p.offset + 2
-	if max2 <= len(p.input) {
-		chunk2 = string(p.input[p.offset:max2])
-	}
-	if chunk2 == "#t" {
-		address14 = &BaseNode{text: p.slice(p.offset, p.offset + 2), offset: p.offset, children: nil}
+	if p.avail(2) && p.input[p.offset:p.offset+2] == "#t" {
+		address14 = &BaseNode{text: p.slice(p.offset, p.offset + 2), span: p.offsets.span(p.offset, p.offset + 2), children: nil}
 		p.offset = p.offset + 2
 	} else {
 		address14 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::boolean_", expected: "\"#t\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::boolean_", Expected: "\"#t\""})
 		}
 	}
 	if address14 == nil {
 		p.offset = index13
-		var chunk3 string = ""
-		var max3 int = p.offset + 2
-		if max3 <= len(p.input) {
-			chunk3 = string(p.input[p.offset:max3])
-		}
-		if chunk3 == "#f" {
-			address14 = &BaseNode{text: p.slice(p.offset, p.offset + 2), offset: p.offset, children: nil}
+		if p.avail(2) && p.input[p.offset:p.offset+2] == "#f" {
+			address14 = &BaseNode{text: p.slice(p.offset, p.offset + 2), span: p.offsets.span(p.offset, p.offset + 2), children: nil}
 			p.offset = p.offset + 2
 		} else {
 			address14 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::boolean_", expected: "\"#f\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::boolean_", Expected: "\"#f\""})
 			}
 		}
 		if address14 == nil {
 			p.offset = index13
 		}
 	}
-	cache4[index12] = cacheEntry{node: address14, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleBoolean, index12, address14, p.offset, silent4 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address14
 }
 
 func (p *LispGoParser) _read_integer() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address15 TreeNode = nil
 	var index14 int = p.offset
-	var cache5 map[int]cacheEntry = p.cache["integer"]
-	if cache5 == nil {
-		cache5 = make(map[int]cacheEntry)
-		p.cache["integer"] = cache5
-	}
-	if entry, ok := cache5[index14]; ok {
+	var silent5 int = p.silent
+	if entry, ok := p.cache.get(RuleInteger, index14, silent5 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index15 int = p.offset
 	var elements6 []TreeNode = make([]TreeNode, 2)
 	var address16 TreeNode = nil
-	var chunk4 string = ""
-	var max4 int = p.offset + 1
-	if max4 <= len(p.input) {
-		chunk4 = string(p.input[p.offset:max4])
-	}
-	if REGEX_1.MatchString(chunk4) {
-		address16 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-		p.offset = p.offset + 1
+	var end0 int = charClass1.match(p.peekRune(), p.offset)
+	if end0 >= 0 {
+		address16 = &BaseNode{text: p.slice(p.offset, end0), span: p.offsets.span(p.offset, end0), children: nil}
+		p.offset = end0
 	} else {
 		address16 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::integer", expected: "[1-9]"})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::integer", Expected: "[1-9]"})
 		}
 	}
 	if address16 != nil {
@@ -432,22 +458,18 @@ func (p *LispGoParser) _read_integer() TreeNode {
 		var elements7 []TreeNode = nil
 		var address18 TreeNode = nil
 		for {
-			var chunk5 string = ""
-			var max5 int = p.offset + 1
-			if max5 <= len(p.input) {
-				chunk5 = string(p.input[p.offset:max5])
-			}
-			if REGEX_2.MatchString(chunk5) {
-				address18 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-				p.offset = p.offset + 1
+			var end1 int = charClass2.match(p.peekRune(), p.offset)
+			if end1 >= 0 {
+				address18 = &BaseNode{text: p.slice(p.offset, end1), span: p.offsets.span(p.offset, end1), children: nil}
+				p.offset = end1
 			} else {
 				address18 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::integer", expected: "[0-9]"})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::integer", Expected: "[0-9]"})
 				}
 			}
 			if address18 != nil {
@@ -457,7 +479,7 @@ func (p *LispGoParser) _read_integer() TreeNode {
 			}
 		}
 		if len(elements7) >= 0 {
-			address17 = &BaseNode{text: p.slice(index16, p.offset), offset: index16, children: elements7}
+			address17 = &BaseNode{text: p.slice(index16, p.offset), span: p.offsets.span(index16, p.offset), children: elements7}
 		} else {
 			address17 = nil
 		}
@@ -474,43 +496,45 @@ func (p *LispGoParser) _read_integer() TreeNode {
 	if elements6 == nil {
 		address15 = nil
 	} else {
-		address15 = &BaseNode{text: p.slice(index15, p.offset), offset: index15, children: elements6}
+		address15 = &BaseNode{text: p.slice(index15, p.offset), span: p.offsets.span(index15, p.offset), children: elements6}
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleInteger, index14, address15, p.offset, silent5 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache5[index14] = cacheEntry{node: address15, offset: p.offset}
 	return address15
 }
 
 func (p *LispGoParser) _read_string() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address19 TreeNode = nil
 	var index17 int = p.offset
-	var cache6 map[int]cacheEntry = p.cache["string"]
-	if cache6 == nil {
-		cache6 = make(map[int]cacheEntry)
-		p.cache["string"] = cache6
-	}
-	if entry, ok := cache6[index17]; ok {
+	var silent6 int = p.silent
+	if entry, ok := p.cache.get(RuleString, index17, silent6 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index18 int = p.offset
 	var elements8 []TreeNode = make([]TreeNode, 3)
 	var address20 TreeNode = nil
-	var chunk6 string = ""
-	var max6 int = p.offset + 1
-	if max6 <= len(p.input) {
-		chunk6 = string(p.input[p.offset:max6])
-	}
-	if chunk6 == "\"" {
-		address20 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '"' {
+		address20 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address20 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::string", expected: "\"\\\"\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::string", Expected: "\"\\\"\""})
 		}
 	}
 	if address20 != nil {
@@ -524,38 +548,33 @@ func (p *LispGoParser) _read_string() TreeNode {
 			var index21 int = p.offset
 			var elements10 []TreeNode = make([]TreeNode, 2)
 			var address23 TreeNode = nil
-			var chunk7 string = ""
-			var max7 int = p.offset + 1
-			if max7 <= len(p.input) {
-				chunk7 = string(p.input[p.offset:max7])
-			}
-			if chunk7 == "\\" {
-				address23 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '\\' {
+				address23 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address23 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::string", expected: "\"\\\\\""})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::string", Expected: "\"\\\\\""})
 				}
 			}
 			if address23 != nil {
 				elements10[0] = address23
 				var address24 TreeNode = nil
-				if p.offset < len(p.input) {
-					address24 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-					p.offset = p.offset + 1
+				if p.avail(1) {
+					address24 = &BaseNode{text: p.slice(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), span: p.offsets.span(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), children: nil}
+					p.offset = p.offset + runeWidth(p.peekRune(), p.offset)
 				} else {
 					address24 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::string", expected: "<any char>"})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::string", Expected: "<any char>"})
 					}
 				}
 				if address24 != nil {
@@ -571,26 +590,22 @@ func (p *LispGoParser) _read_string() TreeNode {
 			if elements10 == nil {
 				address22 = nil
 			} else {
-				address22 = &BaseNode{text: p.slice(index21, p.offset), offset: index21, children: elements10}
+				address22 = &BaseNode{text: p.slice(index21, p.offset), span: p.offsets.span(index21, p.offset), children: elements10}
 			}
 			if address22 == nil {
 				p.offset = index20
-				var chunk8 string = ""
-				var max8 int = p.offset + 1
-				if max8 <= len(p.input) {
-					chunk8 = string(p.input[p.offset:max8])
-				}
-				if REGEX_3.MatchString(chunk8) {
-					address22 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-					p.offset = p.offset + 1
+				var end2 int = charClass3.match(p.peekRune(), p.offset)
+				if end2 >= 0 {
+					address22 = &BaseNode{text: p.slice(p.offset, end2), span: p.offsets.span(p.offset, end2), children: nil}
+					p.offset = end2
 				} else {
 					address22 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::string", expected: "[^\"]"})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::string", Expected: "[^\"]"})
 					}
 				}
 				if address22 == nil {
@@ -604,29 +619,24 @@ func (p *LispGoParser) _read_string() TreeNode {
 			}
 		}
 		if len(elements9) >= 0 {
-			address21 = &BaseNode{text: p.slice(index19, p.offset), offset: index19, children: elements9}
+			address21 = &BaseNode{text: p.slice(index19, p.offset), span: p.offsets.span(index19, p.offset), children: elements9}
 		} else {
 			address21 = nil
 		}
 		if address21 != nil {
 			elements8[1] = address21
 			var address25 TreeNode = nil
-			var chunk9 string = ""
-			var max9 int = p.offset + 1
-			if max9 <= len(p.input) {
-				chunk9 = string(p.input[p.offset:max9])
-			}
-			if chunk9 == "\"" {
-				address25 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '"' {
+				address25 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address25 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::string", expected: "\"\\\"\""})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::string", Expected: "\"\\\"\""})
 				}
 			}
 			if address25 != nil {
@@ -646,22 +656,29 @@ func (p *LispGoParser) _read_string() TreeNode {
 	if elements8 == nil {
 		address19 = nil
 	} else {
-		address19 = &BaseNode{text: p.slice(index18, p.offset), offset: index18, children: elements8}
+		address19 = &BaseNode{text: p.slice(index18, p.offset), span: p.offsets.span(index18, p.offset), children: elements8}
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleString, index17, address19, p.offset, silent6 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache6[index17] = cacheEntry{node: address19, offset: p.offset}
 	return address19
 }
 
 func (p *LispGoParser) _read_symbol() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address26 TreeNode = nil
 	var index22 int = p.offset
-	var cache7 map[int]cacheEntry = p.cache["symbol"]
-	if cache7 == nil {
-		cache7 = make(map[int]cacheEntry)
-		p.cache["symbol"] = cache7
-	}
-	if entry, ok := cache7[index22]; ok {
+	var silent7 int = p.silent
+	if entry, ok := p.cache.get(RuleSymbol, index22, silent7 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index23 int = p.offset
@@ -672,27 +689,35 @@ func (p *LispGoParser) _read_symbol() TreeNode {
 		var elements12 []TreeNode = make([]TreeNode, 2)
 		var address28 TreeNode = nil
 		var index25 int = p.offset
+		var silent8 int = p.silent
+		p.silent++
+		p.lookahead++
 		address28 = p._read_delimiter()
+		p.lookahead--
+		p.silent = silent8
+		if address28 != nil {
+			p.expect(index25, Expectation{Rule: "CanopyLisp::symbol", Expected: "delimiter", Unexpected: true})
+		}
 		p.offset = index25
 		if address28 == nil {
-			address28 = &BaseNode{text: p.slice(p.offset, p.offset), offset: p.offset, children: nil}
+			address28 = &BaseNode{text: p.slice(p.offset, p.offset), span: p.offsets.span(p.offset, p.offset), children: nil}
 		} else {
 			address28 = nil
 		}
 		if address28 != nil {
 			elements12[0] = address28
 			var address29 TreeNode = nil
-			if p.offset < len(p.input) {
-				address29 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-				p.offset = p.offset + 1
+			if p.avail(1) {
+				address29 = &BaseNode{text: p.slice(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), span: p.offsets.span(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), children: nil}
+				p.offset = p.offset + runeWidth(p.peekRune(), p.offset)
 			} else {
 				address29 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::symbol", expected: "<any char>"})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::symbol", Expected: "<any char>"})
 				}
 			}
 			if address29 != nil {
@@ -708,7 +733,7 @@ func (p *LispGoParser) _read_symbol() TreeNode {
 		if elements12 == nil {
 			address27 = nil
 		} else {
-			address27 = &BaseNode{text: p.slice(index24, p.offset), offset: index24, children: elements12}
+			address27 = &BaseNode{text: p.slice(index24, p.offset), span: p.offsets.span(index24, p.offset), children: elements12}
 		}
 		if address27 != nil {
 			elements11 = append(elements11, address27)
@@ -717,117 +742,124 @@ func (p *LispGoParser) _read_symbol() TreeNode {
 		}
 	}
 	if len(elements11) >= 1 {
-		address26 = &BaseNode{text: p.slice(index23, p.offset), offset: index23, children: elements11}
+		address26 = &BaseNode{text: p.slice(index23, p.offset), span: p.offsets.span(index23, p.offset), children: elements11}
 	} else {
 		address26 = nil
 	}
-	cache7[index22] = cacheEntry{node: address26, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleSymbol, index22, address26, p.offset, silent7 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address26
 }
 
 func (p *LispGoParser) _read_space() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address30 TreeNode = nil
 	var index26 int = p.offset
-	var cache8 map[int]cacheEntry = p.cache["space"]
-	if cache8 == nil {
-		cache8 = make(map[int]cacheEntry)
-		p.cache["space"] = cache8
-	}
-	if entry, ok := cache8[index26]; ok {
+	var silent9 int = p.silent
+	if entry, ok := p.cache.get(RuleSpace, index26, silent9 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
-	var chunk10 string = ""
-	var max10 int = p.offset + 1
-	if max10 <= len(p.input) {
-		chunk10 = string(p.input[p.offset:max10])
-	}
-	if REGEX_4.MatchString(chunk10) {
-		address30 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-		p.offset = p.offset + 1
+	var end3 int = charClass4.match(p.peekRune(), p.offset)
+	if end3 >= 0 {
+		address30 = &BaseNode{text: p.slice(p.offset, end3), span: p.offsets.span(p.offset, end3), children: nil}
+		p.offset = end3
 	} else {
 		address30 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::space", expected: "[\\s]"})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::space", Expected: "[\\s]"})
 		}
 	}
-	cache8[index26] = cacheEntry{node: address30, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleSpace, index26, address30, p.offset, silent9 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address30
 }
 
 func (p *LispGoParser) _read_paren() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address31 TreeNode = nil
 	var index27 int = p.offset
-	var cache9 map[int]cacheEntry = p.cache["paren"]
-	if cache9 == nil {
-		cache9 = make(map[int]cacheEntry)
-		p.cache["paren"] = cache9
-	}
-	if entry, ok := cache9[index27]; ok {
+	var silent10 int = p.silent
+	if entry, ok := p.cache.get(RuleParen, index27, silent10 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index28 int = p.offset
-	var chunk11 string = ""
-	var max11 int = p.offset + 1
-	if max11 <= len(p.input) {
-		chunk11 = string(p.input[p.offset:max11])
-	}
-	if chunk11 == "(" {
-		address31 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '(' {
+		address31 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address31 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::paren", expected: "\"(\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::paren", Expected: "\"(\""})
 		}
 	}
 	if address31 == nil {
 		p.offset = index28
-		var chunk12 string = ""
-		var max12 int = p.offset + 1
-		if max12 <= len(p.input) {
-			chunk12 = string(p.input[p.offset:max12])
-		}
-		if chunk12 == ")" {
-			address31 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+		if p.avail(1) && p.input[p.offset] == ')' {
+			address31 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 			p.offset = p.offset + 1
 		} else {
 			address31 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp::paren", expected: "\")\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "CanopyLisp::paren", Expected: "\")\""})
 			}
 		}
 		if address31 == nil {
 			p.offset = index28
 		}
 	}
-	cache9[index27] = cacheEntry{node: address31, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleParen, index27, address31, p.offset, silent10 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address31
 }
 
 func (p *LispGoParser) _read_delimiter() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address32 TreeNode = nil
 	var index29 int = p.offset
-	var cache10 map[int]cacheEntry = p.cache["delimiter"]
-	if cache10 == nil {
-		cache10 = make(map[int]cacheEntry)
-		p.cache["delimiter"] = cache10
-	}
-	if entry, ok := cache10[index29]; ok {
+	var silent11 int = p.silent
+	if entry, ok := p.cache.get(RuleDelimiter, index29, silent11 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index30 int = p.offset
@@ -839,17 +871,107 @@ func (p *LispGoParser) _read_delimiter() TreeNode {
 			p.offset = index30
 		}
 	}
-	cache10[index29] = cacheEntry{node: address32, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleDelimiter, index29, address32, p.offset, silent11 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address32
 }
 
-func New(input string, actions Actions) *LispGoParser {
-	return &LispGoParser{
-		input: []rune(input),
-		inputString: input,
+func (p *LispGoParser) readItem() TreeNode {
+	var address33 TreeNode = nil
+	address33 = p._read_cell()
+	return address33
+}
+
+// Rule identifies one of the rules in the grammar.
+type Rule int
+
+const (
+	RuleProgram Rule = iota
+	RuleCell
+	RuleList
+	RuleAtom
+	RuleBoolean
+	RuleInteger
+	RuleString
+	RuleSymbol
+	RuleSpace
+	RuleParen
+	RuleDelimiter
+	numRules = iota
+)
+
+const minReadSize = 4096
+
+const seenAhead = 16
+
+var ruleNames = [numRules]string{
+	"program",
+	"cell",
+	"list",
+	"atom",
+	"boolean_",
+	"integer",
+	"string",
+	"symbol",
+	"space",
+	"paren",
+	"delimiter",
+}
+
+// ruleReaders holds the method that matches each rule.
+var ruleReaders = [numRules]func(*LispGoParser) TreeNode{
+	(*LispGoParser)._read_program,
+	(*LispGoParser)._read_cell,
+	(*LispGoParser)._read_list,
+	(*LispGoParser)._read_atom,
+	(*LispGoParser)._read_boolean_,
+	(*LispGoParser)._read_integer,
+	(*LispGoParser)._read_string,
+	(*LispGoParser)._read_symbol,
+	(*LispGoParser)._read_space,
+	(*LispGoParser)._read_paren,
+	(*LispGoParser)._read_delimiter,
+}
+
+// String returns the name of the rule in the grammar.
+func (r Rule) String() string {
+	if r < 0 || r >= numRules {
+		return fmt.Sprintf("Rule(%d)", int(r))
+	}
+	return ruleNames[r]
+}
+
+var memoDefaults = [numRules]bool{
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+}
+
+func New(input string, actions Actions, opts ...Option) *LispGoParser {
+	p := &LispGoParser{
+		input: input,
 		actions: actions,
-		cache: make(map[string]map[int]cacheEntry),
+		failure: failureState{expected: make([]Expectation, 0, 8)},
+	}
+	for _, opt := range opts {
+		opt(&p.opts)
 	}
+	p.offsets = newOffsetIndex(input, p.opts.byteOffsets)
+	p.cache = newMemoTable(&p.opts)
+	p.guarded = p.opts.limits != (Limits{})
+	return p
 }
 
 func (p *LispGoParser) WithTypes(types map[string]NodeExtender) *LispGoParser {
@@ -857,72 +979,263 @@ func (p *LispGoParser) WithTypes(types map[string]NodeExtender) *LispGoParser {
 	return p
 }
 
-func Parse(input string, actions Actions, types map[string]NodeExtender) (TreeNode, error) {
-	parser := New(input, actions)
+func Parse(input string, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	parser := New(input, actions, opts...)
+	if types != nil {
+		parser.types = types
+	}
+	return parser.Parse()
+}
+
+// ParseBytes parses a UTF-8 encoded byte slice. The input is copied once,
+// so the slice may be reused after ParseBytes returns; the text of the
+// returned nodes refers to the copy.
+func ParseBytes(input []byte, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	return Parse(string(input), actions, types, opts...)
+}
+
+// NewReader returns a parser that reads its input from r. Input is read
+// as the parser needs it, and read errors are returned by Parse in place
+// of a *ParseError.
+func NewReader(r io.Reader, actions Actions, opts ...Option) *LispGoParser {
+	p := New("", actions, opts...)
+	p.reader = r
+	p.open = true
+	return p
+}
+
+// ParseReader parses UTF-8 encoded input read from r. See NewReader.
+func ParseReader(r io.Reader, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	parser := NewReader(r, actions, opts...)
 	if types != nil {
 		parser.types = types
 	}
 	return parser.Parse()
 }
 
+// ParseRule parses input starting from rule, rather than from the first
+// rule in the grammar.
+func ParseRule(rule Rule, input string, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	parser := New(input, actions, opts...)
+	if types != nil {
+		parser.types = types
+	}
+	return parser.ParseRule(rule)
+}
+
 func (p *LispGoParser) Parse() (TreeNode, error) {
-	node := p._read_program()
+	return p.parseRule(RuleProgram)
+}
+
+// ParseRule is like Parse, but starts from rule. It may be used with a
+// parser created by New or NewReader.
+func (p *LispGoParser) ParseRule(rule Rule) (TreeNode, error) {
+	if p.stream != nil {
+		return nil, fmt.Errorf("ParseRule cannot be used with a parser created by NewStream")
+	}
+	if rule < 0 || rule >= numRules {
+		return nil, fmt.Errorf("unknown rule %d", int(rule))
+	}
+	return p.parseRule(rule)
+}
+
+// parseRule parses the whole input from rule. Each parse starts afresh,
+// except the passes of a parser created by NewStream, which carry on
+// from the state resume restores.
+func (p *LispGoParser) parseRule(rule Rule) (TreeNode, error) {
+	if p.stream == nil {
+		p.startOver()
+	}
+	return p.parseInput(rule)
+}
+
+func (p *LispGoParser) parseInput(rule Rule) (TreeNode, error) {
+	if err := p.begin(); err != nil {
+		return nil, err
+	}
+	p.cache.reserve(len(p.input))
+	node := ruleReaders[rule](p)
+	if err := p.finish(rule, node != nil, false); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// finish returns the error for a parse from start that matched if matched,
+// or nil if it succeeded. A prefix parse succeeds if start matched; any
+// other parse must also have reached the end of the input.
+func (p *LispGoParser) finish(start Rule, matched, prefix bool) error {
+	if p.stopErr != nil {
+		return p.stopErr
+	}
+	complete := matched && (prefix || !p.avail(1))
+	if p.cache.truncated {
+		return ErrIncomplete
+	}
+	if p.readErr != nil {
+		return p.readErr
+	}
 	if p.actionErr != nil {
-		return nil, p.actionErr
+		return p.actionErr
+	}
+	if complete {
+		return nil
 	}
-	if node != nil && p.offset == len(p.input) {
-		return node, nil
+	if matched && p.failure.offset < p.offset {
+		// The failures before the end of the match were all recovered from,
+		// so the error is the input left over.
+		p.failure.offset = p.offset
+		p.failure.expected = p.failure.expected[:0]
 	}
 	if len(p.failure.expected) == 0 {
+		rule := "CanopyLisp"
+		if start != RuleProgram {
+			rule += "::" + ruleNames[start]
+		}
 		p.failure.offset = p.offset
-		p.failure.expected = append(p.failure.expected, expectation{rule: "CanopyLisp", expected: "<EOF>"})
+		p.failure.expected = append(p.failure.expected, Expectation{Rule: rule, Expected: "<EOF>"})
 	}
-	return nil, p.newParseError()
+	return p.newParseError(matched)
 }
 
-func (p *LispGoParser) newParseError() error {
-	line, column := 1, 1
-	for i, r := range p.input {
-		if i >= p.failure.offset {
-			break
-		}
-		if r == '\n' {
-			line++
-			column = 1
-		} else {
-			column++
-		}
+// newParseError describes the furthest failure. stopped reports that the
+// root rule matched, and the parse only failed because input was left over.
+func (p *LispGoParser) newParseError(stopped bool) error {
+	pos := p.lineIndex().position(p.failure.offset)
+	status := "parse error"
+	if stopped {
+		status = "parse stopped early"
 	}
-	message := fmt.Sprintf("parse error at line %d, column %d", line, column)
-	if len(p.failure.expected) > 0 {
-		message += ": expected "
-		for i, exp := range p.failure.expected {
-			if i > 0 {
-				if i == len(p.failure.expected)-1 {
-					message += " or "
-				} else {
-					message += ", "
-				}
-			}
-			message += fmt.Sprintf("%s from %s", exp.expected, exp.rule)
-		}
+	expected := sortExpectations(p.failure.expected, pos.Offset)
+	message := fmt.Sprintf("%s at line %d, column %d", status, pos.Line, pos.Column)
+	if len(expected) > 0 {
+		message += ": " + describeExpected(expected)
+	}
+	if p.open && p.stream == nil {
+		// Read far enough past the failure to describe what is there.
+		p.fill(p.failure.offset + maxFoundRunes*utf8.UTFMax)
+	}
+	found := describeFound(p.input[p.failure.offset:])
+	message += " but found " + found
+	kind, end := UnexpectedInput, 0
+	if stopped {
+		kind, end = TrailingInput, p.offsets.convert(p.offset)
+	}
+	if p.failure.offset == len(p.input) {
+		kind = UnexpectedEOF
 	}
-	expected := make([]expectation, len(p.failure.expected))
-	copy(expected, p.failure.expected)
 	return &ParseError{
-		Input: p.inputString,
-		Offset: p.failure.offset,
-		Line: line,
-		Column: column,
+		Input: p.input,
+		Offset: pos.Offset,
+		Line: pos.Line,
+		Column: pos.Column,
+		UTF16Column: pos.UTF16Column,
 		Expected: expected,
+		Found: found,
 		Message: message,
+		Kind: kind,
+		End: end,
+	}
+}
+
+// Position returns the line and column of offset, which is in the same
+// units as node offsets. The line index is built on the first call.
+func (p *LispGoParser) Position(offset int) Position {
+	return p.lineIndex().Position(offset)
+}
+
+func (p *LispGoParser) lineIndex() *LineIndex {
+	if p.lines == nil {
+		p.lines = newLineIndex(&p.offsets)
+	}
+	return p.lines
+}
+
+// peek returns the input, first making n bytes at the current offset
+// available if it can.
+func (p *LispGoParser) peek(n int) string {
+	if p.offset+n > p.seen {
+		p.fill(p.offset + n)
+	}
+	return p.input
+}
+
+// avail reports whether n bytes of input are available at the current
+// offset.
+func (p *LispGoParser) avail(n int) bool {
+	return p.offset+n <= p.seen || p.fill(p.offset+n)
+}
+
+// peekRune is like peek, but makes available only the bytes of the rune
+// at the current offset, so that a parser fed by Feed does not wait for
+// input it does not need.
+func (p *LispGoParser) peekRune() string {
+	if p.offset+utf8.UTFMax > p.seen {
+		p.fillRune()
+	}
+	return p.input
+}
+
+func (p *LispGoParser) fillRune() {
+	if p.avail(1) && p.input[p.offset] >= utf8.RuneSelf {
+		p.avail(leadWidth(p.input[p.offset]))
+	}
+}
+
+// fill makes the input before want available, first reading more if it
+// may follow, and reports whether it is there. Terminals only call fill
+// once they look past p.seen, which it moves seenAhead bytes beyond want,
+// so p.cache.reach can record how far the parse has looked without
+// slowing down the rest.
+func (p *LispGoParser) fill(want int) bool {
+	if want > len(p.input) && p.open {
+		p.read(want)
+	}
+	p.seen = min(want+seenAhead, len(p.input))
+	p.cache.reach = max(p.cache.reach, p.seen, want)
+	return want <= len(p.input)
+}
+
+// read reads until the input holds want bytes or the reader is exhausted.
+// Each read at least doubles the input, so that copying the buffer into
+// p.input costs linear time overall. A parser created by NewStream gets
+// its input from Feed instead.
+func (p *LispGoParser) read(want int) {
+	if p.stream != nil {
+		p.more(want)
+		return
+	}
+	target := max(want, 2*len(p.buf), minReadSize)
+	if limit := p.opts.limits.MaxInputSize; limit > 0 {
+		// One byte past the limit is enough to tell the input is too long.
+		target = min(target, limit+1)
+	}
+	for len(p.buf) < target && p.reader != nil {
+		if len(p.buf) == cap(p.buf) {
+			p.buf = slices.Grow(p.buf, target-len(p.buf))
+		}
+		n, err := p.reader.Read(p.buf[len(p.buf):cap(p.buf)])
+		p.buf = p.buf[:len(p.buf)+n]
+		if err != nil {
+			if err != io.EOF {
+				p.readErr = err
+			}
+			p.reader = nil
+			p.open = false
+		}
+	}
+	if p.inputTooLong(len(p.buf)) {
+		p.reader = nil
+		p.open = false
 	}
+	p.input = string(p.buf)
+	p.offsets.extend(p.input, !p.open)
 }
 
 func (p *LispGoParser) slice(start, end int) string {
 	if start < 0 { start = 0 }
 	if end > len(p.input) { end = len(p.input) }
 	if start > end { start = end }
-	return string(p.input[start:end])
+	return p.input[start:end]
 }
 
@@ -0,0 +1,131 @@
+// This file was generated from examples/canopy/lisp.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package lispgoparser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PrettyOptions controls how Pretty renders a ParseError.
+type PrettyOptions struct {
+	Context  int  // lines to show before and after the line with the error
+	TabWidth int  // columns between tab stops, or 8 if zero
+	Color    bool // highlight the message and the caret with ANSI escape codes
+}
+
+const (
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiRed   = "\x1b[1;31m"
+	ansiReset = "\x1b[0m"
+)
+
+// wideRunes are the runes a terminal draws two columns wide: the East Asian
+// wide and fullwidth blocks, and emoji.
+var wideRunes = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1},
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1},
+		{Lo: 0x3041, Hi: 0x33ff, Stride: 1},
+		{Lo: 0x3400, Hi: 0x4dbf, Stride: 1},
+		{Lo: 0x4e00, Hi: 0x9fff, Stride: 1},
+		{Lo: 0xa000, Hi: 0xa4cf, Stride: 1},
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1},
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1},
+		{Lo: 0xfe30, Hi: 0xfe4f, Stride: 1},
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1},
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f900, Hi: 0x1f9ff, Stride: 1},
+		{Lo: 0x20000, Hi: 0x3fffd, Stride: 1},
+	},
+}
+
+// Pretty returns Message followed by the line of the input where the parse
+// failed, with a caret under the column of the failure:
+//
+//	parse error at line 1, column 6: expected "[" from Maps::list or [0-9] from Maps::number but found "x"
+//
+//	     1 | {'a':x}
+//	              ^
+//
+// Tabs are expanded to the next tab stop and wide characters take up two
+// columns, so that the caret lines up in a terminal.
+func (e *ParseError) Pretty(opts PrettyOptions) string {
+	tabWidth := opts.TabWidth
+	if tabWidth <= 0 {
+		tabWidth = 8
+	}
+	paint := func(s, code string) string {
+		if !opts.Color {
+			return s
+		}
+		return code + s + ansiReset
+	}
+
+	var b strings.Builder
+	b.WriteString(paint(e.Message, ansiBold))
+	lines := NewLineIndex(e.Input)
+	lines.scan()
+	if e.Line < 1 || e.Line > len(lines.starts) {
+		return b.String()
+	}
+	b.WriteString("\n\n")
+
+	first := max(e.Line-opts.Context, 1)
+	last := min(e.Line+opts.Context, len(lines.starts))
+	for n := first; n <= last; n++ {
+		start, end := lines.starts[n-1], len(e.Input)
+		if n < len(lines.starts) {
+			end = lines.starts[n]
+		}
+		line := strings.TrimSuffix(strings.TrimSuffix(e.Input[start:end], "\n"), "\r")
+		text, caret := expandLine(line, tabWidth, e.Column)
+
+		fmt.Fprintf(&b, "%s%s\n", paint(fmt.Sprintf("%6d | ", n), ansiDim), text)
+		if n == e.Line {
+			fmt.Fprintf(&b, "%s%s\n", strings.Repeat(" ", 9+caret), paint("^", ansiRed))
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// expandLine returns line with its tabs expanded, and the display column,
+// counting from 0, at which the rune in the given column starts.
+func expandLine(line string, tabWidth, column int) (string, int) {
+	var b strings.Builder
+	width, caret := 0, -1
+	for i, r := range []rune(line) {
+		if i == column-1 {
+			caret = width
+		}
+		if r == '\t' {
+			n := tabWidth - width%tabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			width += n
+			continue
+		}
+		b.WriteRune(r)
+		width += displayWidth(r)
+	}
+	if caret < 0 {
+		caret = width
+	}
+	return b.String(), caret
+}
+
+// displayWidth returns the number of columns a terminal uses to draw r.
+func displayWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(wideRunes, r):
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,46 @@
+// This file was generated from examples/canopy/peg.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package peggoparser
+
+import "unicode/utf8"
+
+// charClass is a compiled character class. Testing an ASCII character is a
+// single bit test; other characters are looked up in ranges, a sorted list of
+// inclusive lower and upper bounds.
+type charClass struct {
+	ascii  [2]uint64
+	ranges []rune
+}
+
+// match returns the offset just past the rune at offset if it is in the
+// class, or -1 if it is not or the input is exhausted.
+func (c *charClass) match(input string, offset int) int {
+	if offset < len(input) {
+		if b := input[offset]; b < utf8.RuneSelf && c.ascii[b>>6]>>(b&63)&1 != 0 {
+			return offset + 1
+		}
+	}
+	return c.matchSlow(input, offset)
+}
+
+// matchSlow handles everything but an ASCII character in the class.
+func (c *charClass) matchSlow(input string, offset int) int {
+	if offset >= len(input) || input[offset] < utf8.RuneSelf {
+		return -1
+	}
+	r, size := utf8.DecodeRuneInString(input[offset:])
+	lo, hi := 0, len(c.ranges)/2
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if c.ranges[2*mid+1] < r {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo < len(c.ranges)/2 && c.ranges[2*lo] <= r {
+		return offset + size
+	}
+	return -1
+}
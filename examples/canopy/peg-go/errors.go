@@ -0,0 +1,206 @@
+// This file was generated from examples/canopy/peg.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package peggoparser
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFoundRunes is the longest run of word characters Found describes.
+const maxFoundRunes = 20
+
+// Expectation is something the parser expected to find where a parse failed:
+// the text of a terminal, such as "\"{\"" or "[0-9]", or the display name of
+// a rule, and the rule it is in, as "Grammar::rule". Offset is where it was
+// expected, in the same units as node offsets. If Custom is set, Expected is
+// instead a message the grammar gave for an expression that failed there,
+// and if Unexpected is set, it is an expression that a negative lookahead
+// found there, such as "\"=\"" in !"=".
+type Expectation struct {
+	Rule       string
+	Expected   string
+	Offset     int
+	Custom     bool
+	Unexpected bool
+}
+
+// ErrorKind classifies a ParseError by what was found where the parse
+// failed.
+type ErrorKind int
+
+const (
+	// UnexpectedInput means the input at the failure does not match.
+	UnexpectedInput ErrorKind = iota
+	// UnexpectedEOF means the parse failed at the end of the input, so
+	// more input might have let it go on.
+	UnexpectedEOF
+	// TrailingInput means the root rule matched, but not all of the input,
+	// and the parse failed on the rest before reaching its end.
+	TrailingInput
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case UnexpectedEOF:
+		return "unexpected EOF"
+	case TrailingInput:
+		return "trailing input"
+	}
+	return "unexpected input"
+}
+
+// ParseError describes input that does not match the grammar. It is returned
+// for the furthest offset at which the parser failed to match, and Expected
+// lists everything that would have let the parse go further there, without
+// duplicates and sorted by Expected and then by Rule. Found describes what
+// was there instead: a quoted run of letters, digits and underscores, or a
+// quoted single character, or "<EOF>" at the end of the input.
+//
+// Kind is UnexpectedEOF whenever Found is "<EOF>", even if the root rule
+// matched part of the input, since a parse that reached the end might go
+// further with more. If the root rule matched, End is the offset where its
+// match ended, which may be before Offset if some rule inside it got further
+// before failing, and otherwise End is zero.
+type ParseError struct {
+	Input       string
+	Offset      int
+	Line        int
+	Column      int
+	UTF16Column int
+	Expected    []Expectation
+	Found       string
+	Message     string
+	Kind        ErrorKind
+	End         int
+}
+
+// Error is implemented by *ParseError. Its methods only use built-in types,
+// so the parser for every grammar defines the same interface, and tooling
+// that handles errors from several parsers can declare it once:
+//
+//	var syntaxErr interface {
+//		error
+//		Location() (offset, line, column int)
+//		ExpectedTokens() []string
+//	}
+//	if errors.As(err, &syntaxErr) {
+//		...
+//	}
+type Error interface {
+	error
+	Location() (offset, line, column int)
+	ExpectedTokens() []string
+}
+
+var _ Error = (*ParseError)(nil)
+
+func (e *ParseError) Error() string {
+	return e.Message
+}
+
+// Location returns the offset, line and column of the failure.
+func (e *ParseError) Location() (offset, line, column int) {
+	return e.Offset, e.Line, e.Column
+}
+
+// ExpectedTokens returns the distinct Expected strings of e.Expected, in
+// order, leaving out custom messages and unexpected expressions.
+func (e *ParseError) ExpectedTokens() []string {
+	tokens := make([]string, 0, len(e.Expected))
+	for _, exp := range e.Expected {
+		if !exp.Custom && !exp.Unexpected && !slices.Contains(tokens, exp.Expected) {
+			tokens = append(tokens, exp.Expected)
+		}
+	}
+	return tokens
+}
+
+// sortExpectations returns a sorted copy of expected without duplicates, each
+// at offset.
+func sortExpectations(expected []Expectation, offset int) []Expectation {
+	sorted := make([]Expectation, len(expected))
+	for i, exp := range expected {
+		exp.Offset = offset
+		sorted[i] = exp
+	}
+	slices.SortStableFunc(sorted, func(a, b Expectation) int {
+		return cmp.Or(cmp.Compare(a.Expected, b.Expected), cmp.Compare(a.Rule, b.Rule))
+	})
+	return slices.Compact(sorted)
+}
+
+// describeExpected returns the part of a ParseError's message that says what
+// was expected: the custom messages, then a list of the unexpected
+// expressions and one of the other expectations.
+func describeExpected(expected []Expectation) string {
+	var messages, unexpected, tokens []string
+	for _, exp := range expected {
+		switch {
+		case exp.Custom:
+			messages = append(messages, exp.Expected)
+		case exp.Unexpected:
+			unexpected = append(unexpected, exp.Expected+" from "+exp.Rule)
+		default:
+			tokens = append(tokens, exp.Expected+" from "+exp.Rule)
+		}
+	}
+	if len(unexpected) > 0 {
+		messages = append(messages, "unexpected "+listTokens(unexpected))
+	}
+	if len(tokens) > 0 {
+		messages = append(messages, "expected "+listTokens(tokens))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// listTokens joins tokens with commas, and "or" before the last.
+func listTokens(tokens []string) string {
+	n := len(tokens)
+	if n == 1 {
+		return tokens[0]
+	}
+	return strings.Join(tokens[:n-1], ", ") + " or " + tokens[n-1]
+}
+
+// expect records that exp was expected at offset, as a terminal that fails
+// there does, for a named expression that failed.
+func (p *PegGoParser) expect(offset int, exp Expectation) {
+	if offset < p.failure.offset || p.silent > 0 {
+		return
+	}
+	if offset > p.failure.offset {
+		p.failure.offset = offset
+		p.failure.expected = p.failure.expected[:0]
+	}
+	p.failure.expected = append(p.failure.expected, exp)
+}
+
+// describeFound returns the Found description of rest, the input from the
+// failure onwards.
+func describeFound(rest string) string {
+	if rest == "" {
+		return "<EOF>"
+	}
+	end := 0
+	for runes := 0; runes < maxFoundRunes && end < len(rest); runes++ {
+		r, size := utf8.DecodeRuneInString(rest[end:])
+		if !isWordRune(r) {
+			break
+		}
+		end += size
+	}
+	if end == 0 {
+		_, end = utf8.DecodeRuneInString(rest)
+	}
+	return strconv.Quote(rest[:end])
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,128 @@
+// This file was generated from examples/canopy/peg.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package peggoparser
+
+import "fmt"
+
+// Limits bounds the resources a parse may use, so that input from untrusted
+// sources cannot exhaust the stack or memory. A zero field means no limit.
+type Limits struct {
+	MaxDepth       int // rules applied within one another
+	MaxSteps       int // rules applied in total
+	MaxMemoEntries int // results stored in the memo table at once
+	MaxInputSize   int // bytes of input
+}
+
+// WithLimits makes a parse that would exceed limits fail with a *LimitError.
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+// LimitError is returned when a parse would exceed one of its Limits.
+type LimitError struct {
+	Limit  string // the name of the field in Limits, such as "MaxDepth"
+	Max    int    // the value of that field
+	Offset int    // the furthest offset the parse had reached
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("parse exceeded %s of %d at offset %d", e.Limit, e.Max, e.Offset)
+}
+
+// begin is called before a parse starts, and returns an error if it should
+// not.
+func (p *PegGoParser) begin() error {
+	if p.opts.err != nil {
+		return p.opts.err
+	}
+	p.depth, p.steps, p.silent, p.lookahead = 0, 0, 0, 0
+	p.guard()
+	p.points = p.points[:0]
+	p.cache.floor, p.cache.dropped = 0, 0
+	if p.inputTooLong(len(p.input)) {
+		return p.stopErr
+	}
+	return nil
+}
+
+// startOver clears everything an earlier parse with the same parser left
+// behind, the memo included, so that a parser created by New or NewReader
+// may be used for more than one parse.
+func (p *PegGoParser) startOver() {
+	p.restart(0)
+	if p.cache.count > 0 {
+		p.cache.reset()
+	}
+}
+
+// restart clears the state of an earlier parse for a new one from the byte
+// offset start, but keeps the memo, for ParseAt and Reparse to reuse.
+func (p *PegGoParser) restart(start int) {
+	p.offset, p.seen = start, 0
+	p.failure = failureState{expected: p.failure.expected[:0]}
+	p.actionErr, p.stopErr = nil, nil
+}
+
+// guard sets p.guarded if the parse has limits or a context to check, which
+// cutFailed may have set for a parse with neither.
+func (p *PegGoParser) guard() {
+	p.guarded = p.opts.limits != (Limits{}) || p.ctx != nil
+}
+
+// enter is called as each rule starts in a parser with limits or a context,
+// and reports whether the parse has stopped, in which case the rule fails
+// without doing anything. Otherwise the rule decrements p.depth when it
+// returns.
+func (p *PegGoParser) enter() bool {
+	if p.stopErr != nil {
+		return true
+	}
+	p.depth++
+	p.steps++
+	if p.exceeded() || p.contextDone() {
+		p.depth--
+		return true
+	}
+	return false
+}
+
+// exceeded reports whether the parse has gone past one of its limits, other
+// than the input size, which is checked as the input is read.
+func (p *PegGoParser) exceeded() bool {
+	limits := &p.opts.limits
+	switch {
+	case limits.MaxDepth > 0 && p.depth > limits.MaxDepth:
+		p.stopErr = p.limitError("MaxDepth", limits.MaxDepth)
+	case limits.MaxSteps > 0 && p.steps > limits.MaxSteps:
+		p.stopErr = p.limitError("MaxSteps", limits.MaxSteps)
+	case p.cache.full:
+		p.stopErr = p.limitError("MaxMemoEntries", limits.MaxMemoEntries)
+	default:
+		return false
+	}
+	return true
+}
+
+// inputTooLong reports whether size bytes of input is more than the limit,
+// and stops the parse if so.
+func (p *PegGoParser) inputTooLong(size int) bool {
+	limit := p.opts.limits.MaxInputSize
+	if limit == 0 || size <= limit {
+		return false
+	}
+	p.stopErr = p.limitError("MaxInputSize", limit)
+	return true
+}
+
+func (p *PegGoParser) limitError(limit string, value int) error {
+	return &LimitError{Limit: limit, Max: value, Offset: p.reached()}
+}
+
+// reached returns the furthest offset the parse has reached, for errors
+// that stop it part way through.
+func (p *PegGoParser) reached() int {
+	return p.offsets.convert(max(p.offset, p.failure.offset))
+}
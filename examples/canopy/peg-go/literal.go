@@ -0,0 +1,42 @@
+// This file was generated from examples/canopy/peg.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package peggoparser
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// matchLiteralFold reports whether input contains literal at offset under
+// simple Unicode case folding, as strings.EqualFold compares strings. Folded
+// runes can differ in width, so it returns the offset just past the match, or
+// -1 if there is none.
+func matchLiteralFold(input string, offset int, literal string) int {
+	for _, r := range literal {
+		if offset >= len(input) {
+			return -1
+		}
+		c, size := rune(input[offset]), 1
+		if c >= utf8.RuneSelf {
+			c, size = utf8.DecodeRuneInString(input[offset:])
+		}
+		if !foldEqual(c, r) {
+			return -1
+		}
+		offset += size
+	}
+	return offset
+}
+
+func foldEqual(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
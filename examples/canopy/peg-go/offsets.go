@@ -0,0 +1,165 @@
+// This file was generated from examples/canopy/peg.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package peggoparser
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+// offsetIndex converts the byte offsets the parser works with into the
+// offsets it reports. Unless WithByteOffsets is given those are rune offsets,
+// found by counting runes forward from the nearest checkpoint. ASCII input
+// needs no checkpoints, since its byte and rune offsets are the same.
+//
+// Input read from an io.Reader or passed to Feed arrives in pieces, so the
+// index is extended as the input grows. scanned and runes record how far it has got.
+type offsetIndex struct {
+	input       string
+	byteOffsets bool
+	checkpoints []offsetCheckpoint
+	scanned     int
+	runes       int
+}
+
+// offsetCheckpoint records the rune offset of the first rune starting at or
+// after a multiple of offsetStride bytes.
+type offsetCheckpoint struct {
+	byteOffset int
+	runeOffset int
+}
+
+const offsetStride = 256
+
+func newOffsetIndex(input string, byteOffsets bool) offsetIndex {
+	x := offsetIndex{byteOffsets: byteOffsets}
+	x.extend(input, true)
+	return x
+}
+
+// extend indexes input, which begins with the input already indexed. Unless
+// final is set, a rune cut off at the end of input is left for the next call.
+func (x *offsetIndex) extend(input string, final bool) {
+	x.input = input
+	if x.byteOffsets {
+		return
+	}
+	if x.checkpoints == nil {
+		if isASCII(input[x.scanned:]) {
+			x.scanned, x.runes = len(input), len(input)
+			return
+		}
+		for i := 0; i < x.scanned; i += offsetStride {
+			x.checkpoints = append(x.checkpoints, offsetCheckpoint{i, i})
+		}
+	}
+	i, n := x.scanned, x.runes
+	for i < len(input) {
+		if !final && !utf8.FullRuneInString(input[i:]) {
+			break
+		}
+		if i >= len(x.checkpoints)*offsetStride {
+			x.checkpoints = append(x.checkpoints, offsetCheckpoint{i, n})
+		}
+		i += runeWidth(input, i)
+		n++
+	}
+	x.scanned, x.runes = i, n
+}
+
+// convert returns the reported offset of the rune starting at byte offset b.
+func (x *offsetIndex) convert(b int) int {
+	if x.checkpoints == nil {
+		return b
+	}
+	return x.countRunes(b)
+}
+
+// span returns the span of the input between byte offsets start and end.
+func (x *offsetIndex) span(start, end int) Span {
+	if x.checkpoints == nil {
+		return Span{Start: start, End: end, ByteStart: start, ByteEnd: end}
+	}
+	return x.runeSpan(start, end)
+}
+
+func (x *offsetIndex) runeSpan(start, end int) Span {
+	return Span{Start: x.countRunes(start), End: x.countRunes(end), ByteStart: start, ByteEnd: end}
+}
+
+func (x *offsetIndex) countRunes(b int) int {
+	k := min(b/offsetStride, len(x.checkpoints)-1)
+	for x.checkpoints[k].byteOffset > b {
+		k--
+	}
+	c := x.checkpoints[k]
+	return c.runeOffset + utf8.RuneCountInString(x.input[c.byteOffset:b])
+}
+
+// byteOffset is the inverse of convert: it returns the byte offset of a
+// reported offset. Offsets past the end of the input map to its length.
+func (x *offsetIndex) byteOffset(offset int) int {
+	if x.checkpoints == nil {
+		return offset
+	}
+	k, found := slices.BinarySearchFunc(x.checkpoints, offset, func(c offsetCheckpoint, r int) int {
+		return c.runeOffset - r
+	})
+	if !found {
+		k--
+	}
+	if k < 0 {
+		return 0
+	}
+	c := x.checkpoints[k]
+	b, n := c.byteOffset, c.runeOffset
+	for n < offset && b < len(x.input) {
+		b += runeWidth(x.input, b)
+		n++
+	}
+	return b
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// runeWidth returns the number of bytes in the rune starting at offset.
+func runeWidth(input string, offset int) int {
+	if input[offset] < utf8.RuneSelf {
+		return 1
+	}
+	_, size := utf8.DecodeRuneInString(input[offset:])
+	return size
+}
+
+// leadWidth returns the length of the UTF-8 sequence that begins with byte
+// b, or 1 if b cannot begin one, so that a whole rune can be read before it
+// is decoded.
+func leadWidth(b byte) int {
+	switch {
+	case b < 0xc0:
+		return 1
+	case b < 0xe0:
+		return 2
+	case b < 0xf0:
+		return 3
+	}
+	return 4
+}
+
+// WithByteOffsets makes the parser report byte offsets into the input, rather
+// than rune offsets, from TreeNode.Offset, as the start and end arguments to
+// actions, and in ParseError. Byte offsets can be used to slice the input
+// directly, and avoid the cost of counting runes in non-ASCII input.
+func WithByteOffsets() Option {
+	return func(o *options) {
+		o.byteOffsets = true
+	}
+}
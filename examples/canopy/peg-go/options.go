@@ -0,0 +1,17 @@
+// This file was generated from examples/canopy/peg.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package peggoparser
+
+// Option configures a parser. Options are passed to New or Parse.
+type Option func(*options)
+
+// err is set by a WithMemoRules naming an unknown rule, and fails the parse.
+type options struct {
+	memo        *[numRules]bool
+	profile     *MemoProfile
+	byteOffsets bool
+	limits      Limits
+	panics      bool
+	err         error
+}
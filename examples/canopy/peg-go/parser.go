@@ -4,50 +4,45 @@
 package peggoparser
 
 import (
-		"fmt"
-	"regexp"
-	"strings"
+		"context"
+	"fmt"
+	"io"
+	"slices"
+	"unicode/utf8"
 )
 
 type NodeExtender func(TreeNode) TreeNode
 
-type expectation struct {
-	rule string
-	expected string
-}
-
 type failureState struct {
 	offset int
-	expected []expectation
-}
-
-type cacheEntry struct {
-	node TreeNode
-	offset int
-}
-
-type ParseError struct {
-	Input string
-	Offset int
-	Line int
-	Column int
-	Expected []expectation
-	Message string
-}
-
-func (e *ParseError) Error() string {
-	return e.Message
+	expected []Expectation
 }
 
 type PegGoParser struct {
-	input []rune
-	inputString string
+	input string
+	offsets offsetIndex
+	lines *LineIndex
+	reader io.Reader
+	stream *streamState
+	open bool
+	buf []byte
+	readErr error
 	actions Actions
 	types map[string]NodeExtender
+	opts options
 	offset int
-	cache map[string]map[int]cacheEntry
+	seen int
+	cache memoTable
 	failure failureState
+	silent int
+	lookahead int
+	points []backtrackPoint
 	actionErr error
+	ctx context.Context
+	guarded bool
+	depth int
+	steps int
+	stopErr error
 }
 
 
@@ -59,9 +54,9 @@ type Node1 struct {
 
 var _ TreeNode = (*Node1)(nil)
 
-func newNode1(text string, start int, elements []TreeNode) TreeNode {
+func newNode1(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node1{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.GrammarName = elements[1]
 	node.Rules = elements[2]
@@ -76,9 +71,9 @@ type Node2 struct {
 
 var _ TreeNode = (*Node2)(nil)
 
-func newNode2(text string, start int, elements []TreeNode) TreeNode {
+func newNode2(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node2{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.GrammarRule = elements[1]
 	return node
@@ -92,9 +87,9 @@ type Node3 struct {
 
 var _ TreeNode = (*Node3)(nil)
 
-func newNode3(text string, start int, elements []TreeNode) TreeNode {
+func newNode3(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node3{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.ObjectIdentifier = elements[3]
 	return node
@@ -110,9 +105,9 @@ type Node4 struct {
 
 var _ TreeNode = (*Node4)(nil)
 
-func newNode4(text string, start int, elements []TreeNode) TreeNode {
+func newNode4(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node4{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Identifier = elements[0]
 	node.Assignment = elements[1]
@@ -128,9 +123,9 @@ type Node5 struct {
 
 var _ TreeNode = (*Node5)(nil)
 
-func newNode5(text string, start int, elements []TreeNode) TreeNode {
+func newNode5(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node5{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.ParsingExpression = elements[2]
 	return node
@@ -146,9 +141,9 @@ type Node6 struct {
 
 var _ TreeNode = (*Node6)(nil)
 
-func newNode6(text string, start int, elements []TreeNode) TreeNode {
+func newNode6(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node6{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.FirstPart = elements[0]
 	node.ChoicePart = elements[0]
@@ -165,9 +160,9 @@ type Node7 struct {
 
 var _ TreeNode = (*Node7)(nil)
 
-func newNode7(text string, start int, elements []TreeNode) TreeNode {
+func newNode7(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node7{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Expression = elements[3]
 	node.ChoicePart = elements[3]
@@ -182,9 +177,9 @@ type Node8 struct {
 
 var _ TreeNode = (*Node8)(nil)
 
-func newNode8(text string, start int, elements []TreeNode) TreeNode {
+func newNode8(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node8{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.TypeTag = elements[1]
 	return node
@@ -199,9 +194,9 @@ type Node9 struct {
 
 var _ TreeNode = (*Node9)(nil)
 
-func newNode9(text string, start int, elements []TreeNode) TreeNode {
+func newNode9(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node9{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.ActionableExpression = elements[0]
 	node.ActionTag = elements[2]
@@ -216,9 +211,9 @@ type Node10 struct {
 
 var _ TreeNode = (*Node10)(nil)
 
-func newNode10(text string, start int, elements []TreeNode) TreeNode {
+func newNode10(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node10{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.ActionableExpression = elements[2]
 	return node
@@ -232,9 +227,9 @@ type Node11 struct {
 
 var _ TreeNode = (*Node11)(nil)
 
-func newNode11(text string, start int, elements []TreeNode) TreeNode {
+func newNode11(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node11{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Identifier = elements[1]
 	return node
@@ -248,9 +243,9 @@ type Node12 struct {
 
 var _ TreeNode = (*Node12)(nil)
 
-func newNode12(text string, start int, elements []TreeNode) TreeNode {
+func newNode12(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node12{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.ObjectIdentifier = elements[1]
 	return node
@@ -266,9 +261,9 @@ type Node13 struct {
 
 var _ TreeNode = (*Node13)(nil)
 
-func newNode13(text string, start int, elements []TreeNode) TreeNode {
+func newNode13(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node13{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.FirstPart = elements[0]
 	node.SequencePart = elements[0]
@@ -285,9 +280,9 @@ type Node14 struct {
 
 var _ TreeNode = (*Node14)(nil)
 
-func newNode14(text string, start int, elements []TreeNode) TreeNode {
+func newNode14(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node14{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Expression = elements[1]
 	node.SequencePart = elements[1]
@@ -302,9 +297,9 @@ type Node15 struct {
 
 var _ TreeNode = (*Node15)(nil)
 
-func newNode15(text string, start int, elements []TreeNode) TreeNode {
+func newNode15(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node15{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Expression = elements[1]
 	return node
@@ -318,9 +313,9 @@ type Node16 struct {
 
 var _ TreeNode = (*Node16)(nil)
 
-func newNode16(text string, start int, elements []TreeNode) TreeNode {
+func newNode16(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node16{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Atom = elements[0]
 	return node
@@ -335,9 +330,9 @@ type Node17 struct {
 
 var _ TreeNode = (*Node17)(nil)
 
-func newNode17(text string, start int, elements []TreeNode) TreeNode {
+func newNode17(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node17{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Atom = elements[0]
 	node.Quantifier = elements[1]
@@ -353,9 +348,9 @@ type Node18 struct {
 
 var _ TreeNode = (*Node18)(nil)
 
-func newNode18(text string, start int, elements []TreeNode) TreeNode {
+func newNode18(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node18{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Predicate = elements[0]
 	node.Atom = elements[1]
@@ -370,9 +365,9 @@ type Node19 struct {
 
 var _ TreeNode = (*Node19)(nil)
 
-func newNode19(text string, start int, elements []TreeNode) TreeNode {
+func newNode19(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node19{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Identifier = elements[0]
 	return node
@@ -386,9 +381,9 @@ type Node20 struct {
 
 var _ TreeNode = (*Node20)(nil)
 
-func newNode20(text string, start int, elements []TreeNode) TreeNode {
+func newNode20(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node20{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Identifier = elements[0]
 	return node
@@ -402,9 +397,9 @@ type Node21 struct {
 
 var _ TreeNode = (*Node21)(nil)
 
-func newNode21(text string, start int, elements []TreeNode) TreeNode {
+func newNode21(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node21{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Identifier = elements[0]
 	return node
@@ -418,34 +413,73 @@ type Node22 struct {
 
 var _ TreeNode = (*Node22)(nil)
 
-func newNode22(text string, start int, elements []TreeNode) TreeNode {
+func newNode22(text string, span Span, elements []TreeNode) TreeNode {
 	node := &Node22{
-		BaseNode: BaseNode{text: text, offset: start, children: elements},
+		BaseNode: BaseNode{text: text, span: span, children: elements},
 	}
 	node.Identifier = elements[1]
 	return node
 }
 
 
-var REGEX_1 = regexp.MustCompile(`^[^"]`)
-var REGEX_2 = regexp.MustCompile(`^[^']`)
-var REGEX_3 = regexp.MustCompile("^[^`]")
-var REGEX_4 = regexp.MustCompile(`^[^\]]`)
-var REGEX_5 = regexp.MustCompile(`^[a-zA-Z_]`)
-var REGEX_6 = regexp.MustCompile(`^[a-zA-Z0-9_]`)
-var REGEX_7 = regexp.MustCompile(`^[\s]`)
-var REGEX_8 = regexp.MustCompile(`^[^\n]`)
+// [^"]
+var charClass1 = charClass{
+	ascii: [2]uint64{0xfffffffbffffffff, 0xffffffffffffffff},
+	ranges: []rune{0x80, 0x10ffff},
+}
+
+// [^']
+var charClass2 = charClass{
+	ascii: [2]uint64{0xffffff7fffffffff, 0xffffffffffffffff},
+	ranges: []rune{0x80, 0x10ffff},
+}
+
+// [^`]
+var charClass3 = charClass{
+	ascii: [2]uint64{0xffffffffffffffff, 0xfffffffeffffffff},
+	ranges: []rune{0x80, 0x10ffff},
+}
+
+// [^\]]
+var charClass4 = charClass{
+	ascii: [2]uint64{0xffffffffffffffff, 0xffffffffdfffffff},
+	ranges: []rune{0x80, 0x10ffff},
+}
+
+// [a-zA-Z_]
+var charClass5 = charClass{
+	ascii: [2]uint64{0x0, 0x7fffffe87fffffe},
+}
+
+// [a-zA-Z0-9_]
+var charClass6 = charClass{
+	ascii: [2]uint64{0x3ff000000000000, 0x7fffffe87fffffe},
+}
+
+// [\s]
+var charClass7 = charClass{
+	ascii: [2]uint64{0x100003600, 0x0},
+}
+
+// [^\n]
+var charClass8 = charClass{
+	ascii: [2]uint64{0xfffffffffffffbff, 0xffffffffffffffff},
+	ranges: []rune{0x80, 0x10ffff},
+}
+
 
 func (p *PegGoParser) _read_grammar() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address0 TreeNode = nil
 	var index0 int = p.offset
-	var cache0 map[int]cacheEntry = p.cache["grammar"]
-	if cache0 == nil {
-		cache0 = make(map[int]cacheEntry)
-		p.cache["grammar"] = cache0
-	}
-	if entry, ok := cache0[index0]; ok {
+	var silent0 int = p.silent
+	if entry, ok := p.cache.get(RuleGrammar, index0, silent0 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index1 int = p.offset
@@ -463,7 +497,7 @@ func (p *PegGoParser) _read_grammar() TreeNode {
 		}
 	}
 	if len(elements1) >= 0 {
-		address1 = &BaseNode{text: p.slice(index2, p.offset), offset: index2, children: elements1}
+		address1 = &BaseNode{text: p.slice(index2, p.offset), span: p.offsets.span(index2, p.offset), children: elements1}
 	} else {
 		address1 = nil
 	}
@@ -493,7 +527,7 @@ func (p *PegGoParser) _read_grammar() TreeNode {
 					}
 				}
 				if len(elements4) >= 0 {
-					address6 = &BaseNode{text: p.slice(index5, p.offset), offset: index5, children: elements4}
+					address6 = &BaseNode{text: p.slice(index5, p.offset), span: p.offsets.span(index5, p.offset), children: elements4}
 				} else {
 					address6 = nil
 				}
@@ -514,7 +548,7 @@ func (p *PegGoParser) _read_grammar() TreeNode {
 				if elements3 == nil {
 					address5 = nil
 				} else {
-					address5 = newNode2(p.slice(index4, p.offset), index4, elements3)
+					address5 = newNode2(p.slice(index4, p.offset), p.offsets.span(index4, p.offset), elements3)
 				}
 				if address5 != nil {
 					elements2 = append(elements2, address5)
@@ -523,7 +557,7 @@ func (p *PegGoParser) _read_grammar() TreeNode {
 				}
 			}
 			if len(elements2) >= 1 {
-				address4 = &BaseNode{text: p.slice(index3, p.offset), offset: index3, children: elements2}
+				address4 = &BaseNode{text: p.slice(index3, p.offset), span: p.offsets.span(index3, p.offset), children: elements2}
 			} else {
 				address4 = nil
 			}
@@ -542,7 +576,7 @@ func (p *PegGoParser) _read_grammar() TreeNode {
 					}
 				}
 				if len(elements5) >= 0 {
-					address9 = &BaseNode{text: p.slice(index6, p.offset), offset: index6, children: elements5}
+					address9 = &BaseNode{text: p.slice(index6, p.offset), span: p.offsets.span(index6, p.offset), children: elements5}
 				} else {
 					address9 = nil
 				}
@@ -567,69 +601,67 @@ func (p *PegGoParser) _read_grammar() TreeNode {
 	if elements0 == nil {
 		address0 = nil
 	} else {
-		address0 = newNode1(p.slice(index1, p.offset), index1, elements0)
+		address0 = newNode1(p.slice(index1, p.offset), p.offsets.span(index1, p.offset), elements0)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleGrammar, index0, address0, p.offset, silent0 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache0[index0] = cacheEntry{node: address0, offset: p.offset}
 	return address0
 }
 
 func (p *PegGoParser) _read_grammar_name() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address11 TreeNode = nil
 	var index7 int = p.offset
-	var cache1 map[int]cacheEntry = p.cache["grammar_name"]
-	if cache1 == nil {
-		cache1 = make(map[int]cacheEntry)
-		p.cache["grammar_name"] = cache1
-	}
-	if entry, ok := cache1[index7]; ok {
+	var silent1 int = p.silent
+	if entry, ok := p.cache.get(RuleGrammarName, index7, silent1 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index8 int = p.offset
 	var elements6 []TreeNode = make([]TreeNode, 4)
 	var address12 TreeNode = nil
-	var chunk0 string = ""
-	var max0 int = p.offset + 7
-	if max0 <= len(p.input) {
-		chunk0 = string(p.input[p.offset:max0])
-	}
-	if strings.EqualFold(chunk0, "grammar") {
-		address12 = &BaseNode{text: p.slice(p.offset, p.offset + 7), offset: p.offset, children: nil}
-		p.offset = p.offset + 7
+	var end0 int = matchLiteralFold(p.peek(28), p.offset, "grammar")
+	if end0 >= 0 {
+		address12 = &BaseNode{text: p.slice(p.offset, end0), span: p.offsets.span(p.offset, end0), children: nil}
+		p.offset = end0
 	} else {
 		address12 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::grammar_name", expected: "`grammar`"})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::grammar_name", Expected: "`grammar`"})
 		}
 	}
 	if address12 != nil {
 		elements6[0] = address12
 		var address13 TreeNode = nil
 		var index9 int = p.offset
-		var chunk1 string = ""
-		var max1 int = p.offset + 1
-		if max1 <= len(p.input) {
-			chunk1 = string(p.input[p.offset:max1])
-		}
-		if chunk1 == ":" {
-			address13 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+		if p.avail(1) && p.input[p.offset] == ':' {
+			address13 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 			p.offset = p.offset + 1
 		} else {
 			address13 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::grammar_name", expected: "\":\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::grammar_name", Expected: "\":\""})
 			}
 		}
 		if address13 == nil {
-			address13 = &BaseNode{text: p.slice(index9, index9), offset: index9, children: nil}
+			address13 = &BaseNode{text: p.slice(index9, index9), span: p.offsets.span(index9, index9), children: nil}
 			p.offset = index9
 		}
 		if address13 != nil {
@@ -647,7 +679,7 @@ func (p *PegGoParser) _read_grammar_name() TreeNode {
 				}
 			}
 			if len(elements7) >= 1 {
-				address14 = &BaseNode{text: p.slice(index10, p.offset), offset: index10, children: elements7}
+				address14 = &BaseNode{text: p.slice(index10, p.offset), span: p.offsets.span(index10, p.offset), children: elements7}
 			} else {
 				address14 = nil
 			}
@@ -676,22 +708,29 @@ func (p *PegGoParser) _read_grammar_name() TreeNode {
 	if elements6 == nil {
 		address11 = nil
 	} else {
-		address11 = newNode3(p.slice(index8, p.offset), index8, elements6)
+		address11 = newNode3(p.slice(index8, p.offset), p.offsets.span(index8, p.offset), elements6)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleGrammarName, index7, address11, p.offset, silent1 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache1[index7] = cacheEntry{node: address11, offset: p.offset}
 	return address11
 }
 
 func (p *PegGoParser) _read_grammar_rule() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address17 TreeNode = nil
 	var index11 int = p.offset
-	var cache2 map[int]cacheEntry = p.cache["grammar_rule"]
-	if cache2 == nil {
-		cache2 = make(map[int]cacheEntry)
-		p.cache["grammar_rule"] = cache2
-	}
-	if entry, ok := cache2[index11]; ok {
+	var silent2 int = p.silent
+	if entry, ok := p.cache.get(RuleGrammarRule, index11, silent2 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index12 int = p.offset
@@ -723,22 +762,29 @@ func (p *PegGoParser) _read_grammar_rule() TreeNode {
 	if elements8 == nil {
 		address17 = nil
 	} else {
-		address17 = newNode4(p.slice(index12, p.offset), index12, elements8)
+		address17 = newNode4(p.slice(index12, p.offset), p.offsets.span(index12, p.offset), elements8)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleGrammarRule, index11, address17, p.offset, silent2 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache2[index11] = cacheEntry{node: address17, offset: p.offset}
 	return address17
 }
 
 func (p *PegGoParser) _read_assignment() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address21 TreeNode = nil
 	var index13 int = p.offset
-	var cache3 map[int]cacheEntry = p.cache["assignment"]
-	if cache3 == nil {
-		cache3 = make(map[int]cacheEntry)
-		p.cache["assignment"] = cache3
-	}
-	if entry, ok := cache3[index13]; ok {
+	var silent3 int = p.silent
+	if entry, ok := p.cache.get(RuleAssignment, index13, silent3 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index14 int = p.offset
@@ -756,29 +802,24 @@ func (p *PegGoParser) _read_assignment() TreeNode {
 		}
 	}
 	if len(elements10) >= 1 {
-		address22 = &BaseNode{text: p.slice(index15, p.offset), offset: index15, children: elements10}
+		address22 = &BaseNode{text: p.slice(index15, p.offset), span: p.offsets.span(index15, p.offset), children: elements10}
 	} else {
 		address22 = nil
 	}
 	if address22 != nil {
 		elements9[0] = address22
 		var address24 TreeNode = nil
-		var chunk2 string = ""
-		var max2 int = p.offset + 2
-		if max2 <= len(p.input) {
-			chunk2 = string(p.input[p.offset:max2])
-		}
-		if chunk2 == "<-" {
-			address24 = &BaseNode{text: p.slice(p.offset, p.offset + 2), offset: p.offset, children: nil}
+		if p.avail(2) && p.input[p.offset:p.offset+2] == "<-" {
+			address24 = &BaseNode{text: p.slice(p.offset, p.offset + 2), span: p.offsets.span(p.offset, p.offset + 2), children: nil}
 			p.offset = p.offset + 2
 		} else {
 			address24 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::assignment", expected: "\"<-\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::assignment", Expected: "\"<-\""})
 			}
 		}
 		if address24 != nil {
@@ -796,7 +837,7 @@ func (p *PegGoParser) _read_assignment() TreeNode {
 				}
 			}
 			if len(elements11) >= 1 {
-				address25 = &BaseNode{text: p.slice(index16, p.offset), offset: index16, children: elements11}
+				address25 = &BaseNode{text: p.slice(index16, p.offset), span: p.offsets.span(index16, p.offset), children: elements11}
 			} else {
 				address25 = nil
 			}
@@ -817,22 +858,29 @@ func (p *PegGoParser) _read_assignment() TreeNode {
 	if elements9 == nil {
 		address21 = nil
 	} else {
-		address21 = &BaseNode{text: p.slice(index14, p.offset), offset: index14, children: elements9}
+		address21 = &BaseNode{text: p.slice(index14, p.offset), span: p.offsets.span(index14, p.offset), children: elements9}
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleAssignment, index13, address21, p.offset, silent3 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache3[index13] = cacheEntry{node: address21, offset: p.offset}
 	return address21
 }
 
 func (p *PegGoParser) _read_parsing_expression() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address27 TreeNode = nil
 	var index17 int = p.offset
-	var cache4 map[int]cacheEntry = p.cache["parsing_expression"]
-	if cache4 == nil {
-		cache4 = make(map[int]cacheEntry)
-		p.cache["parsing_expression"] = cache4
-	}
-	if entry, ok := cache4[index17]; ok {
+	var silent4 int = p.silent
+	if entry, ok := p.cache.get(RuleParsingExpression, index17, silent4 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index18 int = p.offset
@@ -844,41 +892,43 @@ func (p *PegGoParser) _read_parsing_expression() TreeNode {
 			p.offset = index18
 		}
 	}
-	cache4[index17] = cacheEntry{node: address27, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleParsingExpression, index17, address27, p.offset, silent4 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address27
 }
 
 func (p *PegGoParser) _read_parenthesised_expression() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address28 TreeNode = nil
 	var index19 int = p.offset
-	var cache5 map[int]cacheEntry = p.cache["parenthesised_expression"]
-	if cache5 == nil {
-		cache5 = make(map[int]cacheEntry)
-		p.cache["parenthesised_expression"] = cache5
-	}
-	if entry, ok := cache5[index19]; ok {
+	var silent5 int = p.silent
+	if entry, ok := p.cache.get(RuleParenthesisedExpression, index19, silent5 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index20 int = p.offset
 	var elements12 []TreeNode = make([]TreeNode, 5)
 	var address29 TreeNode = nil
-	var chunk3 string = ""
-	var max3 int = p.offset + 1
-	if max3 <= len(p.input) {
-		chunk3 = string(p.input[p.offset:max3])
-	}
-	if chunk3 == "(" {
-		address29 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '(' {
+		address29 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address29 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::parenthesised_expression", expected: "\"(\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::parenthesised_expression", Expected: "\"(\""})
 		}
 	}
 	if address29 != nil {
@@ -896,7 +946,7 @@ func (p *PegGoParser) _read_parenthesised_expression() TreeNode {
 			}
 		}
 		if len(elements13) >= 0 {
-			address30 = &BaseNode{text: p.slice(index21, p.offset), offset: index21, children: elements13}
+			address30 = &BaseNode{text: p.slice(index21, p.offset), span: p.offsets.span(index21, p.offset), children: elements13}
 		} else {
 			address30 = nil
 		}
@@ -919,29 +969,24 @@ func (p *PegGoParser) _read_parenthesised_expression() TreeNode {
 					}
 				}
 				if len(elements14) >= 0 {
-					address33 = &BaseNode{text: p.slice(index22, p.offset), offset: index22, children: elements14}
+					address33 = &BaseNode{text: p.slice(index22, p.offset), span: p.offsets.span(index22, p.offset), children: elements14}
 				} else {
 					address33 = nil
 				}
 				if address33 != nil {
 					elements12[3] = address33
 					var address35 TreeNode = nil
-					var chunk4 string = ""
-					var max4 int = p.offset + 1
-					if max4 <= len(p.input) {
-						chunk4 = string(p.input[p.offset:max4])
-					}
-					if chunk4 == ")" {
-						address35 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+					if p.avail(1) && p.input[p.offset] == ')' {
+						address35 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 						p.offset = p.offset + 1
 					} else {
 						address35 = nil
-						if p.offset > p.failure.offset {
-							p.failure.offset = p.offset
-							p.failure.expected = nil
-						}
-						if p.offset == p.failure.offset {
-							p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::parenthesised_expression", expected: "\")\""})
+						if p.offset >= p.failure.offset && p.silent == 0 {
+							if p.offset > p.failure.offset {
+								p.failure.offset = p.offset
+								p.failure.expected = p.failure.expected[:0]
+							}
+							p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::parenthesised_expression", Expected: "\")\""})
 						}
 					}
 					if address35 != nil {
@@ -969,22 +1014,29 @@ func (p *PegGoParser) _read_parenthesised_expression() TreeNode {
 	if elements12 == nil {
 		address28 = nil
 	} else {
-		address28 = newNode5(p.slice(index20, p.offset), index20, elements12)
+		address28 = newNode5(p.slice(index20, p.offset), p.offsets.span(index20, p.offset), elements12)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleParenthesisedExpression, index19, address28, p.offset, silent5 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache5[index19] = cacheEntry{node: address28, offset: p.offset}
 	return address28
 }
 
 func (p *PegGoParser) _read_choice_expression() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address36 TreeNode = nil
 	var index23 int = p.offset
-	var cache6 map[int]cacheEntry = p.cache["choice_expression"]
-	if cache6 == nil {
-		cache6 = make(map[int]cacheEntry)
-		p.cache["choice_expression"] = cache6
-	}
-	if entry, ok := cache6[index23]; ok {
+	var silent6 int = p.silent
+	if entry, ok := p.cache.get(RuleChoiceExpression, index23, silent6 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index24 int = p.offset
@@ -1013,29 +1065,24 @@ func (p *PegGoParser) _read_choice_expression() TreeNode {
 				}
 			}
 			if len(elements18) >= 1 {
-				address40 = &BaseNode{text: p.slice(index27, p.offset), offset: index27, children: elements18}
+				address40 = &BaseNode{text: p.slice(index27, p.offset), span: p.offsets.span(index27, p.offset), children: elements18}
 			} else {
 				address40 = nil
 			}
 			if address40 != nil {
 				elements17[0] = address40
 				var address42 TreeNode = nil
-				var chunk5 string = ""
-				var max5 int = p.offset + 1
-				if max5 <= len(p.input) {
-					chunk5 = string(p.input[p.offset:max5])
-				}
-				if chunk5 == "/" {
-					address42 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == '/' {
+					address42 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address42 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::choice_expression", expected: "\"/\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::choice_expression", Expected: "\"/\""})
 					}
 				}
 				if address42 != nil {
@@ -1053,7 +1100,7 @@ func (p *PegGoParser) _read_choice_expression() TreeNode {
 						}
 					}
 					if len(elements19) >= 1 {
-						address43 = &BaseNode{text: p.slice(index28, p.offset), offset: index28, children: elements19}
+						address43 = &BaseNode{text: p.slice(index28, p.offset), span: p.offsets.span(index28, p.offset), children: elements19}
 					} else {
 						address43 = nil
 					}
@@ -1082,7 +1129,7 @@ func (p *PegGoParser) _read_choice_expression() TreeNode {
 			if elements17 == nil {
 				address39 = nil
 			} else {
-				address39 = newNode7(p.slice(index26, p.offset), index26, elements17)
+				address39 = newNode7(p.slice(index26, p.offset), p.offsets.span(index26, p.offset), elements17)
 			}
 			if address39 != nil {
 				elements16 = append(elements16, address39)
@@ -1091,7 +1138,7 @@ func (p *PegGoParser) _read_choice_expression() TreeNode {
 			}
 		}
 		if len(elements16) >= 1 {
-			address38 = &BaseNode{text: p.slice(index25, p.offset), offset: index25, children: elements16}
+			address38 = &BaseNode{text: p.slice(index25, p.offset), span: p.offsets.span(index25, p.offset), children: elements16}
 		} else {
 			address38 = nil
 		}
@@ -1108,22 +1155,29 @@ func (p *PegGoParser) _read_choice_expression() TreeNode {
 	if elements15 == nil {
 		address36 = nil
 	} else {
-		address36 = newNode6(p.slice(index24, p.offset), index24, elements15)
+		address36 = newNode6(p.slice(index24, p.offset), p.offsets.span(index24, p.offset), elements15)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleChoiceExpression, index23, address36, p.offset, silent6 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache6[index23] = cacheEntry{node: address36, offset: p.offset}
 	return address36
 }
 
 func (p *PegGoParser) _read_choice_part() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address46 TreeNode = nil
 	var index29 int = p.offset
-	var cache7 map[int]cacheEntry = p.cache["choice_part"]
-	if cache7 == nil {
-		cache7 = make(map[int]cacheEntry)
-		p.cache["choice_part"] = cache7
-	}
-	if entry, ok := cache7[index29]; ok {
+	var silent7 int = p.silent
+	if entry, ok := p.cache.get(RuleChoicePart, index29, silent7 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index30 int = p.offset
@@ -1161,7 +1215,7 @@ func (p *PegGoParser) _read_choice_part() TreeNode {
 			}
 		}
 		if len(elements22) >= 1 {
-			address49 = &BaseNode{text: p.slice(index34, p.offset), offset: index34, children: elements22}
+			address49 = &BaseNode{text: p.slice(index34, p.offset), span: p.offsets.span(index34, p.offset), children: elements22}
 		} else {
 			address49 = nil
 		}
@@ -1182,10 +1236,10 @@ func (p *PegGoParser) _read_choice_part() TreeNode {
 		if elements21 == nil {
 			address48 = nil
 		} else {
-			address48 = newNode8(p.slice(index33, p.offset), index33, elements21)
+			address48 = newNode8(p.slice(index33, p.offset), p.offsets.span(index33, p.offset), elements21)
 		}
 		if address48 == nil {
-			address48 = &BaseNode{text: p.slice(index32, index32), offset: index32, children: nil}
+			address48 = &BaseNode{text: p.slice(index32, index32), span: p.offsets.span(index32, index32), children: nil}
 			p.offset = index32
 		}
 		if address48 != nil {
@@ -1201,22 +1255,29 @@ func (p *PegGoParser) _read_choice_part() TreeNode {
 	if elements20 == nil {
 		address46 = nil
 	} else {
-		address46 = &BaseNode{text: p.slice(index30, p.offset), offset: index30, children: elements20}
+		address46 = &BaseNode{text: p.slice(index30, p.offset), span: p.offsets.span(index30, p.offset), children: elements20}
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleChoicePart, index29, address46, p.offset, silent7 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache7[index29] = cacheEntry{node: address46, offset: p.offset}
 	return address46
 }
 
 func (p *PegGoParser) _read_action_expression() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address52 TreeNode = nil
 	var index35 int = p.offset
-	var cache8 map[int]cacheEntry = p.cache["action_expression"]
-	if cache8 == nil {
-		cache8 = make(map[int]cacheEntry)
-		p.cache["action_expression"] = cache8
-	}
-	if entry, ok := cache8[index35]; ok {
+	var silent8 int = p.silent
+	if entry, ok := p.cache.get(RuleActionExpression, index35, silent8 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index36 int = p.offset
@@ -1238,7 +1299,7 @@ func (p *PegGoParser) _read_action_expression() TreeNode {
 			}
 		}
 		if len(elements24) >= 1 {
-			address54 = &BaseNode{text: p.slice(index37, p.offset), offset: index37, children: elements24}
+			address54 = &BaseNode{text: p.slice(index37, p.offset), span: p.offsets.span(index37, p.offset), children: elements24}
 		} else {
 			address54 = nil
 		}
@@ -1263,44 +1324,46 @@ func (p *PegGoParser) _read_action_expression() TreeNode {
 	if elements23 == nil {
 		address52 = nil
 	} else {
-		address52 = newNode9(p.slice(index36, p.offset), index36, elements23)
+		address52 = newNode9(p.slice(index36, p.offset), p.offsets.span(index36, p.offset), elements23)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleActionExpression, index35, address52, p.offset, silent8 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache8[index35] = cacheEntry{node: address52, offset: p.offset}
 	return address52
 }
 
 func (p *PegGoParser) _read_actionable_expression() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address57 TreeNode = nil
 	var index38 int = p.offset
-	var cache9 map[int]cacheEntry = p.cache["actionable_expression"]
-	if cache9 == nil {
-		cache9 = make(map[int]cacheEntry)
-		p.cache["actionable_expression"] = cache9
-	}
-	if entry, ok := cache9[index38]; ok {
+	var silent9 int = p.silent
+	if entry, ok := p.cache.get(RuleActionableExpression, index38, silent9 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index39 int = p.offset
 	var index40 int = p.offset
 	var elements25 []TreeNode = make([]TreeNode, 5)
 	var address58 TreeNode = nil
-	var chunk6 string = ""
-	var max6 int = p.offset + 1
-	if max6 <= len(p.input) {
-		chunk6 = string(p.input[p.offset:max6])
-	}
-	if chunk6 == "(" {
-		address58 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '(' {
+		address58 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address58 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::actionable_expression", expected: "\"(\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::actionable_expression", Expected: "\"(\""})
 		}
 	}
 	if address58 != nil {
@@ -1318,7 +1381,7 @@ func (p *PegGoParser) _read_actionable_expression() TreeNode {
 			}
 		}
 		if len(elements26) >= 0 {
-			address59 = &BaseNode{text: p.slice(index41, p.offset), offset: index41, children: elements26}
+			address59 = &BaseNode{text: p.slice(index41, p.offset), span: p.offsets.span(index41, p.offset), children: elements26}
 		} else {
 			address59 = nil
 		}
@@ -1341,29 +1404,24 @@ func (p *PegGoParser) _read_actionable_expression() TreeNode {
 					}
 				}
 				if len(elements27) >= 0 {
-					address62 = &BaseNode{text: p.slice(index42, p.offset), offset: index42, children: elements27}
+					address62 = &BaseNode{text: p.slice(index42, p.offset), span: p.offsets.span(index42, p.offset), children: elements27}
 				} else {
 					address62 = nil
 				}
 				if address62 != nil {
 					elements25[3] = address62
 					var address64 TreeNode = nil
-					var chunk7 string = ""
-					var max7 int = p.offset + 1
-					if max7 <= len(p.input) {
-						chunk7 = string(p.input[p.offset:max7])
-					}
-					if chunk7 == ")" {
-						address64 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+					if p.avail(1) && p.input[p.offset] == ')' {
+						address64 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 						p.offset = p.offset + 1
 					} else {
 						address64 = nil
-						if p.offset > p.failure.offset {
-							p.failure.offset = p.offset
-							p.failure.expected = nil
-						}
-						if p.offset == p.failure.offset {
-							p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::actionable_expression", expected: "\")\""})
+						if p.offset >= p.failure.offset && p.silent == 0 {
+							if p.offset > p.failure.offset {
+								p.failure.offset = p.offset
+								p.failure.expected = p.failure.expected[:0]
+							}
+							p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::actionable_expression", Expected: "\")\""})
 						}
 					}
 					if address64 != nil {
@@ -1391,7 +1449,7 @@ func (p *PegGoParser) _read_actionable_expression() TreeNode {
 	if elements25 == nil {
 		address57 = nil
 	} else {
-		address57 = newNode10(p.slice(index40, p.offset), index40, elements25)
+		address57 = newNode10(p.slice(index40, p.offset), p.offsets.span(index40, p.offset), elements25)
 	}
 	if address57 == nil {
 		p.offset = index39
@@ -1408,41 +1466,43 @@ func (p *PegGoParser) _read_actionable_expression() TreeNode {
 			}
 		}
 	}
-	cache9[index38] = cacheEntry{node: address57, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleActionableExpression, index38, address57, p.offset, silent9 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address57
 }
 
 func (p *PegGoParser) _read_action_tag() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address65 TreeNode = nil
 	var index43 int = p.offset
-	var cache10 map[int]cacheEntry = p.cache["action_tag"]
-	if cache10 == nil {
-		cache10 = make(map[int]cacheEntry)
-		p.cache["action_tag"] = cache10
-	}
-	if entry, ok := cache10[index43]; ok {
+	var silent10 int = p.silent
+	if entry, ok := p.cache.get(RuleActionTag, index43, silent10 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index44 int = p.offset
 	var elements28 []TreeNode = make([]TreeNode, 2)
 	var address66 TreeNode = nil
-	var chunk8 string = ""
-	var max8 int = p.offset + 1
-	if max8 <= len(p.input) {
-		chunk8 = string(p.input[p.offset:max8])
-	}
-	if chunk8 == "%" {
-		address66 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '%' {
+		address66 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address66 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::action_tag", expected: "\"%\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::action_tag", Expected: "\"%\""})
 		}
 	}
 	if address66 != nil {
@@ -1462,43 +1522,45 @@ func (p *PegGoParser) _read_action_tag() TreeNode {
 	if elements28 == nil {
 		address65 = nil
 	} else {
-		address65 = newNode11(p.slice(index44, p.offset), index44, elements28)
+		address65 = newNode11(p.slice(index44, p.offset), p.offsets.span(index44, p.offset), elements28)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleActionTag, index43, address65, p.offset, silent10 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache10[index43] = cacheEntry{node: address65, offset: p.offset}
 	return address65
 }
 
 func (p *PegGoParser) _read_type_tag() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address68 TreeNode = nil
 	var index45 int = p.offset
-	var cache11 map[int]cacheEntry = p.cache["type_tag"]
-	if cache11 == nil {
-		cache11 = make(map[int]cacheEntry)
-		p.cache["type_tag"] = cache11
-	}
-	if entry, ok := cache11[index45]; ok {
+	var silent11 int = p.silent
+	if entry, ok := p.cache.get(RuleTypeTag, index45, silent11 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index46 int = p.offset
 	var elements29 []TreeNode = make([]TreeNode, 3)
 	var address69 TreeNode = nil
-	var chunk9 string = ""
-	var max9 int = p.offset + 1
-	if max9 <= len(p.input) {
-		chunk9 = string(p.input[p.offset:max9])
-	}
-	if chunk9 == "<" {
-		address69 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '<' {
+		address69 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address69 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::type_tag", expected: "\"<\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::type_tag", Expected: "\"<\""})
 		}
 	}
 	if address69 != nil {
@@ -1508,22 +1570,17 @@ func (p *PegGoParser) _read_type_tag() TreeNode {
 		if address70 != nil {
 			elements29[1] = address70
 			var address71 TreeNode = nil
-			var chunk10 string = ""
-			var max10 int = p.offset + 1
-			if max10 <= len(p.input) {
-				chunk10 = string(p.input[p.offset:max10])
-			}
-			if chunk10 == ">" {
-				address71 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '>' {
+				address71 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address71 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::type_tag", expected: "\">\""})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::type_tag", Expected: "\">\""})
 				}
 			}
 			if address71 != nil {
@@ -1543,22 +1600,29 @@ func (p *PegGoParser) _read_type_tag() TreeNode {
 	if elements29 == nil {
 		address68 = nil
 	} else {
-		address68 = newNode12(p.slice(index46, p.offset), index46, elements29)
+		address68 = newNode12(p.slice(index46, p.offset), p.offsets.span(index46, p.offset), elements29)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleTypeTag, index45, address68, p.offset, silent11 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache11[index45] = cacheEntry{node: address68, offset: p.offset}
 	return address68
 }
 
 func (p *PegGoParser) _read_sequence_expression() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address72 TreeNode = nil
 	var index47 int = p.offset
-	var cache12 map[int]cacheEntry = p.cache["sequence_expression"]
-	if cache12 == nil {
-		cache12 = make(map[int]cacheEntry)
-		p.cache["sequence_expression"] = cache12
-	}
-	if entry, ok := cache12[index47]; ok {
+	var silent12 int = p.silent
+	if entry, ok := p.cache.get(RuleSequenceExpression, index47, silent12 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index48 int = p.offset
@@ -1587,7 +1651,7 @@ func (p *PegGoParser) _read_sequence_expression() TreeNode {
 				}
 			}
 			if len(elements33) >= 1 {
-				address76 = &BaseNode{text: p.slice(index51, p.offset), offset: index51, children: elements33}
+				address76 = &BaseNode{text: p.slice(index51, p.offset), span: p.offsets.span(index51, p.offset), children: elements33}
 			} else {
 				address76 = nil
 			}
@@ -1608,7 +1672,7 @@ func (p *PegGoParser) _read_sequence_expression() TreeNode {
 			if elements32 == nil {
 				address75 = nil
 			} else {
-				address75 = newNode14(p.slice(index50, p.offset), index50, elements32)
+				address75 = newNode14(p.slice(index50, p.offset), p.offsets.span(index50, p.offset), elements32)
 			}
 			if address75 != nil {
 				elements31 = append(elements31, address75)
@@ -1617,7 +1681,7 @@ func (p *PegGoParser) _read_sequence_expression() TreeNode {
 			}
 		}
 		if len(elements31) >= 1 {
-			address74 = &BaseNode{text: p.slice(index49, p.offset), offset: index49, children: elements31}
+			address74 = &BaseNode{text: p.slice(index49, p.offset), span: p.offsets.span(index49, p.offset), children: elements31}
 		} else {
 			address74 = nil
 		}
@@ -1634,22 +1698,29 @@ func (p *PegGoParser) _read_sequence_expression() TreeNode {
 	if elements30 == nil {
 		address72 = nil
 	} else {
-		address72 = newNode13(p.slice(index48, p.offset), index48, elements30)
+		address72 = newNode13(p.slice(index48, p.offset), p.offsets.span(index48, p.offset), elements30)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleSequenceExpression, index47, address72, p.offset, silent12 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache12[index47] = cacheEntry{node: address72, offset: p.offset}
 	return address72
 }
 
 func (p *PegGoParser) _read_sequence_part() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address79 TreeNode = nil
 	var index52 int = p.offset
-	var cache13 map[int]cacheEntry = p.cache["sequence_part"]
-	if cache13 == nil {
-		cache13 = make(map[int]cacheEntry)
-		p.cache["sequence_part"] = cache13
-	}
-	if entry, ok := cache13[index52]; ok {
+	var silent13 int = p.silent
+	if entry, ok := p.cache.get(RuleSequencePart, index52, silent13 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index53 int = p.offset
@@ -1658,7 +1729,7 @@ func (p *PegGoParser) _read_sequence_part() TreeNode {
 	var index54 int = p.offset
 	address80 = p._read_label()
 	if address80 == nil {
-		address80 = &BaseNode{text: p.slice(index54, index54), offset: index54, children: nil}
+		address80 = &BaseNode{text: p.slice(index54, index54), span: p.offsets.span(index54, index54), children: nil}
 		p.offset = index54
 	}
 	if address80 != nil {
@@ -1690,22 +1761,29 @@ func (p *PegGoParser) _read_sequence_part() TreeNode {
 	if elements34 == nil {
 		address79 = nil
 	} else {
-		address79 = newNode15(p.slice(index53, p.offset), index53, elements34)
+		address79 = newNode15(p.slice(index53, p.offset), p.offsets.span(index53, p.offset), elements34)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleSequencePart, index52, address79, p.offset, silent13 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache13[index52] = cacheEntry{node: address79, offset: p.offset}
 	return address79
 }
 
 func (p *PegGoParser) _read_maybe_atom() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address82 TreeNode = nil
 	var index56 int = p.offset
-	var cache14 map[int]cacheEntry = p.cache["maybe_atom"]
-	if cache14 == nil {
-		cache14 = make(map[int]cacheEntry)
-		p.cache["maybe_atom"] = cache14
-	}
-	if entry, ok := cache14[index56]; ok {
+	var silent14 int = p.silent
+	if entry, ok := p.cache.get(RuleMaybeAtom, index56, silent14 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index57 int = p.offset
@@ -1715,22 +1793,17 @@ func (p *PegGoParser) _read_maybe_atom() TreeNode {
 	if address83 != nil {
 		elements35[0] = address83
 		var address84 TreeNode = nil
-		var chunk11 string = ""
-		var max11 int = p.offset + 1
-		if max11 <= len(p.input) {
-			chunk11 = string(p.input[p.offset:max11])
-		}
-		if chunk11 == "?" {
-			address84 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+		if p.avail(1) && p.input[p.offset] == '?' {
+			address84 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 			p.offset = p.offset + 1
 		} else {
 			address84 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::maybe_atom", expected: "\"?\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::maybe_atom", Expected: "\"?\""})
 			}
 		}
 		if address84 != nil {
@@ -1746,22 +1819,29 @@ func (p *PegGoParser) _read_maybe_atom() TreeNode {
 	if elements35 == nil {
 		address82 = nil
 	} else {
-		address82 = newNode16(p.slice(index57, p.offset), index57, elements35)
+		address82 = newNode16(p.slice(index57, p.offset), p.offsets.span(index57, p.offset), elements35)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleMaybeAtom, index56, address82, p.offset, silent14 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache14[index56] = cacheEntry{node: address82, offset: p.offset}
 	return address82
 }
 
 func (p *PegGoParser) _read_repeated_atom() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address85 TreeNode = nil
 	var index58 int = p.offset
-	var cache15 map[int]cacheEntry = p.cache["repeated_atom"]
-	if cache15 == nil {
-		cache15 = make(map[int]cacheEntry)
-		p.cache["repeated_atom"] = cache15
-	}
-	if entry, ok := cache15[index58]; ok {
+	var silent15 int = p.silent
+	if entry, ok := p.cache.get(RuleRepeatedAtom, index58, silent15 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index59 int = p.offset
@@ -1772,42 +1852,32 @@ func (p *PegGoParser) _read_repeated_atom() TreeNode {
 		elements36[0] = address86
 		var address87 TreeNode = nil
 		var index60 int = p.offset
-		var chunk12 string = ""
-		var max12 int = p.offset + 1
-		if max12 <= len(p.input) {
-			chunk12 = string(p.input[p.offset:max12])
-		}
-		if chunk12 == "*" {
-			address87 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+		if p.avail(1) && p.input[p.offset] == '*' {
+			address87 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 			p.offset = p.offset + 1
 		} else {
 			address87 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::repeated_atom", expected: "\"*\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::repeated_atom", Expected: "\"*\""})
 			}
 		}
 		if address87 == nil {
 			p.offset = index60
-			var chunk13 string = ""
-			var max13 int = p.offset + 1
-			if max13 <= len(p.input) {
-				chunk13 = string(p.input[p.offset:max13])
-			}
-			if chunk13 == "+" {
-				address87 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '+' {
+				address87 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address87 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::repeated_atom", expected: "\"+\""})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::repeated_atom", Expected: "\"+\""})
 				}
 			}
 			if address87 == nil {
@@ -1827,22 +1897,29 @@ func (p *PegGoParser) _read_repeated_atom() TreeNode {
 	if elements36 == nil {
 		address85 = nil
 	} else {
-		address85 = newNode17(p.slice(index59, p.offset), index59, elements36)
+		address85 = newNode17(p.slice(index59, p.offset), p.offsets.span(index59, p.offset), elements36)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleRepeatedAtom, index58, address85, p.offset, silent15 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache15[index58] = cacheEntry{node: address85, offset: p.offset}
 	return address85
 }
 
 func (p *PegGoParser) _read_atom() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address88 TreeNode = nil
 	var index61 int = p.offset
-	var cache16 map[int]cacheEntry = p.cache["atom"]
-	if cache16 == nil {
-		cache16 = make(map[int]cacheEntry)
-		p.cache["atom"] = cache16
-	}
-	if entry, ok := cache16[index61]; ok {
+	var silent16 int = p.silent
+	if entry, ok := p.cache.get(RuleAtom, index61, silent16 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index62 int = p.offset
@@ -1862,20 +1939,27 @@ func (p *PegGoParser) _read_atom() TreeNode {
 			}
 		}
 	}
-	cache16[index61] = cacheEntry{node: address88, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleAtom, index61, address88, p.offset, silent16 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address88
 }
 
 func (p *PegGoParser) _read_terminal_node() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address89 TreeNode = nil
 	var index63 int = p.offset
-	var cache17 map[int]cacheEntry = p.cache["terminal_node"]
-	if cache17 == nil {
-		cache17 = make(map[int]cacheEntry)
-		p.cache["terminal_node"] = cache17
-	}
-	if entry, ok := cache17[index63]; ok {
+	var silent17 int = p.silent
+	if entry, ok := p.cache.get(RuleTerminalNode, index63, silent17 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index64 int = p.offset
@@ -1895,62 +1979,59 @@ func (p *PegGoParser) _read_terminal_node() TreeNode {
 			}
 		}
 	}
-	cache17[index63] = cacheEntry{node: address89, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleTerminalNode, index63, address89, p.offset, silent17 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address89
 }
 
 func (p *PegGoParser) _read_predicated_atom() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address90 TreeNode = nil
 	var index65 int = p.offset
-	var cache18 map[int]cacheEntry = p.cache["predicated_atom"]
-	if cache18 == nil {
-		cache18 = make(map[int]cacheEntry)
-		p.cache["predicated_atom"] = cache18
-	}
-	if entry, ok := cache18[index65]; ok {
+	var silent18 int = p.silent
+	if entry, ok := p.cache.get(RulePredicatedAtom, index65, silent18 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index66 int = p.offset
 	var elements37 []TreeNode = make([]TreeNode, 2)
 	var address91 TreeNode = nil
 	var index67 int = p.offset
-	var chunk14 string = ""
-	var max14 int = p.offset + 1
-	if max14 <= len(p.input) {
-		chunk14 = string(p.input[p.offset:max14])
-	}
-	if chunk14 == "&" {
-		address91 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '&' {
+		address91 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address91 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::predicated_atom", expected: "\"&\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::predicated_atom", Expected: "\"&\""})
 		}
 	}
 	if address91 == nil {
 		p.offset = index67
-		var chunk15 string = ""
-		var max15 int = p.offset + 1
-		if max15 <= len(p.input) {
-			chunk15 = string(p.input[p.offset:max15])
-		}
-		if chunk15 == "!" {
-			address91 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+		if p.avail(1) && p.input[p.offset] == '!' {
+			address91 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 			p.offset = p.offset + 1
 		} else {
 			address91 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::predicated_atom", expected: "\"!\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::predicated_atom", Expected: "\"!\""})
 			}
 		}
 		if address91 == nil {
@@ -1974,22 +2055,29 @@ func (p *PegGoParser) _read_predicated_atom() TreeNode {
 	if elements37 == nil {
 		address90 = nil
 	} else {
-		address90 = newNode18(p.slice(index66, p.offset), index66, elements37)
+		address90 = newNode18(p.slice(index66, p.offset), p.offsets.span(index66, p.offset), elements37)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RulePredicatedAtom, index65, address90, p.offset, silent18 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache18[index65] = cacheEntry{node: address90, offset: p.offset}
 	return address90
 }
 
 func (p *PegGoParser) _read_reference_expression() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address93 TreeNode = nil
 	var index68 int = p.offset
-	var cache19 map[int]cacheEntry = p.cache["reference_expression"]
-	if cache19 == nil {
-		cache19 = make(map[int]cacheEntry)
-		p.cache["reference_expression"] = cache19
-	}
-	if entry, ok := cache19[index68]; ok {
+	var silent19 int = p.silent
+	if entry, ok := p.cache.get(RuleReferenceExpression, index68, silent19 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index69 int = p.offset
@@ -2000,10 +2088,18 @@ func (p *PegGoParser) _read_reference_expression() TreeNode {
 		elements38[0] = address94
 		var address95 TreeNode = nil
 		var index70 int = p.offset
+		var silent20 int = p.silent
+		p.silent++
+		p.lookahead++
 		address95 = p._read_assignment()
+		p.lookahead--
+		p.silent = silent20
+		if address95 != nil {
+			p.expect(index70, Expectation{Rule: "Canopy.PEG::reference_expression", Expected: "assignment", Unexpected: true})
+		}
 		p.offset = index70
 		if address95 == nil {
-			address95 = &BaseNode{text: p.slice(p.offset, p.offset), offset: p.offset, children: nil}
+			address95 = &BaseNode{text: p.slice(p.offset, p.offset), span: p.offsets.span(p.offset, p.offset), children: nil}
 		} else {
 			address95 = nil
 		}
@@ -2020,44 +2116,46 @@ func (p *PegGoParser) _read_reference_expression() TreeNode {
 	if elements38 == nil {
 		address93 = nil
 	} else {
-		address93 = newNode19(p.slice(index69, p.offset), index69, elements38)
+		address93 = newNode19(p.slice(index69, p.offset), p.offsets.span(index69, p.offset), elements38)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleReferenceExpression, index68, address93, p.offset, silent19 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache19[index68] = cacheEntry{node: address93, offset: p.offset}
 	return address93
 }
 
 func (p *PegGoParser) _read_string_expression() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address96 TreeNode = nil
 	var index71 int = p.offset
-	var cache20 map[int]cacheEntry = p.cache["string_expression"]
-	if cache20 == nil {
-		cache20 = make(map[int]cacheEntry)
-		p.cache["string_expression"] = cache20
-	}
-	if entry, ok := cache20[index71]; ok {
+	var silent21 int = p.silent
+	if entry, ok := p.cache.get(RuleStringExpression, index71, silent21 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index72 int = p.offset
 	var index73 int = p.offset
 	var elements39 []TreeNode = make([]TreeNode, 3)
 	var address97 TreeNode = nil
-	var chunk16 string = ""
-	var max16 int = p.offset + 1
-	if max16 <= len(p.input) {
-		chunk16 = string(p.input[p.offset:max16])
-	}
-	if chunk16 == "\"" {
-		address97 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '"' {
+		address97 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address97 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::string_expression", expected: "'\"'"})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::string_expression", Expected: "'\"'"})
 		}
 	}
 	if address97 != nil {
@@ -2071,38 +2169,33 @@ func (p *PegGoParser) _read_string_expression() TreeNode {
 			var index76 int = p.offset
 			var elements41 []TreeNode = make([]TreeNode, 2)
 			var address100 TreeNode = nil
-			var chunk17 string = ""
-			var max17 int = p.offset + 1
-			if max17 <= len(p.input) {
-				chunk17 = string(p.input[p.offset:max17])
-			}
-			if chunk17 == "\\" {
-				address100 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '\\' {
+				address100 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address100 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::string_expression", expected: "\"\\\\\""})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::string_expression", Expected: "\"\\\\\""})
 				}
 			}
 			if address100 != nil {
 				elements41[0] = address100
 				var address101 TreeNode = nil
-				if p.offset < len(p.input) {
-					address101 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-					p.offset = p.offset + 1
+				if p.avail(1) {
+					address101 = &BaseNode{text: p.slice(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), span: p.offsets.span(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), children: nil}
+					p.offset = p.offset + runeWidth(p.peekRune(), p.offset)
 				} else {
 					address101 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::string_expression", expected: "<any char>"})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::string_expression", Expected: "<any char>"})
 					}
 				}
 				if address101 != nil {
@@ -2118,26 +2211,22 @@ func (p *PegGoParser) _read_string_expression() TreeNode {
 			if elements41 == nil {
 				address99 = nil
 			} else {
-				address99 = &BaseNode{text: p.slice(index76, p.offset), offset: index76, children: elements41}
+				address99 = &BaseNode{text: p.slice(index76, p.offset), span: p.offsets.span(index76, p.offset), children: elements41}
 			}
 			if address99 == nil {
 				p.offset = index75
-				var chunk18 string = ""
-				var max18 int = p.offset + 1
-				if max18 <= len(p.input) {
-					chunk18 = string(p.input[p.offset:max18])
-				}
-				if REGEX_1.MatchString(chunk18) {
-					address99 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-					p.offset = p.offset + 1
+				var end1 int = charClass1.match(p.peekRune(), p.offset)
+				if end1 >= 0 {
+					address99 = &BaseNode{text: p.slice(p.offset, end1), span: p.offsets.span(p.offset, end1), children: nil}
+					p.offset = end1
 				} else {
 					address99 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::string_expression", expected: "[^\"]"})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::string_expression", Expected: "[^\"]"})
 					}
 				}
 				if address99 == nil {
@@ -2151,29 +2240,24 @@ func (p *PegGoParser) _read_string_expression() TreeNode {
 			}
 		}
 		if len(elements40) >= 0 {
-			address98 = &BaseNode{text: p.slice(index74, p.offset), offset: index74, children: elements40}
+			address98 = &BaseNode{text: p.slice(index74, p.offset), span: p.offsets.span(index74, p.offset), children: elements40}
 		} else {
 			address98 = nil
 		}
 		if address98 != nil {
 			elements39[1] = address98
 			var address102 TreeNode = nil
-			var chunk19 string = ""
-			var max19 int = p.offset + 1
-			if max19 <= len(p.input) {
-				chunk19 = string(p.input[p.offset:max19])
-			}
-			if chunk19 == "\"" {
-				address102 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '"' {
+				address102 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address102 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::string_expression", expected: "'\"'"})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::string_expression", Expected: "'\"'"})
 				}
 			}
 			if address102 != nil {
@@ -2193,29 +2277,24 @@ func (p *PegGoParser) _read_string_expression() TreeNode {
 	if elements39 == nil {
 		address96 = nil
 	} else {
-		address96 = &BaseNode{text: p.slice(index73, p.offset), offset: index73, children: elements39}
+		address96 = &BaseNode{text: p.slice(index73, p.offset), span: p.offsets.span(index73, p.offset), children: elements39}
 	}
 	if address96 == nil {
 		p.offset = index72
 		var index77 int = p.offset
 		var elements42 []TreeNode = make([]TreeNode, 3)
 		var address103 TreeNode = nil
-		var chunk20 string = ""
-		var max20 int = p.offset + 1
-		if max20 <= len(p.input) {
-			chunk20 = string(p.input[p.offset:max20])
-		}
-		if chunk20 == "'" {
-			address103 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+		if p.avail(1) && p.input[p.offset] == '\'' {
+			address103 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 			p.offset = p.offset + 1
 		} else {
 			address103 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::string_expression", expected: "\"'\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::string_expression", Expected: "\"'\""})
 			}
 		}
 		if address103 != nil {
@@ -2229,38 +2308,33 @@ func (p *PegGoParser) _read_string_expression() TreeNode {
 				var index80 int = p.offset
 				var elements44 []TreeNode = make([]TreeNode, 2)
 				var address106 TreeNode = nil
-				var chunk21 string = ""
-				var max21 int = p.offset + 1
-				if max21 <= len(p.input) {
-					chunk21 = string(p.input[p.offset:max21])
-				}
-				if chunk21 == "\\" {
-					address106 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == '\\' {
+					address106 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address106 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::string_expression", expected: "\"\\\\\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::string_expression", Expected: "\"\\\\\""})
 					}
 				}
 				if address106 != nil {
 					elements44[0] = address106
 					var address107 TreeNode = nil
-					if p.offset < len(p.input) {
-						address107 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-						p.offset = p.offset + 1
+					if p.avail(1) {
+						address107 = &BaseNode{text: p.slice(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), span: p.offsets.span(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), children: nil}
+						p.offset = p.offset + runeWidth(p.peekRune(), p.offset)
 					} else {
 						address107 = nil
-						if p.offset > p.failure.offset {
-							p.failure.offset = p.offset
-							p.failure.expected = nil
-						}
-						if p.offset == p.failure.offset {
-							p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::string_expression", expected: "<any char>"})
+						if p.offset >= p.failure.offset && p.silent == 0 {
+							if p.offset > p.failure.offset {
+								p.failure.offset = p.offset
+								p.failure.expected = p.failure.expected[:0]
+							}
+							p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::string_expression", Expected: "<any char>"})
 						}
 					}
 					if address107 != nil {
@@ -2276,26 +2350,22 @@ func (p *PegGoParser) _read_string_expression() TreeNode {
 				if elements44 == nil {
 					address105 = nil
 				} else {
-					address105 = &BaseNode{text: p.slice(index80, p.offset), offset: index80, children: elements44}
+					address105 = &BaseNode{text: p.slice(index80, p.offset), span: p.offsets.span(index80, p.offset), children: elements44}
 				}
 				if address105 == nil {
 					p.offset = index79
-					var chunk22 string = ""
-					var max22 int = p.offset + 1
-					if max22 <= len(p.input) {
-						chunk22 = string(p.input[p.offset:max22])
-					}
-					if REGEX_2.MatchString(chunk22) {
-						address105 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-						p.offset = p.offset + 1
+					var end2 int = charClass2.match(p.peekRune(), p.offset)
+					if end2 >= 0 {
+						address105 = &BaseNode{text: p.slice(p.offset, end2), span: p.offsets.span(p.offset, end2), children: nil}
+						p.offset = end2
 					} else {
 						address105 = nil
-						if p.offset > p.failure.offset {
-							p.failure.offset = p.offset
-							p.failure.expected = nil
-						}
-						if p.offset == p.failure.offset {
-							p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::string_expression", expected: "[^']"})
+						if p.offset >= p.failure.offset && p.silent == 0 {
+							if p.offset > p.failure.offset {
+								p.failure.offset = p.offset
+								p.failure.expected = p.failure.expected[:0]
+							}
+							p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::string_expression", Expected: "[^']"})
 						}
 					}
 					if address105 == nil {
@@ -2309,29 +2379,24 @@ func (p *PegGoParser) _read_string_expression() TreeNode {
 				}
 			}
 			if len(elements43) >= 0 {
-				address104 = &BaseNode{text: p.slice(index78, p.offset), offset: index78, children: elements43}
+				address104 = &BaseNode{text: p.slice(index78, p.offset), span: p.offsets.span(index78, p.offset), children: elements43}
 			} else {
 				address104 = nil
 			}
 			if address104 != nil {
 				elements42[1] = address104
 				var address108 TreeNode = nil
-				var chunk23 string = ""
-				var max23 int = p.offset + 1
-				if max23 <= len(p.input) {
-					chunk23 = string(p.input[p.offset:max23])
-				}
-				if chunk23 == "'" {
-					address108 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == '\'' {
+					address108 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address108 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::string_expression", expected: "\"'\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::string_expression", Expected: "\"'\""})
 					}
 				}
 				if address108 != nil {
@@ -2351,47 +2416,49 @@ func (p *PegGoParser) _read_string_expression() TreeNode {
 		if elements42 == nil {
 			address96 = nil
 		} else {
-			address96 = &BaseNode{text: p.slice(index77, p.offset), offset: index77, children: elements42}
+			address96 = &BaseNode{text: p.slice(index77, p.offset), span: p.offsets.span(index77, p.offset), children: elements42}
 		}
 		if address96 == nil {
 			p.offset = index72
 		}
 	}
-	cache20[index71] = cacheEntry{node: address96, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleStringExpression, index71, address96, p.offset, silent21 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address96
 }
 
 func (p *PegGoParser) _read_ci_string_expression() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address109 TreeNode = nil
 	var index81 int = p.offset
-	var cache21 map[int]cacheEntry = p.cache["ci_string_expression"]
-	if cache21 == nil {
-		cache21 = make(map[int]cacheEntry)
-		p.cache["ci_string_expression"] = cache21
-	}
-	if entry, ok := cache21[index81]; ok {
+	var silent22 int = p.silent
+	if entry, ok := p.cache.get(RuleCiStringExpression, index81, silent22 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index82 int = p.offset
 	var elements45 []TreeNode = make([]TreeNode, 3)
 	var address110 TreeNode = nil
-	var chunk24 string = ""
-	var max24 int = p.offset + 1
-	if max24 <= len(p.input) {
-		chunk24 = string(p.input[p.offset:max24])
-	}
-	if chunk24 == "`" {
-		address110 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '`' {
+		address110 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address110 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::ci_string_expression", expected: "\"`\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::ci_string_expression", Expected: "\"`\""})
 		}
 	}
 	if address110 != nil {
@@ -2405,38 +2472,33 @@ func (p *PegGoParser) _read_ci_string_expression() TreeNode {
 			var index85 int = p.offset
 			var elements47 []TreeNode = make([]TreeNode, 2)
 			var address113 TreeNode = nil
-			var chunk25 string = ""
-			var max25 int = p.offset + 1
-			if max25 <= len(p.input) {
-				chunk25 = string(p.input[p.offset:max25])
-			}
-			if chunk25 == "\\" {
-				address113 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '\\' {
+				address113 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address113 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::ci_string_expression", expected: "\"\\\\\""})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::ci_string_expression", Expected: "\"\\\\\""})
 				}
 			}
 			if address113 != nil {
 				elements47[0] = address113
 				var address114 TreeNode = nil
-				if p.offset < len(p.input) {
-					address114 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-					p.offset = p.offset + 1
+				if p.avail(1) {
+					address114 = &BaseNode{text: p.slice(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), span: p.offsets.span(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), children: nil}
+					p.offset = p.offset + runeWidth(p.peekRune(), p.offset)
 				} else {
 					address114 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::ci_string_expression", expected: "<any char>"})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::ci_string_expression", Expected: "<any char>"})
 					}
 				}
 				if address114 != nil {
@@ -2452,26 +2514,22 @@ func (p *PegGoParser) _read_ci_string_expression() TreeNode {
 			if elements47 == nil {
 				address112 = nil
 			} else {
-				address112 = &BaseNode{text: p.slice(index85, p.offset), offset: index85, children: elements47}
+				address112 = &BaseNode{text: p.slice(index85, p.offset), span: p.offsets.span(index85, p.offset), children: elements47}
 			}
 			if address112 == nil {
 				p.offset = index84
-				var chunk26 string = ""
-				var max26 int = p.offset + 1
-				if max26 <= len(p.input) {
-					chunk26 = string(p.input[p.offset:max26])
-				}
-				if REGEX_3.MatchString(chunk26) {
-					address112 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-					p.offset = p.offset + 1
+				var end3 int = charClass3.match(p.peekRune(), p.offset)
+				if end3 >= 0 {
+					address112 = &BaseNode{text: p.slice(p.offset, end3), span: p.offsets.span(p.offset, end3), children: nil}
+					p.offset = end3
 				} else {
 					address112 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::ci_string_expression", expected: "[^`]"})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::ci_string_expression", Expected: "[^`]"})
 					}
 				}
 				if address112 == nil {
@@ -2485,29 +2543,24 @@ func (p *PegGoParser) _read_ci_string_expression() TreeNode {
 			}
 		}
 		if len(elements46) >= 0 {
-			address111 = &BaseNode{text: p.slice(index83, p.offset), offset: index83, children: elements46}
+			address111 = &BaseNode{text: p.slice(index83, p.offset), span: p.offsets.span(index83, p.offset), children: elements46}
 		} else {
 			address111 = nil
 		}
 		if address111 != nil {
 			elements45[1] = address111
 			var address115 TreeNode = nil
-			var chunk27 string = ""
-			var max27 int = p.offset + 1
-			if max27 <= len(p.input) {
-				chunk27 = string(p.input[p.offset:max27])
-			}
-			if chunk27 == "`" {
-				address115 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '`' {
+				address115 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address115 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::ci_string_expression", expected: "\"`\""})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::ci_string_expression", Expected: "\"`\""})
 				}
 			}
 			if address115 != nil {
@@ -2527,103 +2580,102 @@ func (p *PegGoParser) _read_ci_string_expression() TreeNode {
 	if elements45 == nil {
 		address109 = nil
 	} else {
-		address109 = &BaseNode{text: p.slice(index82, p.offset), offset: index82, children: elements45}
+		address109 = &BaseNode{text: p.slice(index82, p.offset), span: p.offsets.span(index82, p.offset), children: elements45}
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleCiStringExpression, index81, address109, p.offset, silent22 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache21[index81] = cacheEntry{node: address109, offset: p.offset}
 	return address109
 }
 
 func (p *PegGoParser) _read_any_char_expression() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address116 TreeNode = nil
 	var index86 int = p.offset
-	var cache22 map[int]cacheEntry = p.cache["any_char_expression"]
-	if cache22 == nil {
-		cache22 = make(map[int]cacheEntry)
-		p.cache["any_char_expression"] = cache22
-	}
-	if entry, ok := cache22[index86]; ok {
+	var silent23 int = p.silent
+	if entry, ok := p.cache.get(RuleAnyCharExpression, index86, silent23 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
-	var chunk28 string = ""
-	var max28 int = p.offset + 1
-	if max28 <= len(p.input) {
-		chunk28 = string(p.input[p.offset:max28])
-	}
-	if chunk28 == "." {
-		address116 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '.' {
+		address116 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address116 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::any_char_expression", expected: "\".\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::any_char_expression", Expected: "\".\""})
 		}
 	}
-	cache22[index86] = cacheEntry{node: address116, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(RuleAnyCharExpression, index86, address116, p.offset, silent23 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address116
 }
 
 func (p *PegGoParser) _read_char_class_expression() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address117 TreeNode = nil
 	var index87 int = p.offset
-	var cache23 map[int]cacheEntry = p.cache["char_class_expression"]
-	if cache23 == nil {
-		cache23 = make(map[int]cacheEntry)
-		p.cache["char_class_expression"] = cache23
-	}
-	if entry, ok := cache23[index87]; ok {
+	var silent24 int = p.silent
+	if entry, ok := p.cache.get(RuleCharClassExpression, index87, silent24 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index88 int = p.offset
 	var elements48 []TreeNode = make([]TreeNode, 4)
 	var address118 TreeNode = nil
-	var chunk29 string = ""
-	var max29 int = p.offset + 1
-	if max29 <= len(p.input) {
-		chunk29 = string(p.input[p.offset:max29])
-	}
-	if chunk29 == "[" {
-		address118 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '[' {
+		address118 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address118 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::char_class_expression", expected: "\"[\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::char_class_expression", Expected: "\"[\""})
 		}
 	}
 	if address118 != nil {
 		elements48[0] = address118
 		var address119 TreeNode = nil
 		var index89 int = p.offset
-		var chunk30 string = ""
-		var max30 int = p.offset + 1
-		if max30 <= len(p.input) {
-			chunk30 = string(p.input[p.offset:max30])
-		}
-		if chunk30 == "^" {
-			address119 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+		if p.avail(1) && p.input[p.offset] == '^' {
+			address119 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 			p.offset = p.offset + 1
 		} else {
 			address119 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::char_class_expression", expected: "\"^\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::char_class_expression", Expected: "\"^\""})
 			}
 		}
 		if address119 == nil {
-			address119 = &BaseNode{text: p.slice(index89, index89), offset: index89, children: nil}
+			address119 = &BaseNode{text: p.slice(index89, index89), span: p.offsets.span(index89, index89), children: nil}
 			p.offset = index89
 		}
 		if address119 != nil {
@@ -2637,38 +2689,33 @@ func (p *PegGoParser) _read_char_class_expression() TreeNode {
 				var index92 int = p.offset
 				var elements50 []TreeNode = make([]TreeNode, 2)
 				var address122 TreeNode = nil
-				var chunk31 string = ""
-				var max31 int = p.offset + 1
-				if max31 <= len(p.input) {
-					chunk31 = string(p.input[p.offset:max31])
-				}
-				if chunk31 == "\\" {
-					address122 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == '\\' {
+					address122 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address122 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::char_class_expression", expected: "\"\\\\\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::char_class_expression", Expected: "\"\\\\\""})
 					}
 				}
 				if address122 != nil {
 					elements50[0] = address122
 					var address123 TreeNode = nil
-					if p.offset < len(p.input) {
-						address123 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-						p.offset = p.offset + 1
+					if p.avail(1) {
+						address123 = &BaseNode{text: p.slice(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), span: p.offsets.span(p.offset, p.offset + runeWidth(p.peekRune(), p.offset)), children: nil}
+						p.offset = p.offset + runeWidth(p.peekRune(), p.offset)
 					} else {
 						address123 = nil
-						if p.offset > p.failure.offset {
-							p.failure.offset = p.offset
-							p.failure.expected = nil
-						}
-						if p.offset == p.failure.offset {
-							p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::char_class_expression", expected: "<any char>"})
+						if p.offset >= p.failure.offset && p.silent == 0 {
+							if p.offset > p.failure.offset {
+								p.failure.offset = p.offset
+								p.failure.expected = p.failure.expected[:0]
+							}
+							p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::char_class_expression", Expected: "<any char>"})
 						}
 					}
 					if address123 != nil {
@@ -2684,26 +2731,22 @@ func (p *PegGoParser) _read_char_class_expression() TreeNode {
 				if elements50 == nil {
 					address121 = nil
 				} else {
-					address121 = &BaseNode{text: p.slice(index92, p.offset), offset: index92, children: elements50}
+					address121 = &BaseNode{text: p.slice(index92, p.offset), span: p.offsets.span(index92, p.offset), children: elements50}
 				}
 				if address121 == nil {
 					p.offset = index91
-					var chunk32 string = ""
-					var max32 int = p.offset + 1
-					if max32 <= len(p.input) {
-						chunk32 = string(p.input[p.offset:max32])
-					}
-					if REGEX_4.MatchString(chunk32) {
-						address121 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-						p.offset = p.offset + 1
+					var end4 int = charClass4.match(p.peekRune(), p.offset)
+					if end4 >= 0 {
+						address121 = &BaseNode{text: p.slice(p.offset, end4), span: p.offsets.span(p.offset, end4), children: nil}
+						p.offset = end4
 					} else {
 						address121 = nil
-						if p.offset > p.failure.offset {
-							p.failure.offset = p.offset
-							p.failure.expected = nil
-						}
-						if p.offset == p.failure.offset {
-							p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::char_class_expression", expected: "[^\\]]"})
+						if p.offset >= p.failure.offset && p.silent == 0 {
+							if p.offset > p.failure.offset {
+								p.failure.offset = p.offset
+								p.failure.expected = p.failure.expected[:0]
+							}
+							p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::char_class_expression", Expected: "[^\\]]"})
 						}
 					}
 					if address121 == nil {
@@ -2717,29 +2760,24 @@ func (p *PegGoParser) _read_char_class_expression() TreeNode {
 				}
 			}
 			if len(elements49) >= 1 {
-				address120 = &BaseNode{text: p.slice(index90, p.offset), offset: index90, children: elements49}
+				address120 = &BaseNode{text: p.slice(index90, p.offset), span: p.offsets.span(index90, p.offset), children: elements49}
 			} else {
 				address120 = nil
 			}
 			if address120 != nil {
 				elements48[2] = address120
 				var address124 TreeNode = nil
-				var chunk33 string = ""
-				var max33 int = p.offset + 1
-				if max33 <= len(p.input) {
-					chunk33 = string(p.input[p.offset:max33])
-				}
-				if chunk33 == "]" {
-					address124 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+				if p.avail(1) && p.input[p.offset] == ']' {
+					address124 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 					p.offset = p.offset + 1
 				} else {
 					address124 = nil
-					if p.offset > p.failure.offset {
-						p.failure.offset = p.offset
-						p.failure.expected = nil
-					}
-					if p.offset == p.failure.offset {
-						p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::char_class_expression", expected: "\"]\""})
+					if p.offset >= p.failure.offset && p.silent == 0 {
+						if p.offset > p.failure.offset {
+							p.failure.offset = p.offset
+							p.failure.expected = p.failure.expected[:0]
+						}
+						p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::char_class_expression", Expected: "\"]\""})
 					}
 				}
 				if address124 != nil {
@@ -2763,22 +2801,29 @@ func (p *PegGoParser) _read_char_class_expression() TreeNode {
 	if elements48 == nil {
 		address117 = nil
 	} else {
-		address117 = &BaseNode{text: p.slice(index88, p.offset), offset: index88, children: elements48}
+		address117 = &BaseNode{text: p.slice(index88, p.offset), span: p.offsets.span(index88, p.offset), children: elements48}
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleCharClassExpression, index87, address117, p.offset, silent24 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache23[index87] = cacheEntry{node: address117, offset: p.offset}
 	return address117
 }
 
 func (p *PegGoParser) _read_label() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address125 TreeNode = nil
 	var index93 int = p.offset
-	var cache24 map[int]cacheEntry = p.cache["label"]
-	if cache24 == nil {
-		cache24 = make(map[int]cacheEntry)
-		p.cache["label"] = cache24
-	}
-	if entry, ok := cache24[index93]; ok {
+	var silent25 int = p.silent
+	if entry, ok := p.cache.get(RuleLabel, index93, silent25 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index94 int = p.offset
@@ -2788,22 +2833,17 @@ func (p *PegGoParser) _read_label() TreeNode {
 	if address126 != nil {
 		elements51[0] = address126
 		var address127 TreeNode = nil
-		var chunk34 string = ""
-		var max34 int = p.offset + 1
-		if max34 <= len(p.input) {
-			chunk34 = string(p.input[p.offset:max34])
-		}
-		if chunk34 == ":" {
-			address127 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+		if p.avail(1) && p.input[p.offset] == ':' {
+			address127 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 			p.offset = p.offset + 1
 		} else {
 			address127 = nil
-			if p.offset > p.failure.offset {
-				p.failure.offset = p.offset
-				p.failure.expected = nil
-			}
-			if p.offset == p.failure.offset {
-				p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::label", expected: "\":\""})
+			if p.offset >= p.failure.offset && p.silent == 0 {
+				if p.offset > p.failure.offset {
+					p.failure.offset = p.offset
+					p.failure.expected = p.failure.expected[:0]
+				}
+				p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::label", Expected: "\":\""})
 			}
 		}
 		if address127 != nil {
@@ -2819,22 +2859,29 @@ func (p *PegGoParser) _read_label() TreeNode {
 	if elements51 == nil {
 		address125 = nil
 	} else {
-		address125 = newNode20(p.slice(index94, p.offset), index94, elements51)
+		address125 = newNode20(p.slice(index94, p.offset), p.offsets.span(index94, p.offset), elements51)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleLabel, index93, address125, p.offset, silent25 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache24[index93] = cacheEntry{node: address125, offset: p.offset}
 	return address125
 }
 
 func (p *PegGoParser) _read_object_identifier() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address128 TreeNode = nil
 	var index95 int = p.offset
-	var cache25 map[int]cacheEntry = p.cache["object_identifier"]
-	if cache25 == nil {
-		cache25 = make(map[int]cacheEntry)
-		p.cache["object_identifier"] = cache25
-	}
-	if entry, ok := cache25[index95]; ok {
+	var silent26 int = p.silent
+	if entry, ok := p.cache.get(RuleObjectIdentifier, index95, silent26 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index96 int = p.offset
@@ -2851,22 +2898,17 @@ func (p *PegGoParser) _read_object_identifier() TreeNode {
 			var index98 int = p.offset
 			var elements54 []TreeNode = make([]TreeNode, 2)
 			var address132 TreeNode = nil
-			var chunk35 string = ""
-			var max35 int = p.offset + 1
-			if max35 <= len(p.input) {
-				chunk35 = string(p.input[p.offset:max35])
-			}
-			if chunk35 == "." {
-				address132 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+			if p.avail(1) && p.input[p.offset] == '.' {
+				address132 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 				p.offset = p.offset + 1
 			} else {
 				address132 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::object_identifier", expected: "\".\""})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::object_identifier", Expected: "\".\""})
 				}
 			}
 			if address132 != nil {
@@ -2886,7 +2928,7 @@ func (p *PegGoParser) _read_object_identifier() TreeNode {
 			if elements54 == nil {
 				address131 = nil
 			} else {
-				address131 = newNode22(p.slice(index98, p.offset), index98, elements54)
+				address131 = newNode22(p.slice(index98, p.offset), p.offsets.span(index98, p.offset), elements54)
 			}
 			if address131 != nil {
 				elements53 = append(elements53, address131)
@@ -2895,7 +2937,7 @@ func (p *PegGoParser) _read_object_identifier() TreeNode {
 			}
 		}
 		if len(elements53) >= 0 {
-			address130 = &BaseNode{text: p.slice(index97, p.offset), offset: index97, children: elements53}
+			address130 = &BaseNode{text: p.slice(index97, p.offset), span: p.offsets.span(index97, p.offset), children: elements53}
 		} else {
 			address130 = nil
 		}
@@ -2912,43 +2954,46 @@ func (p *PegGoParser) _read_object_identifier() TreeNode {
 	if elements52 == nil {
 		address128 = nil
 	} else {
-		address128 = newNode21(p.slice(index96, p.offset), index96, elements52)
+		address128 = newNode21(p.slice(index96, p.offset), p.offsets.span(index96, p.offset), elements52)
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleObjectIdentifier, index95, address128, p.offset, silent26 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache25[index95] = cacheEntry{node: address128, offset: p.offset}
 	return address128
 }
 
 func (p *PegGoParser) _read_identifier() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address134 TreeNode = nil
 	var index99 int = p.offset
-	var cache26 map[int]cacheEntry = p.cache["identifier"]
-	if cache26 == nil {
-		cache26 = make(map[int]cacheEntry)
-		p.cache["identifier"] = cache26
-	}
-	if entry, ok := cache26[index99]; ok {
+	var silent27 int = p.silent
+	if entry, ok := p.cache.get(RuleIdentifier, index99, silent27 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index100 int = p.offset
 	var elements55 []TreeNode = make([]TreeNode, 2)
 	var address135 TreeNode = nil
-	var chunk36 string = ""
-	var max36 int = p.offset + 1
-	if max36 <= len(p.input) {
-		chunk36 = string(p.input[p.offset:max36])
-	}
-	if REGEX_5.MatchString(chunk36) {
-		address135 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-		p.offset = p.offset + 1
+	var end5 int = charClass5.match(p.peekRune(), p.offset)
+	if end5 >= 0 {
+		address135 = &BaseNode{text: p.slice(p.offset, end5), span: p.offsets.span(p.offset, end5), children: nil}
+		p.offset = end5
 	} else {
 		address135 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::identifier", expected: "[a-zA-Z_]"})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::identifier", Expected: "[a-zA-Z_]"})
 		}
 	}
 	if address135 != nil {
@@ -2958,22 +3003,18 @@ func (p *PegGoParser) _read_identifier() TreeNode {
 		var elements56 []TreeNode = nil
 		var address137 TreeNode = nil
 		for {
-			var chunk37 string = ""
-			var max37 int = p.offset + 1
-			if max37 <= len(p.input) {
-				chunk37 = string(p.input[p.offset:max37])
-			}
-			if REGEX_6.MatchString(chunk37) {
-				address137 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-				p.offset = p.offset + 1
+			var end6 int = charClass6.match(p.peekRune(), p.offset)
+			if end6 >= 0 {
+				address137 = &BaseNode{text: p.slice(p.offset, end6), span: p.offsets.span(p.offset, end6), children: nil}
+				p.offset = end6
 			} else {
 				address137 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::identifier", expected: "[a-zA-Z0-9_]"})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::identifier", Expected: "[a-zA-Z0-9_]"})
 				}
 			}
 			if address137 != nil {
@@ -2983,7 +3024,7 @@ func (p *PegGoParser) _read_identifier() TreeNode {
 			}
 		}
 		if len(elements56) >= 0 {
-			address136 = &BaseNode{text: p.slice(index101, p.offset), offset: index101, children: elements56}
+			address136 = &BaseNode{text: p.slice(index101, p.offset), span: p.offsets.span(index101, p.offset), children: elements56}
 		} else {
 			address136 = nil
 		}
@@ -3000,41 +3041,44 @@ func (p *PegGoParser) _read_identifier() TreeNode {
 	if elements55 == nil {
 		address134 = nil
 	} else {
-		address134 = &BaseNode{text: p.slice(index100, p.offset), offset: index100, children: elements55}
+		address134 = &BaseNode{text: p.slice(index100, p.offset), span: p.offsets.span(index100, p.offset), children: elements55}
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleIdentifier, index99, address134, p.offset, silent27 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache26[index99] = cacheEntry{node: address134, offset: p.offset}
 	return address134
 }
 
 func (p *PegGoParser) _read___() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address138 TreeNode = nil
 	var index102 int = p.offset
-	var cache27 map[int]cacheEntry = p.cache["__"]
-	if cache27 == nil {
-		cache27 = make(map[int]cacheEntry)
-		p.cache["__"] = cache27
-	}
-	if entry, ok := cache27[index102]; ok {
+	var silent28 int = p.silent
+	if entry, ok := p.cache.get(rule27, index102, silent28 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index103 int = p.offset
-	var chunk38 string = ""
-	var max38 int = p.offset + 1
-	if max38 <= len(p.input) {
-		chunk38 = string(p.input[p.offset:max38])
-	}
-	if REGEX_7.MatchString(chunk38) {
-		address138 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-		p.offset = p.offset + 1
+	var end7 int = charClass7.match(p.peekRune(), p.offset)
+	if end7 >= 0 {
+		address138 = &BaseNode{text: p.slice(p.offset, end7), span: p.offsets.span(p.offset, end7), children: nil}
+		p.offset = end7
 	} else {
 		address138 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::__", expected: "[\\s]"})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::__", Expected: "[\\s]"})
 		}
 	}
 	if address138 == nil {
@@ -3044,41 +3088,43 @@ func (p *PegGoParser) _read___() TreeNode {
 			p.offset = index103
 		}
 	}
-	cache27[index102] = cacheEntry{node: address138, offset: p.offset}
+	if p.stopErr == nil {
+		p.cache.put(rule27, index102, address138, p.offset, silent28 > 0)
+	}
+	if p.guarded {
+		p.depth--
+	}
 	return address138
 }
 
 func (p *PegGoParser) _read_comment() TreeNode {
+	if p.guarded && p.enter() {
+		return nil
+	}
 	var address139 TreeNode = nil
 	var index104 int = p.offset
-	var cache28 map[int]cacheEntry = p.cache["comment"]
-	if cache28 == nil {
-		cache28 = make(map[int]cacheEntry)
-		p.cache["comment"] = cache28
-	}
-	if entry, ok := cache28[index104]; ok {
+	var silent29 int = p.silent
+	if entry, ok := p.cache.get(RuleComment, index104, silent29 > 0); ok {
 		p.offset = entry.offset
+		if p.guarded {
+			p.depth--
+		}
 		return entry.node
 	}
 	var index105 int = p.offset
 	var elements57 []TreeNode = make([]TreeNode, 2)
 	var address140 TreeNode = nil
-	var chunk39 string = ""
-	var max39 int = p.offset + 1
-	if max39 <= len(p.input) {
-		chunk39 = string(p.input[p.offset:max39])
-	}
-	if chunk39 == "#" {
-		address140 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
+	if p.avail(1) && p.input[p.offset] == '#' {
+		address140 = &BaseNode{text: p.slice(p.offset, p.offset + 1), span: p.offsets.span(p.offset, p.offset + 1), children: nil}
 		p.offset = p.offset + 1
 	} else {
 		address140 = nil
-		if p.offset > p.failure.offset {
-			p.failure.offset = p.offset
-			p.failure.expected = nil
-		}
-		if p.offset == p.failure.offset {
-			p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::comment", expected: "\"#\""})
+		if p.offset >= p.failure.offset && p.silent == 0 {
+			if p.offset > p.failure.offset {
+				p.failure.offset = p.offset
+				p.failure.expected = p.failure.expected[:0]
+			}
+			p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::comment", Expected: "\"#\""})
 		}
 	}
 	if address140 != nil {
@@ -3088,22 +3134,18 @@ func (p *PegGoParser) _read_comment() TreeNode {
 		var elements58 []TreeNode = nil
 		var address142 TreeNode = nil
 		for {
-			var chunk40 string = ""
-			var max40 int = p.offset + 1
-			if max40 <= len(p.input) {
-				chunk40 = string(p.input[p.offset:max40])
-			}
-			if REGEX_8.MatchString(chunk40) {
-				address142 = &BaseNode{text: p.slice(p.offset, p.offset + 1), offset: p.offset, children: nil}
-				p.offset = p.offset + 1
+			var end8 int = charClass8.match(p.peekRune(), p.offset)
+			if end8 >= 0 {
+				address142 = &BaseNode{text: p.slice(p.offset, end8), span: p.offsets.span(p.offset, end8), children: nil}
+				p.offset = end8
 			} else {
 				address142 = nil
-				if p.offset > p.failure.offset {
-					p.failure.offset = p.offset
-					p.failure.expected = nil
-				}
-				if p.offset == p.failure.offset {
-					p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG::comment", expected: "[^\\n]"})
+				if p.offset >= p.failure.offset && p.silent == 0 {
+					if p.offset > p.failure.offset {
+						p.failure.offset = p.offset
+						p.failure.expected = p.failure.expected[:0]
+					}
+					p.failure.expected = append(p.failure.expected, Expectation{Rule: "Canopy.PEG::comment", Expected: "[^\\n]"})
 				}
 			}
 			if address142 != nil {
@@ -3113,7 +3155,7 @@ func (p *PegGoParser) _read_comment() TreeNode {
 			}
 		}
 		if len(elements58) >= 0 {
-			address141 = &BaseNode{text: p.slice(index106, p.offset), offset: index106, children: elements58}
+			address141 = &BaseNode{text: p.slice(index106, p.offset), span: p.offsets.span(index106, p.offset), children: elements58}
 		} else {
 			address141 = nil
 		}
@@ -3130,19 +3172,175 @@ func (p *PegGoParser) _read_comment() TreeNode {
 	if elements57 == nil {
 		address139 = nil
 	} else {
-		address139 = &BaseNode{text: p.slice(index105, p.offset), offset: index105, children: elements57}
+		address139 = &BaseNode{text: p.slice(index105, p.offset), span: p.offsets.span(index105, p.offset), children: elements57}
+	}
+	if p.stopErr == nil {
+		p.cache.put(RuleComment, index104, address139, p.offset, silent29 > 0)
+	}
+	if p.guarded {
+		p.depth--
 	}
-	cache28[index104] = cacheEntry{node: address139, offset: p.offset}
 	return address139
 }
 
-func New(input string, actions Actions) *PegGoParser {
-	return &PegGoParser{
-		input: []rune(input),
-		inputString: input,
+// Rule identifies one of the rules in the grammar.
+type Rule int
+
+const (
+	RuleGrammar Rule = iota
+	RuleGrammarName
+	RuleGrammarRule
+	RuleAssignment
+	RuleParsingExpression
+	RuleParenthesisedExpression
+	RuleChoiceExpression
+	RuleChoicePart
+	RuleActionExpression
+	RuleActionableExpression
+	RuleActionTag
+	RuleTypeTag
+	RuleSequenceExpression
+	RuleSequencePart
+	RuleMaybeAtom
+	RuleRepeatedAtom
+	RuleAtom
+	RuleTerminalNode
+	RulePredicatedAtom
+	RuleReferenceExpression
+	RuleStringExpression
+	RuleCiStringExpression
+	RuleAnyCharExpression
+	RuleCharClassExpression
+	RuleLabel
+	RuleObjectIdentifier
+	RuleIdentifier
+	rule27
+	RuleComment
+	numRules = iota
+)
+
+const minReadSize = 4096
+
+const seenAhead = 16
+
+var ruleNames = [numRules]string{
+	"grammar",
+	"grammar_name",
+	"grammar_rule",
+	"assignment",
+	"parsing_expression",
+	"parenthesised_expression",
+	"choice_expression",
+	"choice_part",
+	"action_expression",
+	"actionable_expression",
+	"action_tag",
+	"type_tag",
+	"sequence_expression",
+	"sequence_part",
+	"maybe_atom",
+	"repeated_atom",
+	"atom",
+	"terminal_node",
+	"predicated_atom",
+	"reference_expression",
+	"string_expression",
+	"ci_string_expression",
+	"any_char_expression",
+	"char_class_expression",
+	"label",
+	"object_identifier",
+	"identifier",
+	"__",
+	"comment",
+}
+
+// ruleReaders holds the method that matches each rule.
+var ruleReaders = [numRules]func(*PegGoParser) TreeNode{
+	(*PegGoParser)._read_grammar,
+	(*PegGoParser)._read_grammar_name,
+	(*PegGoParser)._read_grammar_rule,
+	(*PegGoParser)._read_assignment,
+	(*PegGoParser)._read_parsing_expression,
+	(*PegGoParser)._read_parenthesised_expression,
+	(*PegGoParser)._read_choice_expression,
+	(*PegGoParser)._read_choice_part,
+	(*PegGoParser)._read_action_expression,
+	(*PegGoParser)._read_actionable_expression,
+	(*PegGoParser)._read_action_tag,
+	(*PegGoParser)._read_type_tag,
+	(*PegGoParser)._read_sequence_expression,
+	(*PegGoParser)._read_sequence_part,
+	(*PegGoParser)._read_maybe_atom,
+	(*PegGoParser)._read_repeated_atom,
+	(*PegGoParser)._read_atom,
+	(*PegGoParser)._read_terminal_node,
+	(*PegGoParser)._read_predicated_atom,
+	(*PegGoParser)._read_reference_expression,
+	(*PegGoParser)._read_string_expression,
+	(*PegGoParser)._read_ci_string_expression,
+	(*PegGoParser)._read_any_char_expression,
+	(*PegGoParser)._read_char_class_expression,
+	(*PegGoParser)._read_label,
+	(*PegGoParser)._read_object_identifier,
+	(*PegGoParser)._read_identifier,
+	(*PegGoParser)._read___,
+	(*PegGoParser)._read_comment,
+}
+
+// String returns the name of the rule in the grammar.
+func (r Rule) String() string {
+	if r < 0 || r >= numRules {
+		return fmt.Sprintf("Rule(%d)", int(r))
+	}
+	return ruleNames[r]
+}
+
+var memoDefaults = [numRules]bool{
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+	true,
+}
+
+func New(input string, actions Actions, opts ...Option) *PegGoParser {
+	p := &PegGoParser{
+		input: input,
 		actions: actions,
-		cache: make(map[string]map[int]cacheEntry),
+		failure: failureState{expected: make([]Expectation, 0, 8)},
 	}
+	for _, opt := range opts {
+		opt(&p.opts)
+	}
+	p.offsets = newOffsetIndex(input, p.opts.byteOffsets)
+	p.cache = newMemoTable(&p.opts)
+	p.guarded = p.opts.limits != (Limits{})
+	return p
 }
 
 func (p *PegGoParser) WithTypes(types map[string]NodeExtender) *PegGoParser {
@@ -3150,72 +3348,263 @@ func (p *PegGoParser) WithTypes(types map[string]NodeExtender) *PegGoParser {
 	return p
 }
 
-func Parse(input string, actions Actions, types map[string]NodeExtender) (TreeNode, error) {
-	parser := New(input, actions)
+func Parse(input string, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	parser := New(input, actions, opts...)
+	if types != nil {
+		parser.types = types
+	}
+	return parser.Parse()
+}
+
+// ParseBytes parses a UTF-8 encoded byte slice. The input is copied once,
+// so the slice may be reused after ParseBytes returns; the text of the
+// returned nodes refers to the copy.
+func ParseBytes(input []byte, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	return Parse(string(input), actions, types, opts...)
+}
+
+// NewReader returns a parser that reads its input from r. Input is read
+// as the parser needs it, and read errors are returned by Parse in place
+// of a *ParseError.
+func NewReader(r io.Reader, actions Actions, opts ...Option) *PegGoParser {
+	p := New("", actions, opts...)
+	p.reader = r
+	p.open = true
+	return p
+}
+
+// ParseReader parses UTF-8 encoded input read from r. See NewReader.
+func ParseReader(r io.Reader, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	parser := NewReader(r, actions, opts...)
 	if types != nil {
 		parser.types = types
 	}
 	return parser.Parse()
 }
 
+// ParseRule parses input starting from rule, rather than from the first
+// rule in the grammar.
+func ParseRule(rule Rule, input string, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {
+	parser := New(input, actions, opts...)
+	if types != nil {
+		parser.types = types
+	}
+	return parser.ParseRule(rule)
+}
+
 func (p *PegGoParser) Parse() (TreeNode, error) {
-	node := p._read_grammar()
+	return p.parseRule(RuleGrammar)
+}
+
+// ParseRule is like Parse, but starts from rule. It may be used with a
+// parser created by New or NewReader.
+func (p *PegGoParser) ParseRule(rule Rule) (TreeNode, error) {
+	if p.stream != nil {
+		return nil, fmt.Errorf("ParseRule cannot be used with a parser created by NewStream")
+	}
+	if rule < 0 || rule >= numRules {
+		return nil, fmt.Errorf("unknown rule %d", int(rule))
+	}
+	return p.parseRule(rule)
+}
+
+// parseRule parses the whole input from rule. Each parse starts afresh,
+// except the passes of a parser created by NewStream, which carry on
+// from the state resume restores.
+func (p *PegGoParser) parseRule(rule Rule) (TreeNode, error) {
+	if p.stream == nil {
+		p.startOver()
+	}
+	return p.parseInput(rule)
+}
+
+func (p *PegGoParser) parseInput(rule Rule) (TreeNode, error) {
+	if err := p.begin(); err != nil {
+		return nil, err
+	}
+	p.cache.reserve(len(p.input))
+	node := ruleReaders[rule](p)
+	if err := p.finish(rule, node != nil, false); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// finish returns the error for a parse from start that matched if matched,
+// or nil if it succeeded. A prefix parse succeeds if start matched; any
+// other parse must also have reached the end of the input.
+func (p *PegGoParser) finish(start Rule, matched, prefix bool) error {
+	if p.stopErr != nil {
+		return p.stopErr
+	}
+	complete := matched && (prefix || !p.avail(1))
+	if p.cache.truncated {
+		return ErrIncomplete
+	}
+	if p.readErr != nil {
+		return p.readErr
+	}
 	if p.actionErr != nil {
-		return nil, p.actionErr
+		return p.actionErr
 	}
-	if node != nil && p.offset == len(p.input) {
-		return node, nil
+	if complete {
+		return nil
+	}
+	if matched && p.failure.offset < p.offset {
+		// The failures before the end of the match were all recovered from,
+		// so the error is the input left over.
+		p.failure.offset = p.offset
+		p.failure.expected = p.failure.expected[:0]
 	}
 	if len(p.failure.expected) == 0 {
+		rule := "Canopy.PEG"
+		if start != RuleGrammar {
+			rule += "::" + ruleNames[start]
+		}
 		p.failure.offset = p.offset
-		p.failure.expected = append(p.failure.expected, expectation{rule: "Canopy.PEG", expected: "<EOF>"})
+		p.failure.expected = append(p.failure.expected, Expectation{Rule: rule, Expected: "<EOF>"})
 	}
-	return nil, p.newParseError()
+	return p.newParseError(matched)
 }
 
-func (p *PegGoParser) newParseError() error {
-	line, column := 1, 1
-	for i, r := range p.input {
-		if i >= p.failure.offset {
-			break
-		}
-		if r == '\n' {
-			line++
-			column = 1
-		} else {
-			column++
-		}
+// newParseError describes the furthest failure. stopped reports that the
+// root rule matched, and the parse only failed because input was left over.
+func (p *PegGoParser) newParseError(stopped bool) error {
+	pos := p.lineIndex().position(p.failure.offset)
+	status := "parse error"
+	if stopped {
+		status = "parse stopped early"
 	}
-	message := fmt.Sprintf("parse error at line %d, column %d", line, column)
-	if len(p.failure.expected) > 0 {
-		message += ": expected "
-		for i, exp := range p.failure.expected {
-			if i > 0 {
-				if i == len(p.failure.expected)-1 {
-					message += " or "
-				} else {
-					message += ", "
-				}
-			}
-			message += fmt.Sprintf("%s from %s", exp.expected, exp.rule)
-		}
+	expected := sortExpectations(p.failure.expected, pos.Offset)
+	message := fmt.Sprintf("%s at line %d, column %d", status, pos.Line, pos.Column)
+	if len(expected) > 0 {
+		message += ": " + describeExpected(expected)
+	}
+	if p.open && p.stream == nil {
+		// Read far enough past the failure to describe what is there.
+		p.fill(p.failure.offset + maxFoundRunes*utf8.UTFMax)
+	}
+	found := describeFound(p.input[p.failure.offset:])
+	message += " but found " + found
+	kind, end := UnexpectedInput, 0
+	if stopped {
+		kind, end = TrailingInput, p.offsets.convert(p.offset)
+	}
+	if p.failure.offset == len(p.input) {
+		kind = UnexpectedEOF
 	}
-	expected := make([]expectation, len(p.failure.expected))
-	copy(expected, p.failure.expected)
 	return &ParseError{
-		Input: p.inputString,
-		Offset: p.failure.offset,
-		Line: line,
-		Column: column,
+		Input: p.input,
+		Offset: pos.Offset,
+		Line: pos.Line,
+		Column: pos.Column,
+		UTF16Column: pos.UTF16Column,
 		Expected: expected,
+		Found: found,
 		Message: message,
+		Kind: kind,
+		End: end,
+	}
+}
+
+// Position returns the line and column of offset, which is in the same
+// units as node offsets. The line index is built on the first call.
+func (p *PegGoParser) Position(offset int) Position {
+	return p.lineIndex().Position(offset)
+}
+
+func (p *PegGoParser) lineIndex() *LineIndex {
+	if p.lines == nil {
+		p.lines = newLineIndex(&p.offsets)
+	}
+	return p.lines
+}
+
+// peek returns the input, first making n bytes at the current offset
+// available if it can.
+func (p *PegGoParser) peek(n int) string {
+	if p.offset+n > p.seen {
+		p.fill(p.offset + n)
+	}
+	return p.input
+}
+
+// avail reports whether n bytes of input are available at the current
+// offset.
+func (p *PegGoParser) avail(n int) bool {
+	return p.offset+n <= p.seen || p.fill(p.offset+n)
+}
+
+// peekRune is like peek, but makes available only the bytes of the rune
+// at the current offset, so that a parser fed by Feed does not wait for
+// input it does not need.
+func (p *PegGoParser) peekRune() string {
+	if p.offset+utf8.UTFMax > p.seen {
+		p.fillRune()
+	}
+	return p.input
+}
+
+func (p *PegGoParser) fillRune() {
+	if p.avail(1) && p.input[p.offset] >= utf8.RuneSelf {
+		p.avail(leadWidth(p.input[p.offset]))
+	}
+}
+
+// fill makes the input before want available, first reading more if it
+// may follow, and reports whether it is there. Terminals only call fill
+// once they look past p.seen, which it moves seenAhead bytes beyond want,
+// so p.cache.reach can record how far the parse has looked without
+// slowing down the rest.
+func (p *PegGoParser) fill(want int) bool {
+	if want > len(p.input) && p.open {
+		p.read(want)
+	}
+	p.seen = min(want+seenAhead, len(p.input))
+	p.cache.reach = max(p.cache.reach, p.seen, want)
+	return want <= len(p.input)
+}
+
+// read reads until the input holds want bytes or the reader is exhausted.
+// Each read at least doubles the input, so that copying the buffer into
+// p.input costs linear time overall. A parser created by NewStream gets
+// its input from Feed instead.
+func (p *PegGoParser) read(want int) {
+	if p.stream != nil {
+		p.more(want)
+		return
+	}
+	target := max(want, 2*len(p.buf), minReadSize)
+	if limit := p.opts.limits.MaxInputSize; limit > 0 {
+		// One byte past the limit is enough to tell the input is too long.
+		target = min(target, limit+1)
+	}
+	for len(p.buf) < target && p.reader != nil {
+		if len(p.buf) == cap(p.buf) {
+			p.buf = slices.Grow(p.buf, target-len(p.buf))
+		}
+		n, err := p.reader.Read(p.buf[len(p.buf):cap(p.buf)])
+		p.buf = p.buf[:len(p.buf)+n]
+		if err != nil {
+			if err != io.EOF {
+				p.readErr = err
+			}
+			p.reader = nil
+			p.open = false
+		}
+	}
+	if p.inputTooLong(len(p.buf)) {
+		p.reader = nil
+		p.open = false
 	}
+	p.input = string(p.buf)
+	p.offsets.extend(p.input, !p.open)
 }
 
 func (p *PegGoParser) slice(start, end int) string {
 	if start < 0 { start = 0 }
 	if end > len(p.input) { end = len(p.input) }
 	if start > end { start = end }
-	return string(p.input[start:end])
+	return p.input[start:end]
 }
 
@@ -0,0 +1,140 @@
+// This file was generated from examples/canopy/peg.peg
+// See https://canopy.jcoglan.com/ for documentation
+
+package peggoparser
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrIncomplete is returned by Feed while the result of the parse depends on
+// input that has not been fed yet.
+var ErrIncomplete = errors.New("input is incomplete")
+
+// streamState holds what a parser created by NewStream keeps between passes:
+// the failure state and action error saved when the last pass ran out of
+// input, and the result once the parse is finished.
+//
+// Each pass starts on the input the previous pass had, and until it first
+// runs out of that input it repeats what the previous pass did. replaying is
+// set until then, and mark is the number of expectations saved, so that the
+// ones recorded again by the replay can be dropped.
+type streamState struct {
+	failure   failureState
+	actionErr error
+	replaying bool
+	mark      int
+	closed    bool
+	done      bool
+	node      TreeNode
+	err       error
+}
+
+// NewStream returns a parser whose input is supplied in chunks by Feed, for
+// callers that receive input piecemeal and cannot block on an io.Reader.
+func NewStream(actions Actions, opts ...Option) *PegGoParser {
+	p := New("", actions, opts...)
+	p.open = true
+	p.stream = &streamState{}
+	return p
+}
+
+// Feed appends chunk to the input and parses it again. Each pass reuses the
+// memoized results of rules that finished without reaching the end of the
+// input, so only the rules still waiting for input are run again. Rules
+// that enclose the whole input, such as a repetition of records, still
+// revisit everything they matched, so a pass is only run once the input has
+// doubled since the last one, keeping the total cost linear.
+//
+// Feed returns ErrIncomplete while more input could change the result, and
+// otherwise the result Parse would return for the input fed so far. Input
+// that matches the grammar could always be followed by more, so a tree is
+// only returned by Close, but an error may be returned before then, once no
+// further input could avoid it. Such an error describes what was found at
+// the failure from the input fed so far, so its Found may stop short of what
+// Parse would report for the whole input. Once a result has been returned,
+// later calls return it again.
+func (p *PegGoParser) Feed(chunk []byte) (TreeNode, error) {
+	if p.stream == nil {
+		return nil, fmt.Errorf("parser was not created by NewStream")
+	}
+	if p.stream.done {
+		return p.stream.node, p.stream.err
+	}
+	p.buf = append(p.buf, chunk...)
+	if p.inputTooLong(len(p.buf)) {
+		p.stream.done = true
+		p.stream.err = p.stopErr
+		return nil, p.stopErr
+	}
+	if len(p.buf) < 2*len(p.input) {
+		return nil, ErrIncomplete
+	}
+	return p.resume()
+}
+
+// Close marks the end of the input and returns the result of the parse.
+func (p *PegGoParser) Close() (TreeNode, error) {
+	if p.stream == nil {
+		return nil, fmt.Errorf("parser was not created by NewStream")
+	}
+	if p.stream.done {
+		return p.stream.node, p.stream.err
+	}
+	p.stream.closed = true
+	return p.resume()
+}
+
+// resume runs a pass over the input fed so far, first undoing anything the
+// previous pass recorded after it ran out of input.
+func (p *PegGoParser) resume() (TreeNode, error) {
+	if p.cache.truncated {
+		p.cache.discardPending()
+		p.failure = p.stream.failure
+		p.actionErr = p.stream.actionErr
+	}
+	p.stream.replaying = true
+	p.stream.mark = len(p.failure.expected)
+	p.offset = 0
+	node, err := p.Parse()
+	if err != ErrIncomplete {
+		p.stream.done = true
+		p.stream.node, p.stream.err = node, err
+	}
+	return node, err
+}
+
+// more is read for a parser created by NewStream. The first call in a pass
+// ends the replay of the previous pass, so it drops the expectations
+// recorded twice and makes the rest of the fed input available.
+func (p *PegGoParser) more(want int) {
+	if p.stream.replaying {
+		p.stream.replaying = false
+		p.failure.expected = p.failure.expected[:p.stream.mark]
+		if len(p.input) < len(p.buf) {
+			p.input = string(p.buf)
+		}
+		p.offsets.extend(p.input, p.stream.closed)
+	}
+	if want > len(p.input) && !p.stream.closed {
+		p.truncate()
+	}
+}
+
+// truncate records that the pass has reached the end of the input fed so
+// far. The first time this happens in a pass it saves the failure state and
+// action error, since anything recorded after this point may not happen
+// once more input arrives.
+func (p *PegGoParser) truncate() {
+	if p.cache.truncated {
+		return
+	}
+	p.cache.truncated = true
+	p.stream.failure = failureState{
+		offset:   p.failure.offset,
+		expected: slices.Clone(p.failure.expected),
+	}
+	p.stream.actionErr = p.actionErr
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"jsongoparser"
@@ -75,3 +76,23 @@ func benchmarkParse(b *testing.B, input string, parse func(string) error) {
 		}
 	}
 }
+
+// BenchmarkCanopyParserAfterLargeInput parses small documents with a Parser
+// that has already parsed a large one, whose memo table it must not keep.
+func BenchmarkCanopyParserAfterLargeInput(b *testing.B) {
+	parser := jsongoparser.NewParser(nil, nil)
+	large := "[" + strings.Repeat(jsonInput+",", 4<<20/len(jsonInput)) + jsonInput + "]"
+	if _, err := parser.Parse(large); err != nil {
+		b.Fatalf("large parse failed: %v", err)
+	}
+	small := `{"name": "canopy", "keywords": ["peg", "parser"], "version": 1}`
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.Parse(small); err != nil {
+			b.Fatalf("parse failed: %v", err)
+		}
+	}
+}
@@ -4,7 +4,7 @@ class AnyChar {
   compile (builder, address, action) {
     builder.if_(builder.hasChars_(), () => {
       let of = builder.offset_()
-      builder.syntaxNode_(address, of, of + ' + 1', null, action)
+      builder.syntaxNode_(address, of, builder.terminalEnd_(of, 1), null, action)
     }, () => {
       builder.failure_(address, '<any char>')
     })
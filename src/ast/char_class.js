@@ -12,7 +12,7 @@ class CharClass {
 
     builder.if_(builder.regexMatch_(regex, chunk), () => {
       let of = builder.offset_()
-      builder.syntaxNode_(address, of, of + ' + 1', null, action)
+      builder.syntaxNode_(address, of, builder.terminalEnd_(of, 1, chunk), null, action)
     }, () => {
       builder.failure_(address, this._text)
     })
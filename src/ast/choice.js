@@ -17,7 +17,13 @@ class Choice {
   _compileChoices (builder, address, index, startOffset) {
     if (index === this._options.length) return
 
-    this._options[index].compile(builder, address)
+    let option = this._options[index]
+
+    if (option.mayCut && index < this._options.length - 1) {
+      builder.backtrack_(() => option.compile(builder, address))
+    } else {
+      option.compile(builder, address)
+    }
 
     builder.unlessNode_(address, () => {
       builder.assign_(builder.offset_(), startOffset)
@@ -0,0 +1,8 @@
+'use strict'
+
+// A cut has no compile method of its own: the sequence it appears in
+// records where it is, and commits to the sequence once the parts before it
+// have matched.
+class Cut {}
+
+module.exports = Cut
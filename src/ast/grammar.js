@@ -12,6 +12,7 @@ class Grammar {
 
   compile (builder) {
     let [nodeLabels, actions, regexes] = this._gatherComponents()
+    this._markCuts()
 
     builder.package_(this._name, [...actions].sort(), () => {
       let nodeClassName = builder.syntaxNodeClass_()
@@ -25,6 +26,8 @@ class Grammar {
 
         for (let rule of this._rules)
           rule.compile(builder)
+
+        this._rules[0].compileItems(builder)
       })
 
       let root = this._rules[0].name
@@ -49,6 +52,41 @@ class Grammar {
     return [nodeLabels, actions, regexes]
   }
 
+  // Sets mayCut on every expression that can reach a cut, either in itself
+  // or through the rules it references, so that the expressions enclosing
+  // a cut can tell the builder where the parse may go back to.
+  _markCuts () {
+    let cutting = new Set(),
+        changed = true
+
+    let reachesCut = (node) => {
+      if (node.hasCut && node.hasCut()) return true
+      if (node.refName !== undefined && cutting.has(node.refName)) return true
+      if (!node[Symbol.iterator]) return false
+
+      for (let child of node) {
+        if (reachesCut(child)) return true
+      }
+      return false
+    }
+
+    while (changed) {
+      changed = false
+      for (let rule of this._rules) {
+        if (!cutting.has(rule.name) && reachesCut(rule)) {
+          cutting.add(rule.name)
+          changed = true
+        }
+      }
+    }
+
+    if (cutting.size === 0) return
+
+    this._scan(this, (node) => {
+      node.mayCut = reachesCut(node)
+    })
+  }
+
   _compileTreeNode (builder, nodeClassName, i, [node, labels]) {
     let className = nodeClassName + (i + 1)
     node.setNodeClassName(className)
@@ -9,9 +9,18 @@ class Maybe {
     yield this._expression
   }
 
+  canFail () {
+    return false
+  }
+
   compile (builder, address) {
     let startOffset = builder.localVar_('index', builder.offset_())
-    this._expression.compile(builder, address)
+
+    if (this._expression.mayCut) {
+      builder.backtrack_(() => this._expression.compile(builder, address))
+    } else {
+      this._expression.compile(builder, address)
+    }
 
     builder.unlessNode_(address, () => {
       builder.syntaxNode_(address, startOffset, startOffset, null)
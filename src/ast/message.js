@@ -0,0 +1,20 @@
+'use strict'
+
+class Message {
+  constructor (expression, message) {
+    this._expression = expression
+    this._message    = message
+  }
+
+  *[Symbol.iterator] () {
+    yield this._expression
+  }
+
+  compile (builder, address, action) {
+    builder.expected_(address, this._message, true, () => {
+      this._expression.compile(builder, address, action)
+    })
+  }
+}
+
+module.exports = Message
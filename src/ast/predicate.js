@@ -1,9 +1,10 @@
 'use strict'
 
 class Predicate {
-  constructor (expression, positive) {
+  constructor (expression, positive, text) {
     this._expression = expression
     this._positive   = positive
+    this._text       = text
   }
 
   *[Symbol.iterator] () {
@@ -14,7 +15,17 @@ class Predicate {
     let startOffset = builder.localVar_('index', builder.offset_()),
         branch      = this._positive ? 'ifNode_' : 'unlessNode_'
 
-    this._expression.compile(builder, address)
+    builder.lookahead_(address, startOffset, this._positive, this._text, () => {
+      if (this._expression.mayCut) {
+        builder.lookaheadCut_(address, () => {
+          builder.backtrack_(() => {
+            builder.barrier_(() => this._expression.compile(builder, address))
+          })
+        })
+      } else {
+        this._expression.compile(builder, address)
+      }
+    })
     builder.assign_(builder.offset_(), startOffset)
 
     builder[branch](address, () => {
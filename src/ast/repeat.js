@@ -10,6 +10,10 @@ class Repeat {
     yield this._expression
   }
 
+  canFail () {
+    return this._range[0] > 0
+  }
+
   compile (builder, address, action) {
     let temp = builder.localVars_({
           index:     builder.offset_(),
@@ -22,7 +26,7 @@ class Repeat {
         elAddr      = temp.address
 
     builder.loop_(() => {
-      this._expression.compile(builder, elAddr)
+      this._compileIteration(builder, elAddr)
 
       builder.ifNode_(elAddr, () => {
         builder.append_(elements, elAddr)
@@ -37,6 +41,28 @@ class Repeat {
       builder.assign_(address, builder.nullNode_())
     })
   }
+
+  // An iteration that may reach a cut can be abandoned at its start. If more
+  // than one is needed, the repetition may still fail once one has matched,
+  // so it is a barrier to the cut as well.
+  _compileIteration (builder, elAddr) {
+    let compile = () => this._expression.compile(builder, elAddr)
+
+    if (!this._expression.mayCut)
+      compile()
+    else if (this._range[0] > 1)
+      builder.barrier_(() => builder.backtrack_(compile))
+    else
+      builder.backtrack_(compile)
+  }
+
+  compileItems (builder) {
+    if (this._range[1] !== -1) return
+
+    builder.items_(this._range[0], (address) => {
+      this._expression.compile(builder, address)
+    })
+  }
 }
 
 module.exports = Repeat
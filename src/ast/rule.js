@@ -1,8 +1,16 @@
 'use strict'
 
+const ANNOTATIONS = ['nomemo']
+
 class Rule {
-  constructor (name, expression) {
+  constructor (name, expression, annotations = [], displayName = null) {
+    for (let annotation of annotations) {
+      if (!ANNOTATIONS.includes(annotation))
+        throw new Error("Unknown annotation '@" + annotation + "' on rule '" + name + "'")
+    }
     this.name        = name
+    this.memoized    = !annotations.includes('nomemo')
+    this.displayName = displayName
     this._expression = expression
   }
 
@@ -14,11 +22,27 @@ class Rule {
     builder.rule_(this.name, () => {
       builder.method_('_read_' + this.name, [], () => {
         builder.cache_(this.name, (address) => {
-          this._expression.compile(builder, address)
-        })
+          if (this.displayName === null) {
+            this._expression.compile(builder, address)
+          } else {
+            builder.expected_(address, this.displayName, false, () => {
+              this._expression.compile(builder, address)
+            })
+          }
+        }, this.memoized)
       })
     })
   }
+
+  // Called on the root rule, to let builders match the items of a root rule
+  // like `program <- statement*` one at a time.
+  compileItems (builder) {
+    if (!this._expression.compileItems) return
+
+    builder.rule_(this.name, () => {
+      this._expression.compileItems(builder)
+    })
+  }
 }
 
 module.exports = Rule
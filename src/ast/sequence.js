@@ -1,8 +1,22 @@
 'use strict'
 
+const Cut = require('./cut')
+
 class Sequence {
   constructor (parts) {
-    this._parts = parts
+    this._parts = []
+    this._cuts  = []
+
+    for (let part of parts) {
+      if (part instanceof Cut)
+        this._cuts.push(this._parts.length)
+      else
+        this._parts.push(part)
+    }
+  }
+
+  hasCut () {
+    return this._cuts.length > 0
   }
 
   [Symbol.iterator] () {
@@ -49,11 +63,17 @@ class Sequence {
   }
 
   _compileExpressions (builder, index, elIndex, startOffset, elements) {
+    if (this._cuts.includes(index)) builder.cut_()
+
     let expAddr = builder.localVar_('address'),
         expr    = this._parts[index],
         muted   = expr.muted()
 
-    expr.compile(builder, expAddr)
+    if (this._isBarrier(index)) {
+      builder.barrier_(() => expr.compile(builder, expAddr))
+    } else {
+      expr.compile(builder, expAddr)
+    }
 
     builder.ifNode_(expAddr, () => {
       if (!muted) {
@@ -62,14 +82,26 @@ class Sequence {
       }
       if (index < this._parts.length - 1) {
         this._compileExpressions(builder, index + 1, elIndex, startOffset, elements)
-      } else if (muted) {
-        builder.pass_()
+      } else {
+        if (this._cuts.includes(index + 1)) builder.cut_()
+        if (muted) builder.pass_()
       }
     }, () => {
+      if (this._cuts[0] <= index) builder.cutFailure_()
       builder.assign_(elements, builder.null_())
       builder.assign_(builder.offset_(), startOffset)
     })
   }
+
+  // A part that may reach a cut is a barrier to it if a later part may fail
+  // before this sequence has passed a cut of its own, because the sequence
+  // could then fail after the part had matched, and go back further.
+  _isBarrier (index) {
+    if (!this._parts[index].mayCut || this._cuts[0] <= index) return false
+
+    let end = this._cuts[0]
+    return this._parts.slice(index + 1, end).some((part) => part.canFail())
+  }
 }
 
 module.exports = Sequence
@@ -22,6 +22,10 @@ class SequencePart {
     return this._muted
   }
 
+  canFail () {
+    return !this._expression.canFail || this._expression.canFail()
+  }
+
   compile (builder, address) {
     this._expression.compile(builder, address)
   }
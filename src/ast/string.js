@@ -7,6 +7,10 @@ class String {
     this._ci    = ci
   }
 
+  get value () {
+    return this._value
+  }
+
   compile (builder, address, action) {
     let value  = this._value,
         length = value.length,
@@ -18,7 +22,7 @@ class String {
 
     builder.if_(condition, () => {
       let of = builder.offset_()
-      builder.syntaxNode_(address, of, of + ' + ' + length, null, action)
+      builder.syntaxNode_(address, of, builder.terminalEnd_(of, length, chunk), null, action)
     }, () => {
       builder.failure_(address, this._text)
     })
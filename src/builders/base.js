@@ -50,6 +50,58 @@ class Base {
 
   compileRegex_ (charClass, name) {}
 
+  // Compiles the items of a root rule that repeats an expression any number
+  // of times, at least min, for builders that can return them one by one.
+  items_ (min, block) {}
+
+  // Returns the offset just past a terminal of the given length that matched
+  // at offset. Builders whose offsets do not count characters override this,
+  // using the chunk the terminal was matched against, if it has one.
+  terminalEnd_ (offset, length, chunk) {
+    return offset + ' + ' + length
+  }
+
+  // Compiles an expression whose failure is described by text: a rule's
+  // display name, or a message given in the grammar if custom is true.
+  // Builders that do not describe failures this way just compile it.
+  expected_ (address, text, custom, block) {
+    block()
+  }
+
+  // Compiles the expression of a lookahead that started at startOffset,
+  // which fails if address is null and positive is true, or if it is not
+  // null and positive is false. text is the expression's source, for
+  // builders that report what a lookahead looked for.
+  lookahead_ (address, startOffset, positive, text, block) {
+    block()
+  }
+
+  // Builders that support cuts override these. backtrack_ and barrier_
+  // surround an expression that may reach a cut and that the parse may go
+  // back to the start of, or that another expression may fail after, and
+  // lookaheadCut_ the expression of a lookahead that may reach one, which
+  // fails if a cut inside it does; cut_ is called as a sequence passes a
+  // cut, and cutFailure_ when the sequence fails after one. A cut changes
+  // what a grammar matches, so the other builders reject a grammar that uses
+  // one.
+  backtrack_ (block) {
+    block()
+  }
+
+  barrier_ (block) {
+    block()
+  }
+
+  lookaheadCut_ (address, block) {
+    block()
+  }
+
+  cut_ () {
+    throw new Error("Cuts are not supported by this target: rule '" + this._ruleName + "' uses '~'")
+  }
+
+  cutFailure_ () {}
+
   rule_ (name, block) {
     this._ruleName = name
     block()
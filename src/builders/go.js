@@ -20,13 +20,150 @@ const toPackageName = (source) => {
   return cleaned;
 };
 
+const MAX_RUNE = 0x10ffff;
+
+// Character class escapes, with the meaning they have in Go's regexp package
+// so that generated matchers accept exactly what the regexes used to.
+const CLASS_ESCAPES = {
+  d: [[0x30, 0x39]],
+  s: [[0x09, 0x0a], [0x0c, 0x0d], [0x20, 0x20]],
+  w: [[0x30, 0x39], [0x41, 0x5a], [0x5f, 0x5f], [0x61, 0x7a]],
+};
+
+const CHAR_ESCAPES = { a: 7, f: 12, n: 10, r: 13, t: 9, v: 11 };
+
+const normalizeRanges = (ranges) => {
+  let sorted = ranges.slice().sort((a, b) => a[0] - b[0]);
+  let merged = [];
+  for (let [lo, hi] of sorted) {
+    let last = merged[merged.length - 1];
+    if (last && lo <= last[1] + 1) {
+      last[1] = Math.max(last[1], hi);
+    } else {
+      merged.push([lo, hi]);
+    }
+  }
+  return merged;
+};
+
+const invertRanges = (ranges) => {
+  let inverted = [];
+  let next = 0;
+  for (let [lo, hi] of normalizeRanges(ranges)) {
+    if (lo > next) inverted.push([next, lo - 1]);
+    next = hi + 1;
+  }
+  if (next <= MAX_RUNE) inverted.push([next, MAX_RUNE]);
+  return inverted;
+};
+
+// Converts the source of a character class like `[^a-z\n]` into a sorted
+// list of inclusive code point ranges. Returns null for syntax we do not
+// translate, such as Unicode property classes, in which case the builder falls
+// back to the regexp package.
+const parseCharClass = (source) => {
+  let chars = Array.from(source.slice(1, -1));
+  let negated = chars[0] === '^';
+  if (negated) chars.shift();
+
+  let ranges = [];
+  let i = 0;
+
+  let readEscape = () => {
+    let c = chars[i++];
+    if (c === undefined) return null;
+    if (CLASS_ESCAPES[c]) return { ranges: CLASS_ESCAPES[c] };
+    let upper = c.toLowerCase();
+    if (c !== upper && CLASS_ESCAPES[upper]) {
+      return { ranges: invertRanges(CLASS_ESCAPES[upper]) };
+    }
+    if (CHAR_ESCAPES[c] !== undefined) return { char: CHAR_ESCAPES[c] };
+    if (c === 'x') {
+      let digits = '';
+      if (chars[i] === '{') {
+        let close = chars.indexOf('}', i);
+        if (close < 0) return null;
+        digits = chars.slice(i + 1, close).join('');
+        i = close + 1;
+      } else {
+        digits = chars.slice(i, i + 2).join('');
+        i += 2;
+      }
+      if (!/^[0-9a-fA-F]+$/.test(digits)) return null;
+      let code = parseInt(digits, 16);
+      return code > MAX_RUNE ? null : { char: code };
+    }
+    if (/[0-7]/.test(c)) {
+      let digits = c;
+      while (digits.length < 3 && /[0-7]/.test(chars[i] || '')) digits += chars[i++];
+      return { char: parseInt(digits, 8) };
+    }
+    if (/[A-Za-z0-9]/.test(c)) return null;
+    return { char: c.codePointAt(0) };
+  };
+
+  let readAtom = () => {
+    if (chars[i] === '[') return null;
+    if (chars[i] === '\\') {
+      i += 1;
+      return readEscape();
+    }
+    return { char: chars[i++].codePointAt(0) };
+  };
+
+  while (i < chars.length) {
+    let atom = readAtom();
+    if (!atom) return null;
+    if (atom.ranges) {
+      ranges.push(...atom.ranges);
+      continue;
+    }
+    if (chars[i] === '-' && i + 1 < chars.length) {
+      i += 1;
+      let end = readAtom();
+      if (!end || end.ranges || end.char < atom.char) return null;
+      ranges.push([atom.char, end.char]);
+    } else {
+      ranges.push([atom.char, atom.char]);
+    }
+  }
+
+  return negated ? invertRanges(ranges) : normalizeRanges(ranges);
+};
+
+const hex = (n) => '0x' + n.toString(16);
+
+const runeLiteral = (c) => {
+  if (c === "'" || c === '\\') return "'\\" + c + "'";
+  if (/^[\x20-\x7e]$/.test(c)) return "'" + c + "'";
+  return hex(c.codePointAt(0));
+};
+
+// The runtime support files every parser gets, in the order they are
+// written. Each template is given the package and parser struct names.
+const RUNTIME_FILES = [
+  'treenode.go',
+  'options.go',
+  'offsets.go',
+  'position.go',
+  'errors.go',
+  'pretty.go',
+  'memo.go',
+  'cut.go',
+  'stream.go',
+  'limits.go',
+  'pool.go',
+  'context.go',
+  'recover.go',
+];
+
 const TYPES = {
   address: 'TreeNode',
   index: 'int',
   elements: '[]TreeNode',
-  chunk: 'string',
-  max: 'int',
-  cache: 'map[int]cacheEntry',
+  end: 'int',
+  points: 'int',
+  silent: 'int',
 };
 
 class Builder extends Base {
@@ -35,6 +172,11 @@ class Builder extends Base {
     this._parserImports = new Set();
     this._currentClass = null;
     this._usesExtensions = false;
+    this._usesActions = false;
+    this._ruleConsts = new Map();
+    this._calledActions = new Map();
+    this._memoized = new Map();
+    this._charClasses = new Map();
   }
 
   _tab() {
@@ -71,19 +213,25 @@ class Builder extends Base {
       this._actionMap.set(actionName, methodName);
       return methodName;
     });
-    this._parserImports = new Set(['fmt']);
+    this._parserImports = new Set([
+      'context',
+      'fmt',
+      'io',
+      'slices',
+      'unicode/utf8',
+    ]);
 
     this._currentBuffer = join(this._outputPath, 'parser.go');
     this._buffers.set(this._currentBuffer, '');
     this._writePrelude();
 
-    this._currentBuffer = join(this._outputPath, 'treenode.go');
-    this._buffers.set(this._currentBuffer, '');
-    this._template('go', 'treenode.go.tpl', { name: this._packageName });
-
-    this._currentBuffer = join(this._outputPath, 'actions.go');
-    this._buffers.set(this._currentBuffer, '');
-    this._template('go', 'actions.go.tpl', {
+    for (let file of RUNTIME_FILES) {
+      this._writeTemplate(file, {
+        name: this._packageName,
+        parser: this._structName,
+      });
+    }
+    this._writeTemplate('actions.go', {
       name: this._packageName,
       actions: this._actionNames,
     });
@@ -110,59 +258,40 @@ class Builder extends Base {
     this._line('type NodeExtender func(TreeNode) TreeNode');
     this._newline();
 
-    this._line('type expectation struct {');
-    this._indent(() => {
-      this._line('rule string');
-      this._line('expected string');
-    });
-    this._line('}');
-    this._newline();
-
     this._line('type failureState struct {');
     this._indent(() => {
       this._line('offset int');
-      this._line('expected []expectation');
-    });
-    this._line('}');
-    this._newline();
-
-    this._line('type cacheEntry struct {');
-    this._indent(() => {
-      this._line('node TreeNode');
-      this._line('offset int');
-    });
-    this._line('}');
-    this._newline();
-
-    this._line('type ParseError struct {');
-    this._indent(() => {
-      this._line('Input string');
-      this._line('Offset int');
-      this._line('Line int');
-      this._line('Column int');
-      this._line('Expected []expectation');
-      this._line('Message string');
-    });
-    this._line('}');
-    this._newline();
-
-    this._line('func (e *ParseError) Error() string {');
-    this._indent(() => {
-      this._line('return e.Message');
+      this._line('expected []Expectation');
     });
     this._line('}');
     this._newline();
 
     this._line('type ' + this._structName + ' struct {');
     this._indent(() => {
-      this._line('input []rune');
-      this._line('inputString string');
+      this._line('input string');
+      this._line('offsets offsetIndex');
+      this._line('lines *LineIndex');
+      this._line('reader io.Reader');
+      this._line('stream *streamState');
+      this._line('open bool');
+      this._line('buf []byte');
+      this._line('readErr error');
       this._line('actions Actions');
       this._line('types map[string]NodeExtender');
+      this._line('opts options');
       this._line('offset int');
-      this._line('cache map[string]map[int]cacheEntry');
+      this._line('seen int');
+      this._line('cache memoTable');
       this._line('failure failureState');
+      this._line('silent int');
+      this._line('lookahead int');
+      this._line('points []backtrackPoint');
       this._line('actionErr error');
+      this._line('ctx context.Context');
+      this._line('guarded bool');
+      this._line('depth int');
+      this._line('steps int');
+      this._line('stopErr error');
     });
     this._line('}');
     this._newline();
@@ -179,6 +308,43 @@ class Builder extends Base {
 
   parserClass_(root) {
     this._writeParserHelpers(root);
+
+    this._writeTemplate('prefix.go', {
+      name: this._packageName,
+      parser: this._structName,
+      root,
+      rootRule: this._ruleConst(root),
+    });
+    this._writeTemplate('edit.go', {
+      name: this._packageName,
+      parser: this._structName,
+      rootRule: this._ruleConst(root),
+    });
+    if (this._items) {
+      this._writeTemplate('items.go', {
+        name: this._packageName,
+        parser: this._structName,
+        rootRule: this._ruleConst(root),
+        min: this._items.min,
+        matched: this._items.min > 0 ? 'count >= ' + this._items.min : 'true',
+      });
+      this._writeTemplate('recovery.go', {
+        name: this._packageName,
+        parser: this._structName,
+        min: this._items.min,
+      });
+    }
+  }
+
+  // readItem matches one item of the root rule for Items, which is written
+  // to its own file by parserClass_.
+  items_(min, block) {
+    this._items = { min };
+    this.method_('readItem', [], () => {
+      let address = this.localVar_('address');
+      block(address);
+      this._return(address);
+    });
   }
 
   class_(name, parent, block) {
@@ -230,13 +396,13 @@ class Builder extends Base {
     this._line(
       'func new' +
         cls.name +
-        '(text string, start int, elements []TreeNode) TreeNode {'
+        '(text string, span Span, elements []TreeNode) TreeNode {'
     );
     this._indent(() => {
       this._line('node := &' + cls.name + '{');
       this._indent(() => {
         this._line(
-          'BaseNode: BaseNode{text: text, offset: start, children: elements},'
+          'BaseNode: BaseNode{text: text, span: span, children: elements},'
         );
       });
       this._line('}');
@@ -276,39 +442,85 @@ class Builder extends Base {
     this._line('}');
   }
 
-  cache_(name, block) {
+  cache_(name, block, memoized) {
+    // Parsers with limits, or run by ParseContext, check them as each rule
+    // starts, and count how deeply rules are nested.
+    this._line('if p.guarded && p.enter() {');
+    this._indent(() => {
+      this._return('nil');
+    });
+    this._line('}');
     let vars = this.localVars_({
       address: this.nullNode_(),
       index: this.offset_(),
     });
     let address = vars.address;
     let start = vars.index;
-    let cacheVar = this.localVar_(
-      'cache',
-      'p.cache[' + this._quote(name) + ']'
-    );
+    let silent = this.localVar_('silent', 'p.silent');
+    let rule = this._ruleConst(name);
+    this._memoized.set(name, memoized !== false);
 
-    this.if_(cacheVar + ' == nil', () => {
-      this.assign_(cacheVar, 'make(map[int]cacheEntry)');
-      this.assign_('p.cache[' + this._quote(name) + ']', cacheVar);
-    });
-
-    this._line('if entry, ok := ' + cacheVar + '[' + start + ']; ok {');
+    this._line(
+      'if entry, ok := p.cache.get(' +
+        rule +
+        ', ' +
+        start +
+        ', ' +
+        silent +
+        ' > 0); ok {'
+    );
     this._indent(() => {
       this.assign_('p.offset', 'entry.offset');
+      this._leave();
       this._return('entry.node');
     });
     this._line('}');
 
     block(address);
 
-    this.assign_(
-      cacheVar + '[' + start + ']',
-      'cacheEntry{node: ' + address + ', offset: p.offset}'
-    );
+    // A result found while failures are not being recorded is marked, so
+    // that it is only reused where they are not recorded either. One found
+    // as the parse unwinds after it stopped is not stored at all, since a
+    // lookahead that a cut failed in lets the parse carry on after that.
+    this.if_('p.stopErr == nil', () => {
+      this._line(
+        'p.cache.put(' +
+          rule +
+          ', ' +
+          start +
+          ', ' +
+          address +
+          ', p.offset, ' +
+          silent +
+          ' > 0)'
+      );
+    });
+    this._leave();
     this._return(address);
   }
 
+  _leave() {
+    this.if_('p.guarded', () => {
+      this._line('p.depth--');
+    });
+  }
+
+  // Rule IDs index the memo table, and are exported for ParseRule. They are
+  // derived from the rule name, with a number added if that would collide
+  // with an earlier rule once converted to PascalCase. Rules like `__` that
+  // have no letters are usually internal whitespace rules, so they are left
+  // unexported and named after their ID.
+  _ruleConst(name) {
+    if (!this._ruleConsts.has(name)) {
+      let base = toPascalCase(name);
+      let ident = base ? 'Rule' + base : 'rule' + this._ruleConsts.size;
+      let taken = new Set(this._ruleConsts.values());
+      for (let n = 2; taken.has(ident); n++) ident = 'Rule' + base + n;
+      this._ruleConsts.set(name, ident);
+    }
+    return this._ruleConsts.get(name);
+  }
+
   localVars_(vars) {
     let names = {};
     for (let key in vars) names[key] = this.localVar_(key, vars[key]);
@@ -331,17 +543,10 @@ class Builder extends Base {
     return varName;
   }
 
+  // Terminals test the input in place, so chunks only carry what the match
+  // methods learn about the terminal's end.
   chunk_(length) {
-    let vars = this.localVars_({
-      chunk: this._emptyString(),
-      max: this.offset_() + ' + ' + length,
-    });
-    let chunk = vars.chunk;
-    let max = vars.max;
-    this.if_(max + ' <= len(p.input)', () => {
-      this.assign_(chunk, 'string(p.input[p.offset:' + max + '])');
-    });
-    return chunk;
+    return { length };
   }
 
   syntaxNode_(address, start, end, elements, action, nodeClass) {
@@ -362,9 +567,11 @@ class Builder extends Base {
       });
       this._line('}');
       this._line(
-        'node, err := p.actions.' +
+        'node, err := p.call' +
           methodName +
-          '(p.inputString, ' +
+          '(' +
+          this._ruleConst(this._ruleName) +
+          ', ' +
           start +
           ', ' +
           end +
@@ -372,12 +579,23 @@ class Builder extends Base {
           elementsExpr +
           ')'
       );
+      this._calledActions.set(methodName, action);
       this._line('if err != nil {');
       this._indent(() => {
         this.assign_('p.actionErr', 'err');
         this._return('nil');
       });
       this._line('}');
+      this._usesActions = true;
+      this._line(
+        'p.setActionSpan(node, ' +
+          start +
+          ', ' +
+          end +
+          ', ' +
+          elementsExpr +
+          ')'
+      );
       this.assign_(address, 'node');
     } else if (nodeClass) {
       this.assign_(
@@ -386,9 +604,11 @@ class Builder extends Base {
           nodeClass +
           '(' +
           textExpr +
-          ', ' +
+          ', p.offsets.span(' +
           start +
           ', ' +
+          end +
+          '), ' +
           elementsExpr +
           ')'
       );
@@ -397,9 +617,11 @@ class Builder extends Base {
         address,
         '&BaseNode{text: ' +
           textExpr +
-          ', offset: ' +
+          ', span: p.offsets.span(' +
           start +
-          ', children: ' +
+          ', ' +
+          end +
+          '), children: ' +
           elementsExpr +
           '}'
       );
@@ -423,29 +645,121 @@ class Builder extends Base {
     this._usesExtensions = true;
     this.assign_(
       address,
-      'p.extendNode(' + address + ', ' + this._quote(nodeType) + ')'
+      'p.extendNode(' +
+        address +
+        ', ' +
+        this._quote(nodeType) +
+        ', ' +
+        this._ruleConst(this._ruleName) +
+        ')'
     );
   }
 
   failure_(address, expected) {
     let rule = this._grammarName + '::' + this._ruleName;
     this.assign_(address, this.nullNode_());
-    this.if_('p.offset > p.failure.offset', () => {
-      this.assign_('p.failure.offset', 'p.offset');
-      this.assign_('p.failure.expected', 'nil');
-    });
-    this.if_('p.offset == p.failure.offset', () => {
+    this.if_('p.offset >= p.failure.offset && p.silent == 0', () => {
+      this.if_('p.offset > p.failure.offset', () => {
+        this.assign_('p.failure.offset', 'p.offset');
+        this.assign_('p.failure.expected', 'p.failure.expected[:0]');
+      });
       this.assign_(
         'p.failure.expected',
-        'append(p.failure.expected, expectation{rule: ' +
+        'append(p.failure.expected, Expectation{Rule: ' +
           this._quote(rule) +
-          ', expected: ' +
+          ', Expected: ' +
           this._quote(expected) +
           '})'
       );
     });
   }
 
+  // The failures inside a named expression are not recorded, unless it
+  // passes a cut; if it fails, what it was named is recorded in their place,
+  // at its start.
+  expected_(address, text, custom, block) {
+    let rule = this._grammarName + '::' + this._ruleName;
+    let startOffset = this.localVar_('index', this.offset_());
+    let silent = this.localVar_('silent', 'p.silent');
+    this._line('p.silent++');
+    block();
+    this.assign_('p.silent', silent);
+    this.unlessNode_(address, () => {
+      this._line(
+        'p.expect(' +
+          startOffset +
+          ', Expectation{Rule: ' +
+          this._quote(rule) +
+          ', Expected: ' +
+          this._quote(text) +
+          (custom ? ', Custom: true' : '') +
+          '})'
+      );
+    });
+  }
+
+  // Failures inside a lookahead are not recorded, since what it looks for
+  // is not the next thing the input should contain. If it fails, what it
+  // looked for is recorded at its start instead, as unexpected for a
+  // negative lookahead.
+  lookahead_(address, startOffset, positive, text, block) {
+    let rule = this._grammarName + '::' + this._ruleName;
+    let silent = this.localVar_('silent', 'p.silent');
+    this._line('p.silent++');
+    this._line('p.lookahead++');
+    block();
+    this._line('p.lookahead--');
+    this.assign_('p.silent', silent);
+    let branch = positive ? 'unlessNode_' : 'ifNode_';
+    this[branch](address, () => {
+      this._line(
+        'p.expect(' +
+          startOffset +
+          ', Expectation{Rule: ' +
+          this._quote(rule) +
+          ', Expected: ' +
+          this._quote(text) +
+          (positive ? '' : ', Unexpected: true') +
+          '})'
+      );
+    });
+  }
+
+  // An expression that may reach a cut pushes a point or barrier while it
+  // runs, and pops it by restoring the length of the stack, which also pops
+  // anything a rule left behind by returning early.
+  backtrack_(block) {
+    this._pushPoint('backtrackPoint{offset: p.offset}', block);
+  }
+
+  barrier_(block) {
+    this._pushPoint('backtrackPoint{barrier: true}', block);
+  }
+
+  // A cut that fails inside a lookahead stops the parse until it gets back
+  // here, where the lookahead's expression fails instead.
+  lookaheadCut_(address, block) {
+    block();
+    this.if_('p.cutInLookahead()', () => {
+      this.assign_(address, this.nullNode_());
+    });
+  }
+
+  _pushPoint(point, block) {
+    let depth = this.localVar_('points', 'len(p.points)');
+    this._line('p.points = append(p.points, ' + point + ')');
+    block();
+    this.assign_('p.points', 'p.points[:' + depth + ']');
+  }
+
+  cut_() {
+    this._line('p.cut()');
+  }
+
+  cutFailure_() {
+    this._line('p.cutFailed()');
+  }
+
   jump_(address, rule) {
     this.assign_(address, 'p._read_' + rule + '()');
   }
@@ -478,21 +792,75 @@ class Builder extends Base {
     );
   }
 
-  stringMatch_(expression, string) {
-    return expression + ' == ' + this._quote(string);
+  stringMatch_(chunk, string) {
+    chunk.bytes = Buffer.byteLength(string);
+    if (chunk.bytes === 1) {
+      return 'p.avail(1) && p.input[p.offset] == ' + runeLiteral(string);
+    }
+    return (
+      'p.avail(' +
+      chunk.bytes +
+      ') && p.input[p.offset:p.offset+' +
+      chunk.bytes +
+      '] == ' +
+      this._quote(string)
+    );
+  }
+
+  // A case-insensitive match can be longer in bytes than the literal, but
+  // each of its runes is at most utf8.UTFMax bytes long.
+  stringMatchCI_(chunk, string) {
+    this._useTemplate('literal.go');
+    let maxBytes = Array.from(string).length * 4;
+    chunk.end = this.localVar_(
+      'end',
+      'matchLiteralFold(p.peek(' +
+        maxBytes +
+        '), p.offset, ' +
+        this._quote(string) +
+        ')'
+    );
+    return chunk.end + ' >= 0';
   }
 
-  stringMatchCI_(expression, string) {
-    this._parserImports.add('strings');
-    return 'strings.EqualFold(' + expression + ', ' + this._quote(string) + ')';
+  // Offsets are byte offsets into p.input, so terminal ends come from the
+  // byte length of a literal, or the end found by matching the chunk.
+  terminalEnd_(offset, length, chunk) {
+    if (!chunk) {
+      return offset + ' + runeWidth(p.peekRune(), ' + offset + ')';
+    }
+    if (chunk.end) return chunk.end;
+    return offset + ' + ' + chunk.bytes;
   }
 
-  regexMatch_(regex, string) {
+  // Character classes match a single rune whose width is only known once it
+  // is decoded, so the match leaves the end offset in chunk.end.
+  regexMatch_(regex, chunk) {
+    if (regex.startsWith('charClass')) {
+      chunk.end = this.localVar_('end', regex + '.match(p.peekRune(), p.offset)');
+      return chunk.end + ' >= 0';
+    }
     this._parserImports.add('regexp');
-    return regex + '.MatchString(' + string + ')';
+    chunk.end = this.localVar_('end', '-1');
+    this.if_(
+      'loc := ' +
+        regex +
+        '.FindStringIndex(p.peekRune()[p.offset:]); loc != nil',
+      () => {
+        this.assign_(chunk.end, 'p.offset + loc[1]');
+      }
+    );
+    return chunk.end + ' >= 0';
   }
 
   compileRegex_(charClass, name) {
+    let source = charClass.regex.source.replace(/^\^/, '');
+    let ranges = parseCharClass(source);
+    if (ranges) {
+      charClass.constName = this._compileCharClass(source, ranges, name);
+      return;
+    }
+
     let pattern = charClass.regex.source;
     // Use raw string literal (backticks) for regex patterns to avoid double escaping
     // However, if the pattern itself contains backticks, use quoted strings
@@ -507,6 +875,63 @@ class Builder extends Base {
     this._parserImports.add('regexp');
   }
 
+  // Writes a runtime support file the first time generated code needs it.
+  _useTemplate(file) {
+    if (this._buffers.has(join(this._outputPath, file))) return;
+    this._writeTemplate(file, { name: this._packageName });
+  }
+
+  // Renders templates/go/<file>.tpl into its own file. This may happen while
+  // a rule is being written, so the file is written at the top level rather
+  // than the rule's indentation, and the rule's buffer is restored after.
+  _writeTemplate(file, args) {
+    let current = this._currentBuffer;
+    let indentLevel = this._indentLevel;
+    this._currentBuffer = join(this._outputPath, file);
+    this._indentLevel = 0;
+    this._buffers.set(this._currentBuffer, '');
+    this._template('go', file + '.tpl', args);
+    this._currentBuffer = current;
+    this._indentLevel = indentLevel;
+  }
+
+  // Emits a charClass table: a bitmap for ASCII characters and a list of
+  // range bounds for everything else. The charClass type itself lives in
+  // charclass.go, which is only generated for grammars that need it.
+  _compileCharClass(source, ranges, name) {
+    if (this._charClasses.has(source)) return this._charClasses.get(source);
+
+    let varName = 'charClass' + (this._charClasses.size + 1);
+    let ascii = [0n, 0n];
+    let wide = [];
+
+    for (let [lo, hi] of ranges) {
+      for (let c = lo; c <= Math.min(hi, 0x7f); c++) {
+        ascii[c >> 6] |= 1n << BigInt(c & 63);
+      }
+      if (hi >= 0x80) wide.push(Math.max(lo, 0x80), hi);
+    }
+
+    this._useTemplate('charclass.go');
+    this._charClasses.set(source, varName);
+
+    this._line('// ' + source);
+    this._line('var ' + varName + ' = charClass{');
+    this._indent(() => {
+      this._line(
+        'ascii: [2]uint64{' +
+          ascii.map((word) => '0x' + word.toString(16)).join(', ') +
+          '},'
+      );
+      if (wide.length > 0) {
+        this._line('ranges: []rune{' + wide.map(hex).join(', ') + '},');
+      }
+    });
+    this._line('}');
+    this._newline();
+    return varName;
+  }
+
   arrayLookup_(expression, offset) {
     return expression + '[' + offset + ']';
   }
@@ -520,7 +945,7 @@ class Builder extends Base {
   }
 
   hasChars_() {
-    return 'p.offset < len(p.input)';
+    return 'p.avail(1)';
   }
 
   nullNode_() {
@@ -536,10 +961,6 @@ class Builder extends Base {
     return 'nil';
   }
 
-  _emptyString() {
-    return '""';
-  }
-
   null_() {
     return 'nil';
   }
@@ -550,18 +971,94 @@ class Builder extends Base {
 
   _writeParserHelpers(root) {
     this._newline();
+    this._line('// Rule identifies one of the rules in the grammar.');
+    this._line('type Rule int');
+    this._newline();
+    this._line('const (');
+    this._indent(() => {
+      let first = true;
+      for (let ident of this._ruleConsts.values()) {
+        this._line(first ? ident + ' Rule = iota' : ident);
+        first = false;
+      }
+      this._line('numRules = iota');
+    });
+    this._line(')');
+    this._newline();
+
+    this._line('const minReadSize = 4096');
+    this._newline();
+
+    this._line('const seenAhead = 16');
+    this._newline();
+
+    this._line('var ruleNames = [numRules]string{');
+    this._indent(() => {
+      for (let name of this._ruleConsts.keys()) {
+        this._line(this._quote(name) + ',');
+      }
+    });
+    this._line('}');
+    this._newline();
+
+    this._line('// ruleReaders holds the method that matches each rule.');
+    this._line(
+      'var ruleReaders = [numRules]func(*' + this._structName + ') TreeNode{'
+    );
+    this._indent(() => {
+      for (let name of this._ruleConsts.keys()) {
+        this._line('(*' + this._structName + ')._read_' + name + ',');
+      }
+    });
+    this._line('}');
+    this._newline();
+
+    this._line('// String returns the name of the rule in the grammar.');
+    this._line('func (r Rule) String() string {');
+    this._indent(() => {
+      this._line('if r < 0 || r >= numRules {');
+      this._indent(() => {
+        this._line('return fmt.Sprintf("Rule(%d)", int(r))');
+      });
+      this._line('}');
+      this._line('return ruleNames[r]');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line('var memoDefaults = [numRules]bool{');
+    this._indent(() => {
+      for (let memoized of this._memoized.values()) {
+        this._line(memoized + ',');
+      }
+    });
+    this._line('}');
+    this._newline();
+
     this._line(
-      'func New(input string, actions Actions) *' + this._structName + ' {'
+      'func New(input string, actions Actions, opts ...Option) *' +
+        this._structName +
+        ' {'
     );
     this._indent(() => {
-      this._line('return &' + this._structName + '{');
+      this._line('p := &' + this._structName + '{');
       this._indent(() => {
-        this._line('input: []rune(input),');
-        this._line('inputString: input,');
+        this._line('input: input,');
         this._line('actions: actions,');
-        this._line('cache: make(map[string]map[int]cacheEntry),');
+        this._line(
+          'failure: failureState{expected: make([]Expectation, 0, 8)},'
+        );
       });
       this._line('}');
+      this._line('for _, opt := range opts {');
+      this._indent(() => {
+        this._line('opt(&p.opts)');
+      });
+      this._line('}');
+      this._line('p.offsets = newOffsetIndex(input, p.opts.byteOffsets)');
+      this._line('p.cache = newMemoTable(&p.opts)');
+      this._line('p.guarded = p.opts.limits != (Limits{})');
+      this._line('return p');
     });
     this._line('}');
     this._newline();
@@ -581,10 +1078,10 @@ class Builder extends Base {
     this._newline();
 
     this._line(
-      'func Parse(input string, actions Actions, types map[string]NodeExtender) (TreeNode, error) {'
+      'func Parse(input string, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {'
     );
     this._indent(() => {
-      this._line('parser := New(input, actions)');
+      this._line('parser := New(input, actions, opts...)');
       this._line('if types != nil {');
       this._indent(() => {
         this._line('parser.types = types');
@@ -596,97 +1093,474 @@ class Builder extends Base {
     this._newline();
 
     this._line(
-      'func (p *' + this._structName + ') Parse() (TreeNode, error) {'
+      '// ParseBytes parses a UTF-8 encoded byte slice. The input is copied once,'
+    );
+    this._line(
+      '// so the slice may be reused after ParseBytes returns; the text of the'
+    );
+    this._line('// returned nodes refers to the copy.');
+    this._line(
+      'func ParseBytes(input []byte, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {'
     );
     this._indent(() => {
-      this._line('node := p._read_' + root + '()');
-      this._line('if p.actionErr != nil {');
-      this._indent(() => {
-        this._line('return nil, p.actionErr');
-      });
-      this._line('}');
-      this._line('if node != nil && p.offset == len(p.input) {');
-      this._indent(() => {
-        this._line('return node, nil');
-      });
-      this._line('}');
-      this._line('if len(p.failure.expected) == 0 {');
-      this._indent(() => {
-        this._line('p.failure.offset = p.offset');
-        this._line(
-          'p.failure.expected = append(p.failure.expected, expectation{rule: ' +
-            this._quote(this._grammarName) +
-            ', expected: "<EOF>"})'
-        );
-      });
-      this._line('}');
-      this._line('return nil, p.newParseError()');
+      this._line('return Parse(string(input), actions, types, opts...)');
     });
     this._line('}');
     this._newline();
 
-    this._line('func (p *' + this._structName + ') newParseError() error {');
+    this._line(
+      '// NewReader returns a parser that reads its input from r. Input is read'
+    );
+    this._line(
+      '// as the parser needs it, and read errors are returned by Parse in place'
+    );
+    this._line('// of a *ParseError.');
+    this._line(
+      'func NewReader(r io.Reader, actions Actions, opts ...Option) *' +
+        this._structName +
+        ' {'
+    );
     this._indent(() => {
-      this._line('line, column := 1, 1');
-      this._line('for i, r := range p.input {');
-      this._indent(() => {
-        this._line('if i >= p.failure.offset {');
-        this._indent(() => {
-          this._line('break');
-        });
-        this._line('}');
-        this._line("if r == '\\n' {");
-        this._indent(() => {
-          this._line('line++');
-          this._line('column = 1');
-        });
-        this._line('} else {');
-        this._indent(() => {
-          this._line('column++');
-        });
-        this._line('}');
+      this._line('p := New("", actions, opts...)');
+      this._line('p.reader = r');
+      this._line('p.open = true');
+      this._line('return p');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// ParseReader parses UTF-8 encoded input read from r. See NewReader.'
+    );
+    this._line(
+      'func ParseReader(r io.Reader, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {'
+    );
+    this._indent(() => {
+      this._line('parser := NewReader(r, actions, opts...)');
+      this._line('if types != nil {');
+      this._indent(() => {
+        this._line('parser.types = types');
+      });
+      this._line('}');
+      this._line('return parser.Parse()');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// ParseRule parses input starting from rule, rather than from the first'
+    );
+    this._line('// rule in the grammar.');
+    this._line(
+      'func ParseRule(rule Rule, input string, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, error) {'
+    );
+    this._indent(() => {
+      this._line('parser := New(input, actions, opts...)');
+      this._line('if types != nil {');
+      this._indent(() => {
+        this._line('parser.types = types');
+      });
+      this._line('}');
+      this._line('return parser.ParseRule(rule)');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      'func (p *' + this._structName + ') Parse() (TreeNode, error) {'
+    );
+    this._indent(() => {
+      this._line('return p.parseRule(' + this._ruleConst(root) + ')');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// ParseRule is like Parse, but starts from rule. It may be used with a'
+    );
+    this._line('// parser created by New or NewReader.');
+    this._line(
+      'func (p *' +
+        this._structName +
+        ') ParseRule(rule Rule) (TreeNode, error) {'
+    );
+    this._indent(() => {
+      this._line('if p.stream != nil {');
+      this._indent(() => {
+        this._line(
+          'return nil, fmt.Errorf("ParseRule cannot be used with a parser created by NewStream")'
+        );
+      });
+      this._line('}');
+      this._line('if rule < 0 || rule >= numRules {');
+      this._indent(() => {
+        this._line('return nil, fmt.Errorf("unknown rule %d", int(rule))');
+      });
+      this._line('}');
+      this._line('return p.parseRule(rule)');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// parseRule parses the whole input from rule. Each parse starts afresh,'
+    );
+    this._line(
+      '// except the passes of a parser created by NewStream, which carry on'
+    );
+    this._line('// from the state resume restores.');
+    this._line(
+      'func (p *' +
+        this._structName +
+        ') parseRule(rule Rule) (TreeNode, error) {'
+    );
+    this._indent(() => {
+      this._line('if p.stream == nil {');
+      this._indent(() => {
+        this._line('p.startOver()');
+      });
+      this._line('}');
+      this._line('return p.parseInput(rule)');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      'func (p *' +
+        this._structName +
+        ') parseInput(rule Rule) (TreeNode, error) {'
+    );
+    this._indent(() => {
+      this._line('if err := p.begin(); err != nil {');
+      this._indent(() => {
+        this._line('return nil, err');
+      });
+      this._line('}');
+      this._line('p.cache.reserve(len(p.input))');
+      this._line('node := ruleReaders[rule](p)');
+      this._line('if err := p.finish(rule, node != nil, false); err != nil {');
+      this._indent(() => {
+        this._line('return nil, err');
+      });
+      this._line('}');
+      this._line('return node, nil');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// finish returns the error for a parse from start that matched if matched,'
+    );
+    this._line(
+      '// or nil if it succeeded. A prefix parse succeeds if start matched; any'
+    );
+    this._line('// other parse must also have reached the end of the input.');
+    this._line(
+      'func (p *' +
+        this._structName +
+        ') finish(start Rule, matched, prefix bool) error {'
+    );
+    this._indent(() => {
+      this._line('if p.stopErr != nil {');
+      this._indent(() => {
+        this._line('return p.stopErr');
+      });
+      this._line('}');
+      this._line('complete := matched && (prefix || !p.avail(1))');
+      this._line('if p.cache.truncated {');
+      this._indent(() => {
+        this._line('return ErrIncomplete');
+      });
+      this._line('}');
+      this._line('if p.readErr != nil {');
+      this._indent(() => {
+        this._line('return p.readErr');
+      });
+      this._line('}');
+      this._line('if p.actionErr != nil {');
+      this._indent(() => {
+        this._line('return p.actionErr');
+      });
+      this._line('}');
+      this._line('if complete {');
+      this._indent(() => {
+        this._line('return nil');
+      });
+      this._line('}');
+      this._line('if matched && p.failure.offset < p.offset {');
+      this._indent(() => {
+        this._line(
+          '// The failures before the end of the match were all recovered from,'
+        );
+        this._line('// so the error is the input left over.');
+        this._line('p.failure.offset = p.offset');
+        this._line('p.failure.expected = p.failure.expected[:0]');
+      });
+      this._line('}');
+      this._line('if len(p.failure.expected) == 0 {');
+      this._indent(() => {
+        this._line('rule := ' + this._quote(this._grammarName));
+        this._line('if start != ' + this._ruleConst(root) + ' {');
+        this._indent(() => {
+          this._line('rule += "::" + ruleNames[start]');
+        });
+        this._line('}');
+        this._line('p.failure.offset = p.offset');
+        this._line(
+          'p.failure.expected = append(p.failure.expected, Expectation{Rule: rule, Expected: "<EOF>"})'
+        );
+      });
+      this._line('}');
+      this._line('return p.newParseError(matched)');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// newParseError describes the furthest failure. stopped reports that the'
+    );
+    this._line(
+      '// root rule matched, and the parse only failed because input was left over.'
+    );
+    this._line(
+      'func (p *' + this._structName + ') newParseError(stopped bool) error {'
+    );
+    this._indent(() => {
+      this._line('pos := p.lineIndex().position(p.failure.offset)');
+      this._line('status := "parse error"');
+      this._line('if stopped {');
+      this._indent(() => {
+        this._line('status = "parse stopped early"');
       });
       this._line('}');
+      this._line('expected := sortExpectations(p.failure.expected, pos.Offset)');
       this._line(
-        'message := fmt.Sprintf("parse error at line %d, column %d", line, column)'
+        'message := fmt.Sprintf("%s at line %d, column %d", status, pos.Line, pos.Column)'
       );
-      this._line('if len(p.failure.expected) > 0 {');
+      this._line('if len(expected) > 0 {');
+      this._indent(() => {
+        this._line('message += ": " + describeExpected(expected)');
+      });
+      this._line('}');
+      this._line('if p.open && p.stream == nil {');
+      this._indent(() => {
+        this._line(
+          '// Read far enough past the failure to describe what is there.'
+        );
+        this._line('p.fill(p.failure.offset + maxFoundRunes*utf8.UTFMax)');
+      });
+      this._line('}');
+      this._line('found := describeFound(p.input[p.failure.offset:])');
+      this._line('message += " but found " + found');
+      this._line('kind, end := UnexpectedInput, 0');
+      this._line('if stopped {');
+      this._indent(() => {
+        this._line('kind, end = TrailingInput, p.offsets.convert(p.offset)');
+      });
+      this._line('}');
+      this._line('if p.failure.offset == len(p.input) {');
+      this._indent(() => {
+        this._line('kind = UnexpectedEOF');
+      });
+      this._line('}');
+      this._line('return &ParseError{');
       this._indent(() => {
-        this._line('message += ": expected "');
-        this._line('for i, exp := range p.failure.expected {');
+        this._line('Input: p.input,');
+        this._line('Offset: pos.Offset,');
+        this._line('Line: pos.Line,');
+        this._line('Column: pos.Column,');
+        this._line('UTF16Column: pos.UTF16Column,');
+        this._line('Expected: expected,');
+        this._line('Found: found,');
+        this._line('Message: message,');
+        this._line('Kind: kind,');
+        this._line('End: end,');
+      });
+      this._line('}');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// Position returns the line and column of offset, which is in the same'
+    );
+    this._line(
+      '// units as node offsets. The line index is built on the first call.'
+    );
+    this._line(
+      'func (p *' + this._structName + ') Position(offset int) Position {'
+    );
+    this._indent(() => {
+      this._line('return p.lineIndex().Position(offset)');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      'func (p *' + this._structName + ') lineIndex() *LineIndex {'
+    );
+    this._indent(() => {
+      this._line('if p.lines == nil {');
+      this._indent(() => {
+        this._line('p.lines = newLineIndex(&p.offsets)');
+      });
+      this._line('}');
+      this._line('return p.lines');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// peek returns the input, first making n bytes at the current offset'
+    );
+    this._line('// available if it can.');
+    this._line(
+      'func (p *' + this._structName + ') peek(n int) string {'
+    );
+    this._indent(() => {
+      this._line('if p.offset+n > p.seen {');
+      this._indent(() => {
+        this._line('p.fill(p.offset + n)');
+      });
+      this._line('}');
+      this._line('return p.input');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// avail reports whether n bytes of input are available at the current'
+    );
+    this._line('// offset.');
+    this._line(
+      'func (p *' + this._structName + ') avail(n int) bool {'
+    );
+    this._indent(() => {
+      this._line('return p.offset+n <= p.seen || p.fill(p.offset+n)');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// peekRune is like peek, but makes available only the bytes of the rune'
+    );
+    this._line(
+      '// at the current offset, so that a parser fed by Feed does not wait for'
+    );
+    this._line('// input it does not need.');
+    this._line(
+      'func (p *' + this._structName + ') peekRune() string {'
+    );
+    this._indent(() => {
+      this._line('if p.offset+utf8.UTFMax > p.seen {');
+      this._indent(() => {
+        this._line('p.fillRune()');
+      });
+      this._line('}');
+      this._line('return p.input');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line('func (p *' + this._structName + ') fillRune() {');
+    this._indent(() => {
+      this._line('if p.avail(1) && p.input[p.offset] >= utf8.RuneSelf {');
+      this._indent(() => {
+        this._line('p.avail(leadWidth(p.input[p.offset]))');
+      });
+      this._line('}');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// fill makes the input before want available, first reading more if it'
+    );
+    this._line(
+      '// may follow, and reports whether it is there. Terminals only call fill'
+    );
+    this._line(
+      '// once they look past p.seen, which it moves seenAhead bytes beyond want,'
+    );
+    this._line(
+      '// so p.cache.reach can record how far the parse has looked without'
+    );
+    this._line('// slowing down the rest.');
+    this._line(
+      'func (p *' + this._structName + ') fill(want int) bool {'
+    );
+    this._indent(() => {
+      this._line('if want > len(p.input) && p.open {');
+      this._indent(() => {
+        this._line('p.read(want)');
+      });
+      this._line('}');
+      this._line('p.seen = min(want+seenAhead, len(p.input))');
+      this._line('p.cache.reach = max(p.cache.reach, p.seen, want)');
+      this._line('return want <= len(p.input)');
+    });
+    this._line('}');
+    this._newline();
+
+    this._line(
+      '// read reads until the input holds want bytes or the reader is exhausted.'
+    );
+    this._line(
+      '// Each read at least doubles the input, so that copying the buffer into'
+    );
+    this._line(
+      '// p.input costs linear time overall. A parser created by NewStream gets'
+    );
+    this._line('// its input from Feed instead.');
+    this._line(
+      'func (p *' + this._structName + ') read(want int) {'
+    );
+    this._indent(() => {
+      this._line('if p.stream != nil {');
+      this._indent(() => {
+        this._line('p.more(want)');
+        this._line('return');
+      });
+      this._line('}');
+      this._line('target := max(want, 2*len(p.buf), minReadSize)');
+      this._line('if limit := p.opts.limits.MaxInputSize; limit > 0 {');
+      this._indent(() => {
+        this._line(
+          '// One byte past the limit is enough to tell the input is too long.'
+        );
+        this._line('target = min(target, limit+1)');
+      });
+      this._line('}');
+      this._line('for len(p.buf) < target && p.reader != nil {');
+      this._indent(() => {
+        this._line('if len(p.buf) == cap(p.buf) {');
+        this._indent(() => {
+          this._line('p.buf = slices.Grow(p.buf, target-len(p.buf))');
+        });
+        this._line('}');
+        this._line('n, err := p.reader.Read(p.buf[len(p.buf):cap(p.buf)])');
+        this._line('p.buf = p.buf[:len(p.buf)+n]');
+        this._line('if err != nil {');
         this._indent(() => {
-          this._line('if i > 0 {');
+          this._line('if err != io.EOF {');
           this._indent(() => {
-            this._line('if i == len(p.failure.expected)-1 {');
-            this._indent(() => {
-              this._line('message += " or "');
-            });
-            this._line('} else {');
-            this._indent(() => {
-              this._line('message += ", "');
-            });
-            this._line('}');
+            this._line('p.readErr = err');
           });
           this._line('}');
-          this._line(
-            'message += fmt.Sprintf("%s from %s", exp.expected, exp.rule)'
-          );
+          this._line('p.reader = nil');
+          this._line('p.open = false');
         });
         this._line('}');
       });
       this._line('}');
-      this._line('expected := make([]expectation, len(p.failure.expected))');
-      this._line('copy(expected, p.failure.expected)');
-      this._line('return &ParseError{');
+      this._line('if p.inputTooLong(len(p.buf)) {');
       this._indent(() => {
-        this._line('Input: p.inputString,');
-        this._line('Offset: p.failure.offset,');
-        this._line('Line: line,');
-        this._line('Column: column,');
-        this._line('Expected: expected,');
-        this._line('Message: message,');
+        this._line('p.reader = nil');
+        this._line('p.open = false');
       });
       this._line('}');
+      this._line('p.input = string(p.buf)');
+      this._line('p.offsets.extend(p.input, !p.open)');
     });
     this._line('}');
     this._newline();
@@ -698,17 +1572,78 @@ class Builder extends Base {
       this._line('if start < 0 { start = 0 }');
       this._line('if end > len(p.input) { end = len(p.input) }');
       this._line('if start > end { start = end }');
-      this._line('return string(p.input[start:end])');
+      this._line('return p.input[start:end]');
     });
     this._line('}');
     this._newline();
 
+    // Each action is called through a method that recovers panics, since a
+    // deferred call can only recover a panic in the function that made it.
+    for (let [methodName, action] of this._calledActions) {
+      this._line(
+        'func (p *' +
+          this._structName +
+          ') call' +
+          methodName +
+          '(rule Rule, start, end int, elements []TreeNode) (node TreeNode, err error) {'
+      );
+      this._indent(() => {
+        this._line(
+          'start, end = p.offsets.convert(start), p.offsets.convert(end)'
+        );
+        this._line('if !p.opts.panics {');
+        this._indent(() => {
+          this._line(
+            'defer p.recoverAction(' +
+              this._quote(action) +
+              ', false, rule, start, end, &err)'
+          );
+        });
+        this._line('}');
+        this._line(
+          'return p.actions.' + methodName + '(p.input, start, end, elements)'
+        );
+      });
+      this._line('}');
+      this._newline();
+    }
+
+    // Action results that embed BaseNode get the span the action matched,
+    // unless the action set one or returned one of its elements unchanged.
+    if (this._usesActions) {
+      this._line(
+        'func (p *' +
+          this._structName +
+          ') setActionSpan(node TreeNode, start, end int, elements []TreeNode) {'
+      );
+      this._indent(() => {
+        this._line('n, ok := node.(spanDefaulter)');
+        this._line('if !ok {');
+        this._indent(() => {
+          this._line('return');
+        });
+        this._line('}');
+        this._line('for _, element := range elements {');
+        this._indent(() => {
+          this._line('if element == node {');
+          this._indent(() => {
+            this._line('return');
+          });
+          this._line('}');
+        });
+        this._line('}');
+        this._line('n.setDefaultSpan(p.offsets.span(start, end))');
+      });
+      this._line('}');
+      this._newline();
+    }
+
     // Only generate extendNode if the grammar uses type extensions
     if (this._usesExtensions) {
       this._line(
         'func (p *' +
           this._structName +
-          ') extendNode(node TreeNode, name string) TreeNode {'
+          ') extendNode(node TreeNode, name string, rule Rule) TreeNode {'
       );
       this._indent(() => {
         this._line('if node == nil {');
@@ -721,9 +1656,17 @@ class Builder extends Base {
           this._line('return node');
         });
         this._line('}');
-        this._line('if extender, ok := p.types[name]; ok && extender != nil {');
+        this._line('extender, ok := p.types[name]');
+        this._line('if !ok || extender == nil {');
+        this._indent(() => {
+          this._line('return node');
+        });
+        this._line('}');
+        this._line('node, err := p.callExtender(extender, name, rule, node)');
+        this._line('if err != nil {');
         this._indent(() => {
-          this._line('return extender(node)');
+          this.assign_('p.actionErr', 'err');
+          this._return('nil');
         });
         this._line('}');
         this._line('return node');
@@ -733,6 +1676,8 @@ class Builder extends Base {
     }
   }
 
+  // Items is only written for grammars whose root rule repeats an item with
+  // no upper bound, at least min times.
   serialize() {
     let buffers = super.serialize();
     let parserPath = join(this._outputPath, 'parser.go');
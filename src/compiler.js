@@ -5,6 +5,7 @@ const metagrammar = require('./meta_grammar')
 const Grammar      = require('./ast/grammar'),
       Rule         = require('./ast/rule'),
       Choice       = require('./ast/choice'),
+      Cut          = require('./ast/cut'),
       Extension    = require('./ast/extension'),
       Action       = require('./ast/action'),
       Sequence     = require('./ast/sequence'),
@@ -12,6 +13,7 @@ const Grammar      = require('./ast/grammar'),
       Predicate    = require('./ast/predicate'),
       Repeat       = require('./ast/repeat'),
       Maybe        = require('./ast/maybe'),
+      Message      = require('./ast/message'),
       Reference    = require('./ast/reference'),
       String       = require('./ast/string'),
       CharClass    = require('./ast/char_class'),
@@ -23,8 +25,10 @@ const actions = {
     return new Grammar(name.id.text, rules)
   },
 
-  rule (text, a, b, [name, _, body]) {
-    return new Rule(name.text, body)
+  rule (text, a, b, [name, annotations, displayName, _, body]) {
+    annotations = annotations.elements.map((e) => e.rule_annotation.id.text)
+    displayName = displayName.name && displayName.name.value
+    return new Rule(name.text, body, annotations, displayName)
   },
 
   paren_expr (text, a, b, [_, __, expr]) {
@@ -51,6 +55,8 @@ const actions = {
 
   sequence (text, a, b, [first, rest]) {
     let parts = [first].concat(rest.elements.map((e) => e.expr))
+    if (parts.every((part) => part instanceof Cut))
+      throw new Error("A sequence must contain something besides cuts: '" + text.substring(a, b) + "'")
     return new Sequence(parts)
   },
 
@@ -61,9 +67,14 @@ const actions = {
     return new SequencePart(expr, label && label.text, muted)
   },
 
+  cut (text, a, b, elements) {
+    return new Cut()
+  },
+
   predicate (text, a, b, [pred, _, expr]) {
     let polarities = { '&': true, '!': false }
-    return new Predicate(expr, polarities[pred.text])
+    let source = text.substring(a + pred.text.length + _.text.length, b)
+    return new Predicate(expr, polarities[pred.text], source)
   },
 
   repeat (text, a, b, [expr, _, quant]) {
@@ -88,6 +99,10 @@ const actions = {
     return new Maybe(expr)
   },
 
+  message (text, a, b, [expr, _, __, ___, message]) {
+    return new Message(expr, message.value)
+  },
+
   reference (text, a, b, [expr]) {
     return new Reference(expr.text)
   },
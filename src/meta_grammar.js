@@ -46,147 +46,182 @@
   var TreeNode4 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
     this['identifier'] = elements[0];
-    this['assignment'] = elements[1];
-    this['parsing_expression'] = elements[2];
+    this['rule_annotations'] = elements[1];
+    this['assignment'] = elements[3];
+    this['parsing_expression'] = elements[4];
   };
   inherit(TreeNode4, TreeNode);
 
   var TreeNode5 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['identifier'] = elements[0];
+    this['rule_annotation'] = elements[1];
   };
   inherit(TreeNode5, TreeNode);
 
   var TreeNode6 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
+    this['id'] = elements[1];
     this['identifier'] = elements[1];
   };
   inherit(TreeNode6, TreeNode);
 
   var TreeNode7 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['actionable'] = elements[0];
-    this['action_tag'] = elements[2];
+    this['name'] = elements[1];
+    this['literal_string'] = elements[1];
   };
   inherit(TreeNode7, TreeNode);
 
   var TreeNode8 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['actionable'] = elements[2];
+    this['identifier'] = elements[0];
   };
   inherit(TreeNode8, TreeNode);
 
   var TreeNode9 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['id'] = elements[1];
     this['identifier'] = elements[1];
   };
   inherit(TreeNode9, TreeNode);
 
   var TreeNode10 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['typable'] = elements[0];
-    this['type_tag'] = elements[2];
+    this['actionable'] = elements[0];
+    this['action_tag'] = elements[2];
   };
   inherit(TreeNode10, TreeNode);
 
   var TreeNode11 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['id'] = elements[1];
-    this['object_identifier'] = elements[1];
+    this['actionable'] = elements[2];
   };
   inherit(TreeNode11, TreeNode);
 
   var TreeNode12 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['choice_part'] = elements[0];
+    this['id'] = elements[1];
+    this['identifier'] = elements[1];
   };
   inherit(TreeNode12, TreeNode);
 
   var TreeNode13 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['expr'] = elements[3];
-    this['choice_part'] = elements[3];
+    this['typable'] = elements[0];
+    this['type_tag'] = elements[2];
   };
   inherit(TreeNode13, TreeNode);
 
   var TreeNode14 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['sequence_part'] = elements[0];
+    this['id'] = elements[1];
+    this['object_identifier'] = elements[1];
   };
   inherit(TreeNode14, TreeNode);
 
   var TreeNode15 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['expr'] = elements[1];
-    this['sequence_part'] = elements[1];
+    this['choice_part'] = elements[0];
   };
   inherit(TreeNode15, TreeNode);
 
   var TreeNode16 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['sequence_element'] = elements[2];
+    this['expr'] = elements[3];
+    this['choice_part'] = elements[3];
   };
   inherit(TreeNode16, TreeNode);
 
   var TreeNode17 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['id'] = elements[0];
-    this['identifier'] = elements[0];
+    this['sequence_part'] = elements[0];
   };
   inherit(TreeNode17, TreeNode);
 
   var TreeNode18 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['atom'] = elements[0];
-    this['quantifier'] = elements[2];
+    this['expr'] = elements[1];
+    this['sequence_part'] = elements[1];
   };
   inherit(TreeNode18, TreeNode);
 
   var TreeNode19 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['numeric_quantifier'] = elements[2];
+    this['sequence_element'] = elements[2];
   };
   inherit(TreeNode19, TreeNode);
 
   var TreeNode20 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['min'] = elements[0];
-    this['integer'] = elements[0];
-    this['max'] = elements[1];
+    this['id'] = elements[0];
+    this['identifier'] = elements[0];
   };
   inherit(TreeNode20, TreeNode);
 
   var TreeNode21 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['n'] = elements[3];
+    this['atom'] = elements[0];
+    this['quantifier'] = elements[2];
   };
   inherit(TreeNode21, TreeNode);
 
   var TreeNode22 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['parsing_expression'] = elements[2];
+    this['numeric_quantifier'] = elements[2];
   };
   inherit(TreeNode22, TreeNode);
 
   var TreeNode23 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['atom'] = elements[2];
+    this['min'] = elements[0];
+    this['integer'] = elements[0];
+    this['max'] = elements[1];
   };
   inherit(TreeNode23, TreeNode);
 
   var TreeNode24 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['atom'] = elements[0];
+    this['n'] = elements[3];
   };
   inherit(TreeNode24, TreeNode);
 
   var TreeNode25 = function (text, offset, elements) {
     TreeNode.apply(this, arguments);
-    this['identifier'] = elements[0];
+    this['message'] = elements[4];
+    this['literal_string'] = elements[4];
   };
   inherit(TreeNode25, TreeNode);
 
+  var TreeNode26 = function (text, offset, elements) {
+    TreeNode.apply(this, arguments);
+    this['parsing_expression'] = elements[2];
+  };
+  inherit(TreeNode26, TreeNode);
+
+  var TreeNode27 = function (text, offset, elements) {
+    TreeNode.apply(this, arguments);
+    this['atom'] = elements[2];
+  };
+  inherit(TreeNode27, TreeNode);
+
+  var TreeNode28 = function (text, offset, elements) {
+    TreeNode.apply(this, arguments);
+    this['atom'] = elements[0];
+  };
+  inherit(TreeNode28, TreeNode);
+
+  var TreeNode29 = function (text, offset, elements) {
+    TreeNode.apply(this, arguments);
+    this['identifier'] = elements[0];
+  };
+  inherit(TreeNode29, TreeNode);
+
+  var TreeNode30 = function (text, offset, elements) {
+    TreeNode.apply(this, arguments);
+    this['rule_annotations'] = elements[0];
+    this['assignment'] = elements[2];
+  };
+  inherit(TreeNode30, TreeNode);
+
   var FAILURE = {};
 
   var Grammar = {
@@ -429,19 +464,40 @@
         this._offset = cached[1];
         return cached[0];
       }
-      var index1 = this._offset, elements0 = new Array(3);
+      var index1 = this._offset, elements0 = new Array(5);
       var address1 = FAILURE;
       address1 = this._read_identifier();
       if (address1 !== FAILURE) {
         elements0[0] = address1;
         var address2 = FAILURE;
-        address2 = this._read_assignment();
+        address2 = this._read_rule_annotations();
         if (address2 !== FAILURE) {
           elements0[1] = address2;
           var address3 = FAILURE;
-          address3 = this._read_parsing_expression();
+          var index2 = this._offset;
+          address3 = this._read_display_name();
+          if (address3 === FAILURE) {
+            address3 = new TreeNode(this._input.substring(index2, index2), index2, []);
+            this._offset = index2;
+          }
           if (address3 !== FAILURE) {
             elements0[2] = address3;
+            var address4 = FAILURE;
+            address4 = this._read_assignment();
+            if (address4 !== FAILURE) {
+              elements0[3] = address4;
+              var address5 = FAILURE;
+              address5 = this._read_parsing_expression();
+              if (address5 !== FAILURE) {
+                elements0[4] = address5;
+              } else {
+                elements0 = null;
+                this._offset = index1;
+              }
+            } else {
+              elements0 = null;
+              this._offset = index1;
+            }
           } else {
             elements0 = null;
             this._offset = index1;
@@ -464,6 +520,169 @@
       return address0;
     },
 
+    _read_rule_annotations () {
+      var address0 = FAILURE, index0 = this._offset;
+      this._cache._rule_annotations = this._cache._rule_annotations || {};
+      var cached = this._cache._rule_annotations[index0];
+      if (cached) {
+        this._offset = cached[1];
+        return cached[0];
+      }
+      var index1 = this._offset, elements0 = [], address1 = null;
+      while (true) {
+        var index2 = this._offset, elements1 = new Array(2);
+        var address2 = FAILURE;
+        var index3 = this._offset, elements2 = [], address3 = null;
+        while (true) {
+          address3 = this._read__();
+          if (address3 !== FAILURE) {
+            elements2.push(address3);
+          } else {
+            break;
+          }
+        }
+        if (elements2.length >= 1) {
+          address2 = new TreeNode(this._input.substring(index3, this._offset), index3, elements2);
+          this._offset = this._offset;
+        } else {
+          address2 = FAILURE;
+        }
+        if (address2 !== FAILURE) {
+          elements1[0] = address2;
+          var address4 = FAILURE;
+          address4 = this._read_rule_annotation();
+          if (address4 !== FAILURE) {
+            elements1[1] = address4;
+          } else {
+            elements1 = null;
+            this._offset = index2;
+          }
+        } else {
+          elements1 = null;
+          this._offset = index2;
+        }
+        if (elements1 === null) {
+          address1 = FAILURE;
+        } else {
+          address1 = new TreeNode5(this._input.substring(index2, this._offset), index2, elements1);
+          this._offset = this._offset;
+        }
+        if (address1 !== FAILURE) {
+          elements0.push(address1);
+        } else {
+          break;
+        }
+      }
+      if (elements0.length >= 0) {
+        address0 = new TreeNode(this._input.substring(index1, this._offset), index1, elements0);
+        this._offset = this._offset;
+      } else {
+        address0 = FAILURE;
+      }
+      this._cache._rule_annotations[index0] = [address0, this._offset];
+      return address0;
+    },
+
+    _read_rule_annotation () {
+      var address0 = FAILURE, index0 = this._offset;
+      this._cache._rule_annotation = this._cache._rule_annotation || {};
+      var cached = this._cache._rule_annotation[index0];
+      if (cached) {
+        this._offset = cached[1];
+        return cached[0];
+      }
+      var index1 = this._offset, elements0 = new Array(2);
+      var address1 = FAILURE;
+      var chunk0 = null, max0 = this._offset + 1;
+      if (max0 <= this._inputSize) {
+        chunk0 = this._input.substring(this._offset, max0);
+      }
+      if (chunk0 === '@') {
+        address1 = new TreeNode(this._input.substring(this._offset, this._offset + 1), this._offset, []);
+        this._offset = this._offset + 1;
+      } else {
+        address1 = FAILURE;
+        if (this._offset > this._failure) {
+          this._failure = this._offset;
+          this._expected = [];
+        }
+        if (this._offset === this._failure) {
+          this._expected.push(['Canopy.MetaGrammar::rule_annotation', '"@"']);
+        }
+      }
+      if (address1 !== FAILURE) {
+        elements0[0] = address1;
+        var address2 = FAILURE;
+        address2 = this._read_identifier();
+        if (address2 !== FAILURE) {
+          elements0[1] = address2;
+        } else {
+          elements0 = null;
+          this._offset = index1;
+        }
+      } else {
+        elements0 = null;
+        this._offset = index1;
+      }
+      if (elements0 === null) {
+        address0 = FAILURE;
+      } else {
+        address0 = new TreeNode6(this._input.substring(index1, this._offset), index1, elements0);
+        this._offset = this._offset;
+      }
+      this._cache._rule_annotation[index0] = [address0, this._offset];
+      return address0;
+    },
+
+    _read_display_name () {
+      var address0 = FAILURE, index0 = this._offset;
+      this._cache._display_name = this._cache._display_name || {};
+      var cached = this._cache._display_name[index0];
+      if (cached) {
+        this._offset = cached[1];
+        return cached[0];
+      }
+      var index1 = this._offset, elements0 = new Array(2);
+      var address1 = FAILURE;
+      var index2 = this._offset, elements1 = [], address2 = null;
+      while (true) {
+        address2 = this._read__();
+        if (address2 !== FAILURE) {
+          elements1.push(address2);
+        } else {
+          break;
+        }
+      }
+      if (elements1.length >= 1) {
+        address1 = new TreeNode(this._input.substring(index2, this._offset), index2, elements1);
+        this._offset = this._offset;
+      } else {
+        address1 = FAILURE;
+      }
+      if (address1 !== FAILURE) {
+        elements0[0] = address1;
+        var address3 = FAILURE;
+        address3 = this._read_literal_string();
+        if (address3 !== FAILURE) {
+          elements0[1] = address3;
+        } else {
+          elements0 = null;
+          this._offset = index1;
+        }
+      } else {
+        elements0 = null;
+        this._offset = index1;
+      }
+      if (elements0 === null) {
+        address0 = FAILURE;
+      } else {
+        address0 = new TreeNode7(this._input.substring(index1, this._offset), index1, elements0);
+        this._offset = this._offset;
+      }
+      this._cache._display_name[index0] = [address0, this._offset];
+      return address0;
+    },
+
     _read_assignment () {
       var address0 = FAILURE, index0 = this._offset;
       this._cache._assignment = this._cache._assignment || {};
@@ -721,7 +940,7 @@
           if (elements2 === null) {
             address3 = FAILURE;
           } else {
-            address3 = new TreeNode6(this._input.substring(index3, this._offset), index3, elements2);
+            address3 = new TreeNode9(this._input.substring(index3, this._offset), index3, elements2);
             this._offset = this._offset;
           }
           if (address3 !== FAILURE) {
@@ -749,7 +968,7 @@
       if (elements0 === null) {
         address0 = FAILURE;
       } else {
-        address0 = new TreeNode5(this._input.substring(index1, this._offset), index1, elements0);
+        address0 = new TreeNode8(this._input.substring(index1, this._offset), index1, elements0);
         this._offset = this._offset;
       }
       this._cache._object_identifier[index0] = [address0, this._offset];
@@ -977,18 +1196,22 @@
         return cached[0];
       }
       var index1 = this._offset;
-      address0 = this._read_predicated_atom();
+      address0 = this._read_messaged_atom();
       if (address0 === FAILURE) {
         this._offset = index1;
-        address0 = this._read_repeated_atom();
+        address0 = this._read_predicated_atom();
         if (address0 === FAILURE) {
           this._offset = index1;
-          address0 = this._read_maybe_atom();
+          address0 = this._read_repeated_atom();
           if (address0 === FAILURE) {
             this._offset = index1;
-            address0 = this._read_atom();
+            address0 = this._read_maybe_atom();
             if (address0 === FAILURE) {
               this._offset = index1;
+              address0 = this._read_atom();
+              if (address0 === FAILURE) {
+                this._offset = index1;
+              }
             }
           }
         }
@@ -1120,96 +1343,103 @@
       address0 = this._read_sequence();
       if (address0 === FAILURE) {
         this._offset = index1;
-        address0 = this._read_repeated_atom();
+        address0 = this._read_messaged_atom();
         if (address0 === FAILURE) {
           this._offset = index1;
-          address0 = this._read_maybe_atom();
+          address0 = this._read_repeated_atom();
           if (address0 === FAILURE) {
             this._offset = index1;
-            address0 = this._read_terminal();
+            address0 = this._read_maybe_atom();
             if (address0 === FAILURE) {
               this._offset = index1;
-              var index2 = this._offset, elements0 = new Array(5);
-              var address1 = FAILURE;
-              var chunk0 = null, max0 = this._offset + 1;
-              if (max0 <= this._inputSize) {
-                chunk0 = this._input.substring(this._offset, max0);
-              }
-              if (chunk0 === '(') {
-                address1 = new TreeNode(this._input.substring(this._offset, this._offset + 1), this._offset, []);
-                this._offset = this._offset + 1;
-              } else {
-                address1 = FAILURE;
-                if (this._offset > this._failure) {
-                  this._failure = this._offset;
-                  this._expected = [];
-                }
-                if (this._offset === this._failure) {
-                  this._expected.push(['Canopy.MetaGrammar::actionable', '"("']);
-                }
-              }
-              if (address1 !== FAILURE) {
-                elements0[0] = address1;
-                var address2 = FAILURE;
-                var index3 = this._offset, elements1 = [], address3 = null;
-                while (true) {
-                  address3 = this._read__();
-                  if (address3 !== FAILURE) {
-                    elements1.push(address3);
-                  } else {
-                    break;
-                  }
+              address0 = this._read_terminal();
+              if (address0 === FAILURE) {
+                this._offset = index1;
+                var index2 = this._offset, elements0 = new Array(5);
+                var address1 = FAILURE;
+                var chunk0 = null, max0 = this._offset + 1;
+                if (max0 <= this._inputSize) {
+                  chunk0 = this._input.substring(this._offset, max0);
                 }
-                if (elements1.length >= 0) {
-                  address2 = new TreeNode(this._input.substring(index3, this._offset), index3, elements1);
-                  this._offset = this._offset;
+                if (chunk0 === '(') {
+                  address1 = new TreeNode(this._input.substring(this._offset, this._offset + 1), this._offset, []);
+                  this._offset = this._offset + 1;
                 } else {
-                  address2 = FAILURE;
+                  address1 = FAILURE;
+                  if (this._offset > this._failure) {
+                    this._failure = this._offset;
+                    this._expected = [];
+                  }
+                  if (this._offset === this._failure) {
+                    this._expected.push(['Canopy.MetaGrammar::actionable', '"("']);
+                  }
                 }
-                if (address2 !== FAILURE) {
-                  elements0[1] = address2;
-                  var address4 = FAILURE;
-                  address4 = this._read_actionable();
-                  if (address4 !== FAILURE) {
-                    elements0[2] = address4;
-                    var address5 = FAILURE;
-                    var index4 = this._offset, elements2 = [], address6 = null;
-                    while (true) {
-                      address6 = this._read__();
-                      if (address6 !== FAILURE) {
-                        elements2.push(address6);
-                      } else {
-                        break;
-                      }
-                    }
-                    if (elements2.length >= 0) {
-                      address5 = new TreeNode(this._input.substring(index4, this._offset), index4, elements2);
-                      this._offset = this._offset;
+                if (address1 !== FAILURE) {
+                  elements0[0] = address1;
+                  var address2 = FAILURE;
+                  var index3 = this._offset, elements1 = [], address3 = null;
+                  while (true) {
+                    address3 = this._read__();
+                    if (address3 !== FAILURE) {
+                      elements1.push(address3);
                     } else {
-                      address5 = FAILURE;
+                      break;
                     }
-                    if (address5 !== FAILURE) {
-                      elements0[3] = address5;
-                      var address7 = FAILURE;
-                      var chunk1 = null, max1 = this._offset + 1;
-                      if (max1 <= this._inputSize) {
-                        chunk1 = this._input.substring(this._offset, max1);
+                  }
+                  if (elements1.length >= 0) {
+                    address2 = new TreeNode(this._input.substring(index3, this._offset), index3, elements1);
+                    this._offset = this._offset;
+                  } else {
+                    address2 = FAILURE;
+                  }
+                  if (address2 !== FAILURE) {
+                    elements0[1] = address2;
+                    var address4 = FAILURE;
+                    address4 = this._read_actionable();
+                    if (address4 !== FAILURE) {
+                      elements0[2] = address4;
+                      var address5 = FAILURE;
+                      var index4 = this._offset, elements2 = [], address6 = null;
+                      while (true) {
+                        address6 = this._read__();
+                        if (address6 !== FAILURE) {
+                          elements2.push(address6);
+                        } else {
+                          break;
+                        }
                       }
-                      if (chunk1 === ')') {
-                        address7 = new TreeNode(this._input.substring(this._offset, this._offset + 1), this._offset, []);
-                        this._offset = this._offset + 1;
+                      if (elements2.length >= 0) {
+                        address5 = new TreeNode(this._input.substring(index4, this._offset), index4, elements2);
+                        this._offset = this._offset;
                       } else {
-                        address7 = FAILURE;
-                        if (this._offset > this._failure) {
-                          this._failure = this._offset;
-                          this._expected = [];
+                        address5 = FAILURE;
+                      }
+                      if (address5 !== FAILURE) {
+                        elements0[3] = address5;
+                        var address7 = FAILURE;
+                        var chunk1 = null, max1 = this._offset + 1;
+                        if (max1 <= this._inputSize) {
+                          chunk1 = this._input.substring(this._offset, max1);
                         }
-                        if (this._offset === this._failure) {
-                          this._expected.push(['Canopy.MetaGrammar::actionable', '")"']);
+                        if (chunk1 === ')') {
+                          address7 = new TreeNode(this._input.substring(this._offset, this._offset + 1), this._offset, []);
+                          this._offset = this._offset + 1;
+                        } else {
+                          address7 = FAILURE;
+                          if (this._offset > this._failure) {
+                            this._failure = this._offset;
+                            this._expected = [];
+                          }
+                          if (this._offset === this._failure) {
+                            this._expected.push(['Canopy.MetaGrammar::actionable', '")"']);
+                          }
+                        }
+                        if (address7 !== FAILURE) {
+                          elements0[4] = address7;
+                        } else {
+                          elements0 = null;
+                          this._offset = index2;
                         }
-                      }
-                      if (address7 !== FAILURE) {
-                        elements0[4] = address7;
                       } else {
                         elements0 = null;
                         this._offset = index2;
@@ -1226,18 +1456,15 @@
                   elements0 = null;
                   this._offset = index2;
                 }
-              } else {
-                elements0 = null;
-                this._offset = index2;
-              }
-              if (elements0 === null) {
-                address0 = FAILURE;
-              } else {
-                address0 = this._actions.paren_expr(this._input, index2, this._offset, elements0);
-                this._offset = this._offset;
-              }
-              if (address0 === FAILURE) {
-                this._offset = index1;
+                if (elements0 === null) {
+                  address0 = FAILURE;
+                } else {
+                  address0 = this._actions.paren_expr(this._input, index2, this._offset, elements0);
+                  this._offset = this._offset;
+                }
+                if (address0 === FAILURE) {
+                  this._offset = index1;
+                }
               }
             }
           }
@@ -1291,7 +1518,7 @@
       if (elements0 === null) {
         address0 = FAILURE;
       } else {
-        address0 = new TreeNode9(this._input.substring(index1, this._offset), index1, elements0);
+        address0 = new TreeNode12(this._input.substring(index1, this._offset), index1, elements0);
         this._offset = this._offset;
       }
       this._cache._action_tag[index0] = [address0, this._offset];
@@ -1444,7 +1671,7 @@
       if (elements0 === null) {
         address0 = FAILURE;
       } else {
-        address0 = new TreeNode11(this._input.substring(index1, this._offset), index1, elements0);
+        address0 = new TreeNode14(this._input.substring(index1, this._offset), index1, elements0);
         this._offset = this._offset;
       }
       this._cache._type_tag[index0] = [address0, this._offset];
@@ -1547,7 +1774,7 @@
           if (elements2 === null) {
             address3 = FAILURE;
           } else {
-            address3 = new TreeNode13(this._input.substring(index3, this._offset), index3, elements2);
+            address3 = new TreeNode16(this._input.substring(index3, this._offset), index3, elements2);
             this._offset = this._offset;
           }
           if (address3 !== FAILURE) {
@@ -1632,7 +1859,7 @@
           if (elements2 === null) {
             address3 = FAILURE;
           } else {
-            address3 = new TreeNode15(this._input.substring(index3, this._offset), index3, elements2);
+            address3 = new TreeNode18(this._input.substring(index3, this._offset), index3, elements2);
             this._offset = this._offset;
           }
           if (address3 !== FAILURE) {
@@ -1675,48 +1902,85 @@
         this._offset = cached[1];
         return cached[0];
       }
-      var index1 = this._offset, elements0 = new Array(3);
-      var address1 = FAILURE;
-      var index2 = this._offset;
-      address1 = this._read_mute();
-      if (address1 === FAILURE) {
-        address1 = new TreeNode(this._input.substring(index2, index2), index2, []);
-        this._offset = index2;
-      }
-      if (address1 !== FAILURE) {
-        elements0[0] = address1;
-        var address2 = FAILURE;
+      var index1 = this._offset;
+      address0 = this._read_cut();
+      if (address0 === FAILURE) {
+        this._offset = index1;
+        var index2 = this._offset, elements0 = new Array(3);
+        var address1 = FAILURE;
         var index3 = this._offset;
-        address2 = this._read_label();
-        if (address2 === FAILURE) {
-          address2 = new TreeNode(this._input.substring(index3, index3), index3, []);
+        address1 = this._read_mute();
+        if (address1 === FAILURE) {
+          address1 = new TreeNode(this._input.substring(index3, index3), index3, []);
           this._offset = index3;
         }
-        if (address2 !== FAILURE) {
-          elements0[1] = address2;
-          var address3 = FAILURE;
-          address3 = this._read_sequence_element();
-          if (address3 !== FAILURE) {
-            elements0[2] = address3;
+        if (address1 !== FAILURE) {
+          elements0[0] = address1;
+          var address2 = FAILURE;
+          var index4 = this._offset;
+          address2 = this._read_label();
+          if (address2 === FAILURE) {
+            address2 = new TreeNode(this._input.substring(index4, index4), index4, []);
+            this._offset = index4;
+          }
+          if (address2 !== FAILURE) {
+            elements0[1] = address2;
+            var address3 = FAILURE;
+            address3 = this._read_sequence_element();
+            if (address3 !== FAILURE) {
+              elements0[2] = address3;
+            } else {
+              elements0 = null;
+              this._offset = index2;
+            }
           } else {
             elements0 = null;
-            this._offset = index1;
+            this._offset = index2;
           }
         } else {
           elements0 = null;
+          this._offset = index2;
+        }
+        if (elements0 === null) {
+          address0 = FAILURE;
+        } else {
+          address0 = this._actions.sequence_part(this._input, index2, this._offset, elements0);
+          this._offset = this._offset;
+        }
+        if (address0 === FAILURE) {
           this._offset = index1;
         }
-      } else {
-        elements0 = null;
-        this._offset = index1;
       }
-      if (elements0 === null) {
-        address0 = FAILURE;
+      this._cache._sequence_part[index0] = [address0, this._offset];
+      return address0;
+    },
+
+    _read_cut () {
+      var address0 = FAILURE, index0 = this._offset;
+      this._cache._cut = this._cache._cut || {};
+      var cached = this._cache._cut[index0];
+      if (cached) {
+        this._offset = cached[1];
+        return cached[0];
+      }
+      var chunk0 = null, max0 = this._offset + 1;
+      if (max0 <= this._inputSize) {
+        chunk0 = this._input.substring(this._offset, max0);
+      }
+      if (chunk0 === '~') {
+        address0 = this._actions.cut(this._input, this._offset, this._offset + 1, []);
+        this._offset = this._offset + 1;
       } else {
-        address0 = this._actions.sequence_part(this._input, index1, this._offset, elements0);
-        this._offset = this._offset;
+        address0 = FAILURE;
+        if (this._offset > this._failure) {
+          this._failure = this._offset;
+          this._expected = [];
+        }
+        if (this._offset === this._failure) {
+          this._expected.push(['Canopy.MetaGrammar::cut', '"~"']);
+        }
       }
-      this._cache._sequence_part[index0] = [address0, this._offset];
+      this._cache._cut[index0] = [address0, this._offset];
       return address0;
     },
 
@@ -1793,7 +2057,7 @@
       if (elements0 === null) {
         address0 = FAILURE;
       } else {
-        address0 = new TreeNode17(this._input.substring(index1, this._offset), index1, elements0);
+        address0 = new TreeNode20(this._input.substring(index1, this._offset), index1, elements0);
         this._offset = this._offset;
       }
       this._cache._label[index0] = [address0, this._offset];
@@ -2008,7 +2272,7 @@
           if (elements0 === null) {
             address0 = FAILURE;
           } else {
-            address0 = new TreeNode19(this._input.substring(index2, this._offset), index2, elements0);
+            address0 = new TreeNode22(this._input.substring(index2, this._offset), index2, elements0);
             this._offset = this._offset;
           }
           if (address0 === FAILURE) {
@@ -2120,7 +2384,7 @@
         if (elements1 === null) {
           address2 = FAILURE;
         } else {
-          address2 = new TreeNode21(this._input.substring(index3, this._offset), index3, elements1);
+          address2 = new TreeNode24(this._input.substring(index3, this._offset), index3, elements1);
           this._offset = this._offset;
         }
         if (address2 === FAILURE) {
@@ -2140,13 +2404,132 @@
       if (elements0 === null) {
         address0 = FAILURE;
       } else {
-        address0 = new TreeNode20(this._input.substring(index1, this._offset), index1, elements0);
+        address0 = new TreeNode23(this._input.substring(index1, this._offset), index1, elements0);
         this._offset = this._offset;
       }
       this._cache._numeric_quantifier[index0] = [address0, this._offset];
       return address0;
     },
 
+    _read_messaged_atom () {
+      var address0 = FAILURE, index0 = this._offset;
+      this._cache._messaged_atom = this._cache._messaged_atom || {};
+      var cached = this._cache._messaged_atom[index0];
+      if (cached) {
+        this._offset = cached[1];
+        return cached[0];
+      }
+      var index1 = this._offset, elements0 = new Array(5);
+      var address1 = FAILURE;
+      var index2 = this._offset;
+      address1 = this._read_predicated_atom();
+      if (address1 === FAILURE) {
+        this._offset = index2;
+        address1 = this._read_repeated_atom();
+        if (address1 === FAILURE) {
+          this._offset = index2;
+          address1 = this._read_maybe_atom();
+          if (address1 === FAILURE) {
+            this._offset = index2;
+            address1 = this._read_atom();
+            if (address1 === FAILURE) {
+              this._offset = index2;
+            }
+          }
+        }
+      }
+      if (address1 !== FAILURE) {
+        elements0[0] = address1;
+        var address2 = FAILURE;
+        var index3 = this._offset, elements1 = [], address3 = null;
+        while (true) {
+          address3 = this._read__();
+          if (address3 !== FAILURE) {
+            elements1.push(address3);
+          } else {
+            break;
+          }
+        }
+        if (elements1.length >= 0) {
+          address2 = new TreeNode(this._input.substring(index3, this._offset), index3, elements1);
+          this._offset = this._offset;
+        } else {
+          address2 = FAILURE;
+        }
+        if (address2 !== FAILURE) {
+          elements0[1] = address2;
+          var address4 = FAILURE;
+          var chunk0 = null, max0 = this._offset + 1;
+          if (max0 <= this._inputSize) {
+            chunk0 = this._input.substring(this._offset, max0);
+          }
+          if (chunk0 === '^') {
+            address4 = new TreeNode(this._input.substring(this._offset, this._offset + 1), this._offset, []);
+            this._offset = this._offset + 1;
+          } else {
+            address4 = FAILURE;
+            if (this._offset > this._failure) {
+              this._failure = this._offset;
+              this._expected = [];
+            }
+            if (this._offset === this._failure) {
+              this._expected.push(['Canopy.MetaGrammar::messaged_atom', '"^"']);
+            }
+          }
+          if (address4 !== FAILURE) {
+            elements0[2] = address4;
+            var address5 = FAILURE;
+            var index4 = this._offset, elements2 = [], address6 = null;
+            while (true) {
+              address6 = this._read__();
+              if (address6 !== FAILURE) {
+                elements2.push(address6);
+              } else {
+                break;
+              }
+            }
+            if (elements2.length >= 0) {
+              address5 = new TreeNode(this._input.substring(index4, this._offset), index4, elements2);
+              this._offset = this._offset;
+            } else {
+              address5 = FAILURE;
+            }
+            if (address5 !== FAILURE) {
+              elements0[3] = address5;
+              var address7 = FAILURE;
+              address7 = this._read_literal_string();
+              if (address7 !== FAILURE) {
+                elements0[4] = address7;
+              } else {
+                elements0 = null;
+                this._offset = index1;
+              }
+            } else {
+              elements0 = null;
+              this._offset = index1;
+            }
+          } else {
+            elements0 = null;
+            this._offset = index1;
+          }
+        } else {
+          elements0 = null;
+          this._offset = index1;
+        }
+      } else {
+        elements0 = null;
+        this._offset = index1;
+      }
+      if (elements0 === null) {
+        address0 = FAILURE;
+      } else {
+        address0 = this._actions.message(this._input, index1, this._offset, elements0);
+        this._offset = this._offset;
+      }
+      this._cache._messaged_atom[index0] = [address0, this._offset];
+      return address0;
+    },
+
     _read_paren_expression () {
       var address0 = FAILURE, index0 = this._offset;
       this._cache._paren_expression = this._cache._paren_expression || {};
@@ -2451,7 +2834,42 @@
         elements0[0] = address1;
         var address2 = FAILURE;
         var index2 = this._offset;
-        address2 = this._read_assignment();
+        var index3 = this._offset, elements1 = new Array(3);
+        var address3 = FAILURE;
+        address3 = this._read_rule_annotations();
+        if (address3 !== FAILURE) {
+          elements1[0] = address3;
+          var address4 = FAILURE;
+          var index4 = this._offset;
+          address4 = this._read_display_name();
+          if (address4 === FAILURE) {
+            address4 = new TreeNode(this._input.substring(index4, index4), index4, []);
+            this._offset = index4;
+          }
+          if (address4 !== FAILURE) {
+            elements1[1] = address4;
+            var address5 = FAILURE;
+            address5 = this._read_assignment();
+            if (address5 !== FAILURE) {
+              elements1[2] = address5;
+            } else {
+              elements1 = null;
+              this._offset = index3;
+            }
+          } else {
+            elements1 = null;
+            this._offset = index3;
+          }
+        } else {
+          elements1 = null;
+          this._offset = index3;
+        }
+        if (elements1 === null) {
+          address2 = FAILURE;
+        } else {
+          address2 = new TreeNode30(this._input.substring(index3, this._offset), index3, elements1);
+          this._offset = this._offset;
+        }
         this._offset = index2;
         if (address2 === FAILURE) {
           address2 = new TreeNode(this._input.substring(this._offset, this._offset), this._offset, []);
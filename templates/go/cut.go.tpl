@@ -0,0 +1,80 @@
+package {{name}}
+
+// backtrackPoint is an entry on the stack of places a parse may go back to,
+// which is only kept for expressions that may reach a cut. A point is the
+// start of a choice alternative with others after it, of an optional
+// expression, of one turn of a repetition or of a lookahead, any of which
+// the parse goes back to if the expression fails. dead marks points a cut
+// has made unreachable. A barrier marks an expression that something else
+// may fail after once it has matched, so a cut inside it cannot reach the
+// points beneath.
+type backtrackPoint struct {
+	offset  int
+	dead    bool
+	barrier bool
+}
+
+// cut commits the parse to the sequence it is in, which fails the parse if
+// the rest of the sequence fails. The points that failure would have gone
+// back to are dead, and no parse can go back before the lowest live point,
+// or before the cut if there is none, so the memo table may drop the
+// results for offsets before that.
+func (p *{{parser}}) cut() {
+	i := len(p.points)
+	for i > 0 && !p.points[i-1].barrier {
+		i--
+		p.points[i].dead = true
+	}
+	floor := p.offset
+	for _, point := range p.points[:i] {
+		if !point.barrier && !point.dead {
+			floor = min(floor, point.offset)
+			break
+		}
+	}
+	p.cache.setFloor(floor)
+	// Failures past a cut are reported even inside a named expression, since
+	// the parse has committed to it, but never inside a lookahead.
+	if p.lookahead == 0 {
+		p.silent = 0
+	}
+}
+
+// cutFailed is called when a sequence fails after a cut, and stops the parse
+// with an error for the furthest failure so far. It sets p.guarded, so that
+// the rules still to run call enter and fail at once as the parse unwinds. A
+// parser fed by Feed that has run out of input might match once more
+// arrives, so it carries on, and the pass reports that it is incomplete.
+//
+// Inside a lookahead the cut only commits the lookahead, so the parse only
+// unwinds as far as that, and the lookahead fails.
+func (p *{{parser}}) cutFailed() {
+	if p.stopErr != nil || p.cache.truncated {
+		return
+	}
+	if p.lookahead > 0 {
+		p.stopErr = lookaheadCut(p.lookahead)
+	} else {
+		p.stopErr = p.newParseError(false)
+	}
+	p.guarded = true
+}
+
+// lookaheadCut is the stopErr of a parse unwinding to the lookahead a cut
+// failed in, holding p.lookahead inside it.
+type lookaheadCut int
+
+func (c lookaheadCut) Error() string {
+	return "cut failed inside a lookahead"
+}
+
+// cutInLookahead reports whether a cut failed inside the current lookahead,
+// and if so lets the parse carry on from it.
+func (p *{{parser}}) cutInLookahead() bool {
+	if p.stopErr != lookaheadCut(p.lookahead) {
+		return false
+	}
+	p.stopErr = nil
+	p.guard()
+	return true
+}
@@ -0,0 +1,88 @@
+package {{name}}
+
+import "slices"
+
+// Position is a location in the input. Offset is in the same units as node
+// offsets. Line and Column count from 1, and Column counts runes; UTF16Column
+// counts UTF-16 code units instead, as editor protocols such as LSP expect.
+type Position struct {
+	Offset      int
+	Line        int
+	Column      int
+	UTF16Column int
+}
+
+// LineIndex maps offsets in an input to line and column positions. It finds
+// the start of every line once, so each lookup only scans the line the
+// offset is on. "\n", "\r\n" and a lone "\r" all end a line.
+type LineIndex struct {
+	offsets *offsetIndex
+	starts  []int
+	scanned int
+}
+
+// NewLineIndex returns a LineIndex for input. Offsets passed to Position are
+// rune offsets, or byte offsets if WithByteOffsets is among opts, as they are
+// for a parser created with the same options.
+func NewLineIndex(input string, opts ...Option) *LineIndex {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	offsets := newOffsetIndex(input, o.byteOffsets)
+	return newLineIndex(&offsets)
+}
+
+func newLineIndex(offsets *offsetIndex) *LineIndex {
+	return &LineIndex{offsets: offsets, starts: []int{0}}
+}
+
+// scan records the line starts in any input added since the last scan. A
+// "\r" ends a line on its own, and moves the line start past a "\n" that
+// follows it, even if that "\n" was read later.
+func (x *LineIndex) scan() {
+	input := x.offsets.input
+	for i := x.scanned; i < len(input); i++ {
+		switch input[i] {
+		case '\n':
+			if i > 0 && input[i-1] == '\r' {
+				x.starts[len(x.starts)-1] = i + 1
+			} else {
+				x.starts = append(x.starts, i+1)
+			}
+		case '\r':
+			x.starts = append(x.starts, i+1)
+		}
+	}
+	x.scanned = len(input)
+}
+
+// Position returns the position of offset. Offsets past the end of the input
+// are treated as the end of the input.
+func (x *LineIndex) Position(offset int) Position {
+	return x.position(x.offsets.byteOffset(offset))
+}
+
+func (x *LineIndex) position(b int) Position {
+	x.scan()
+	input := x.offsets.input
+	b = min(max(b, 0), len(input))
+	line, found := slices.BinarySearch(x.starts, b)
+	if !found {
+		line--
+	}
+	column, utf16Column := 1, 1
+	for _, r := range input[x.starts[line]:b] {
+		column++
+		utf16Column++
+		if r > 0xffff {
+			utf16Column++
+		}
+	}
+	return Position{
+		Offset:      x.offsets.convert(b),
+		Line:        line + 1,
+		Column:      column,
+		UTF16Column: utf16Column,
+	}
+}
@@ -0,0 +1,147 @@
+package {{name}}
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrorNode stands in the tree returned by ParseWithRecovery for input that
+// could not be parsed as an item. Its text is the input that was skipped,
+// and Err is the diagnostic reported for it.
+type ErrorNode struct {
+	BaseNode
+	Err *ParseError
+}
+
+// ParseWithRecovery parses input like Parse, but carries on past syntax
+// errors between the items of the root rule. See the method of the same
+// name.
+func ParseWithRecovery(input string, actions Actions, types map[string]NodeExtender, opts ...Option) (TreeNode, []*ParseError, error) {
+	parser := New(input, actions, opts...)
+	if types != nil {
+		parser.types = types
+	}
+	return parser.ParseWithRecovery()
+}
+
+// ParseWithRecovery parses the input as a sequence of items of the root
+// rule, as Items does, but when an item fails to match it records the error
+// and skips ahead to the next offset past the failure where an item
+// matches. It returns a tree whose children are the items and an *ErrorNode
+// for each stretch of skipped input, along with a *ParseError for each. The
+// first has the same position and expectations as the error Parse returns,
+// and each later one is found as if the input began where the parse resumed.
+//
+// An item that fails after a cut is reported with the error Parse would
+// return for it, and the parse resumes after it in the same way.
+//
+// The parse only resumes between items of the root rule's repetition: an
+// error anywhere inside an item skips the rest of that item, and nothing
+// within it is recovered. This method is only generated for grammars whose
+// root rule repeats an item with no upper bound, such as
+// `records <- record*`; a parser for any other grammar does not have it.
+//
+// The root rule's action or type is not applied. err is only set if the
+// parse could not go on at all: because an action failed, the parse was
+// stopped by a limit, or the input could not be read.
+func (p *{{parser}}) ParseWithRecovery() (TreeNode, []*ParseError, error) {
+	if p.stream != nil {
+		return nil, nil, fmt.Errorf("ParseWithRecovery cannot be used with a parser created by NewStream")
+	}
+	p.startOver()
+	if err := p.begin(); err != nil {
+		return nil, nil, err
+	}
+	p.cache.reserve(len(p.input))
+	var items []TreeNode
+	var diagnostics []*ParseError
+	for {
+		start := p.offset
+		node := p.readItem()
+		committed := p.uncommit()
+		if err := p.recoveryErr(); err != nil {
+			return nil, diagnostics, err
+		}
+		if node != nil && committed == nil {
+			items = append(items, node)
+			continue
+		}
+		p.offset = start
+		diagnostic, failure := committed, p.failure.offset
+		if committed != nil {
+			failure = p.offsets.byteOffset(committed.Offset)
+		} else if !p.avail(1) {
+			break
+		} else {
+			diagnostic = p.newParseError(false).(*ParseError)
+		}
+		diagnostics = append(diagnostics, diagnostic)
+		resume := p.resync(max(failure, start))
+		if err := p.recoveryErr(); err != nil {
+			return nil, diagnostics, err
+		}
+		items = append(items, &ErrorNode{
+			BaseNode: BaseNode{text: p.slice(start, resume), span: p.offsets.span(start, resume)},
+			Err:      diagnostic,
+		})
+		// The search recorded failures and results that a parse from resume
+		// would not have, so start afresh from there.
+		p.cache.reset()
+		p.failure = failureState{expected: p.failure.expected[:0]}
+		p.offset = resume
+	}
+{{#if min}}
+	if len(items) < {{min}} && len(diagnostics) == 0 {
+		diagnostics = append(diagnostics, p.newParseError(false).(*ParseError))
+	}
+{{/if}}
+	return &BaseNode{text: p.slice(0, p.offset), span: p.offsets.span(0, p.offset), children: items}, diagnostics, nil
+}
+
+// resync returns the first offset after the rune at failure where an item
+// matches, or the end of the input if there is none.
+func (p *{{parser}}) resync(failure int) int {
+	p.offset = failure
+	for p.avail(1) {
+		_, size := utf8.DecodeRuneInString(p.peekRune()[p.offset:])
+		resume := p.offset + size
+		p.offset = resume
+		if !p.avail(1) {
+			return resume
+		}
+		node := p.readItem()
+		if p.uncommit() == nil && (node != nil || p.recoveryErr() != nil) {
+			return resume
+		}
+		p.offset = resume
+	}
+	return p.offset
+}
+
+// uncommit returns the error for an item that failed after a cut, if there
+// was one, and clears it so the parse can go on. The memo is emptied, as the
+// parser stops running rules once the error is set, and the results stored
+// after that are failures that cannot be trusted.
+func (p *{{parser}}) uncommit() *ParseError {
+	err, ok := p.stopErr.(*ParseError)
+	if !ok {
+		return nil
+	}
+	p.stopErr = nil
+	p.guard()
+	p.cache.reset()
+	return err
+}
+
+// recoveryErr returns the error that stops ParseWithRecovery, if any.
+func (p *{{parser}}) recoveryErr() error {
+	switch {
+	case p.stopErr != nil:
+		return p.stopErr
+	case p.readErr != nil:
+		return p.readErr
+	case p.actionErr != nil:
+		return p.actionErr
+	}
+	return nil
+}
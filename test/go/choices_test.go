@@ -53,6 +53,10 @@ func TestChoiceStringsParsesAnyOfTheChoiceOptions(t *testing.T) {
 	assertChoiceMatches(t, node("c", 12), parseChoice(t, "choice-abc: c"))
 }
 
+func TestChoiceStringsDoNotAllocateForFailedOptions(t *testing.T) {
+	assertSameAllocs(t, choicesParse, "choice-abc: a", "choice-abc: c")
+}
+
 func TestChoiceStringsRejectsInputMatchingNoneOfTheOptions(t *testing.T) {
 	expectChoiceParseError(t, "choice-abc: d")
 }
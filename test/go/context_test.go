@@ -0,0 +1,93 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"itemsgoparser"
+)
+
+// expiringContext is done after its Err method has been called a given
+// number of times, so that a parse can be interrupted part way through.
+type expiringContext struct {
+	context.Context
+	checks int
+}
+
+func (c *expiringContext) Done() <-chan struct{} {
+	return make(chan struct{})
+}
+
+func (c *expiringContext) Err() error {
+	c.checks--
+	if c.checks < 0 {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func manyRecords(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "item:%d\n", i)
+	}
+	return b.String()
+}
+
+func TestParseContextMatchesParse(t *testing.T) {
+	input := manyRecords(100)
+	expected, _ := itemsgoparser.Parse(input, nil, nil)
+
+	tree, err := itemsgoparser.ParseContext(context.Background(), input, nil, nil)
+	if err != nil {
+		t.Fatalf("ParseContext returned unexpected error: %v", err)
+	}
+	if dumpTree(tree) != dumpTree(expected) {
+		t.Fatalf("expected ParseContext to return the same tree as Parse")
+	}
+}
+
+func TestParseContextReturnsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := itemsgoparser.ParseContext(ctx, "item:1\n", nil, nil)
+	var ctxErr *itemsgoparser.ContextError
+	if !errors.As(err, &ctxErr) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a ContextError wrapping context.Canceled, got %v", err)
+	}
+}
+
+func TestParseContextStopsPartWayThrough(t *testing.T) {
+	input := manyRecords(5000)
+	ctx := &expiringContext{Context: context.Background(), checks: 2}
+
+	_, err := itemsgoparser.ParseContext(ctx, input, nil, nil)
+	var ctxErr *itemsgoparser.ContextError
+	if !errors.As(err, &ctxErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a ContextError wrapping context.DeadlineExceeded, got %v", err)
+	}
+	if ctxErr.Offset <= 0 || ctxErr.Offset >= len(input) {
+		t.Fatalf("expected the error to be part way through the input, got offset %d", ctxErr.Offset)
+	}
+}
+
+func TestReparseAfterParseContextStopsMatchesParse(t *testing.T) {
+	input := manyRecords(5000)
+	parser := itemsgoparser.New(input, nil)
+	if _, err := parser.ParseContext(&expiringContext{Context: context.Background(), checks: 2}); err == nil {
+		t.Fatalf("expected ParseContext to stop")
+	}
+
+	tree, err := parser.Reparse(itemsgoparser.Edit{Start: 0, OldEnd: 4, NewText: "meti"})
+	if err != nil {
+		t.Fatalf("Reparse returned unexpected error: %v", err)
+	}
+	expected, _ := itemsgoparser.Parse("meti"+input[4:], nil, nil)
+	if dumpTree(tree) != dumpTree(expected) {
+		t.Fatalf("expected Reparse to return the same tree as Parse")
+	}
+}
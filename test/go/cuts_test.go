@@ -0,0 +1,153 @@
+package test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"cutsgoparser"
+)
+
+func cutsError(t *testing.T, input string, opts ...cutsgoparser.Option) *cutsgoparser.ParseError {
+	t.Helper()
+
+	_, err := cutsgoparser.Parse(input, nil, nil, opts...)
+	var parseErr *cutsgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError for %q, got %v", input, err)
+	}
+	return parseErr
+}
+
+func TestCutsAllowValidInput(t *testing.T) {
+	input := "if {a:[1,2],b:null};if [];name;"
+	if _, err := cutsgoparser.Parse(input, nil, nil); err != nil {
+		t.Fatalf("expected %q to parse, got %v", input, err)
+	}
+}
+
+func TestCutStopsTheParseFromTryingOtherAlternatives(t *testing.T) {
+	// Without the cut after "if", iffy would match as a name.
+	parseErr := cutsError(t, "iffy;")
+
+	message := `parse error at line 1, column 3: expected " " from Cuts::keyword but found "fy"`
+	if parseErr.Message != message {
+		t.Fatalf("expected message %q, got %q", message, parseErr.Message)
+	}
+}
+
+func TestCutReportsFailuresInsideNamedRules(t *testing.T) {
+	// value is named, but each of object, pair and array commits to itself,
+	// so the error is not reported as an expected value at the start.
+	for _, opts := range [][]cutsgoparser.Option{nil, {cutsgoparser.WithLimits(cutsgoparser.Limits{MaxDepth: 100})}} {
+		parseErr := cutsError(t, "if {a:[1,x]};", opts...)
+
+		expected := []cutsgoparser.Expectation{{Rule: "Cuts::value", Expected: "value", Offset: 9}}
+		if !slices.Equal(parseErr.Expected, expected) {
+			t.Fatalf("expected %v, got %v", expected, parseErr.Expected)
+		}
+		message := `parse error at line 1, column 10: expected value from Cuts::value but found "x"`
+		if parseErr.Message != message {
+			t.Fatalf("expected message %q, got %q", message, parseErr.Message)
+		}
+	}
+}
+
+func TestCutInsideALookaheadOnlyFailsTheLookahead(t *testing.T) {
+	// The cut commits !("a" ~ "b") to its sequence, so "ac" fails the
+	// sequence, and with it the lookahead, rather than the parse.
+	limits := cutsgoparser.WithLimits(cutsgoparser.Limits{MaxDepth: 100})
+	for _, opts := range [][]cutsgoparser.Option{nil, {limits}, {cutsgoparser.WithoutMemo()}} {
+		for _, input := range []string{"ac", "ab!"} {
+			if _, err := cutsgoparser.ParseRule(cutsgoparser.RuleGuarded, input, nil, nil, opts...); err != nil {
+				t.Fatalf("expected %q to parse, got %v", input, err)
+			}
+		}
+		_, err := cutsgoparser.ParseRule(cutsgoparser.RuleGuarded, "a1", nil, nil, opts...)
+		message := `parse stopped early at line 1, column 2: expected [a-z] from Cuts::guarded but found "1"`
+		if err == nil || err.Error() != message {
+			t.Fatalf("expected %q, got %v", message, err)
+		}
+	}
+}
+
+func TestCutFailureIsTheSameForEveryWayOfReading(t *testing.T) {
+	input := "if [1];if {a:[1,x]};"
+	parseErr := cutsError(t, input)
+
+	_, err := cutsgoparser.ParseReader(strings.NewReader(input), nil, nil)
+	if err == nil || err.Error() != parseErr.Message {
+		t.Fatalf("expected ParseReader to fail with %q, got %v", parseErr.Message, err)
+	}
+
+	stream := cutsgoparser.NewStream(nil)
+	for i := range len(input) {
+		if _, err = stream.Feed([]byte{input[i]}); err != cutsgoparser.ErrIncomplete {
+			break
+		}
+	}
+	if err == cutsgoparser.ErrIncomplete {
+		_, err = stream.Close()
+	}
+	if err == nil || err.Error() != parseErr.Message {
+		t.Fatalf("expected the stream to fail with %q, got %v", parseErr.Message, err)
+	}
+}
+
+func TestCutLetsTheMemoDropEarlierResults(t *testing.T) {
+	limits := cutsgoparser.WithLimits(cutsgoparser.Limits{MaxMemoEntries: 200})
+
+	// Each statement passes a cut, so nothing before it is needed again.
+	input := strings.Repeat("if [1,{a:2}];", 1000)
+	if _, err := cutsgoparser.Parse(input, nil, nil, limits); err != nil {
+		t.Fatalf("expected %d bytes to parse within the limit, got %v", len(input), err)
+	}
+
+	// Names pass no cut, so the memo holds a result for every one of them.
+	_, err := cutsgoparser.Parse(strings.Repeat("name;", 1000), nil, nil, limits)
+	var limitErr *cutsgoparser.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxMemoEntries" {
+		t.Fatalf("expected a LimitError for MaxMemoEntries, got %v", err)
+	}
+}
+
+func TestParseWithRecoveryResumesAfterACutFailure(t *testing.T) {
+	tree, diagnostics, err := cutsgoparser.ParseWithRecovery("if [1,x];abc;iffy;def;", nil, nil)
+	if err != nil {
+		t.Fatalf("ParseWithRecovery returned unexpected error: %v", err)
+	}
+
+	var items []string
+	for _, child := range tree.Children() {
+		if _, ok := child.(*cutsgoparser.ErrorNode); ok {
+			items = append(items, "!"+child.Text())
+		} else {
+			items = append(items, child.Text())
+		}
+	}
+	if expected := []string{"!if [1,x];", "abc;", "!iff", "y;", "def;"}; !slices.Equal(items, expected) {
+		t.Fatalf("expected items %q, got %q", expected, items)
+	}
+
+	var offsets []int
+	for _, diagnostic := range diagnostics {
+		offsets = append(offsets, diagnostic.Offset)
+	}
+	if expected := []int{6, 15}; !slices.Equal(offsets, expected) {
+		t.Fatalf("expected diagnostics at %v, got %v", expected, offsets)
+	}
+}
+
+func TestCutFailureStopsRunningRules(t *testing.T) {
+	// Once keyword fails after its cut, name is not tried, nor any rule as
+	// the parse unwinds.
+	profile := &cutsgoparser.MemoProfile{}
+	cutsError(t, "iffy;", cutsgoparser.WithMemoProfile(profile))
+
+	for _, stats := range profile.Stats() {
+		if stats.Rule == "name" && stats.Lookups != 0 {
+			t.Fatalf("expected name not to be applied, got %d lookups", stats.Lookups)
+		}
+	}
+}
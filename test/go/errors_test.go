@@ -0,0 +1,170 @@
+package test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"choicesgoparser"
+	"cutsgoparser"
+	"quantifiersgoparser"
+	"terminalsgoparser"
+)
+
+// syntaxError is the interface tooling declares to handle errors from any
+// generated parser.
+type syntaxError interface {
+	error
+	Location() (offset, line, column int)
+	ExpectedTokens() []string
+}
+
+func TestParseErrorSortsExpectations(t *testing.T) {
+	_, err := choicesgoparser.Parse("x", nil, nil)
+
+	var parseErr *choicesgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError, got %v", err)
+	}
+	expected := []choicesgoparser.Expectation{
+		{Rule: "Choices::test", Expected: `"choice-abc: "`, Offset: 0},
+		{Rule: "Choices::test", Expected: `"choice-bind: "`, Offset: 0},
+		{Rule: "Choices::test", Expected: `"choice-rep: "`, Offset: 0},
+		{Rule: "Choices::test", Expected: `"choice-seq: "`, Offset: 0},
+	}
+	if !slices.Equal(parseErr.Expected, expected) {
+		t.Fatalf("expected %v, got %v", expected, parseErr.Expected)
+	}
+	message := `parse error at line 1, column 1: expected "choice-abc: " from Choices::test, "choice-bind: " from Choices::test, "choice-rep: " from Choices::test or "choice-seq: " from Choices::test but found "x"`
+	if parseErr.Message != message {
+		t.Fatalf("expected message %q, got %q", message, parseErr.Message)
+	}
+}
+
+func TestParseErrorRemovesDuplicateExpectations(t *testing.T) {
+	// Both halves of greedy_0 fail on the same character.
+	_, err := quantifiersgoparser.Parse("greedy-0: 1", nil, nil)
+
+	var parseErr *quantifiersgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError, got %v", err)
+	}
+	expected := []quantifiersgoparser.Expectation{{Rule: "Quantifiers::greedy_0", Expected: "[a-z]", Offset: 10}}
+	if !slices.Equal(parseErr.Expected, expected) {
+		t.Fatalf("expected %v, got %v", expected, parseErr.Expected)
+	}
+}
+
+func TestParseErrorSatisfiesTheSharedInterface(t *testing.T) {
+	inputs := []func() error{
+		func() error { _, err := choicesgoparser.Parse("choice-abc: d", nil, nil); return err },
+		func() error { _, err := terminalsgoparser.Parse("pos-class: A", nil, nil); return err },
+	}
+	tokens := [][]string{{`"a"`, `"b"`, `"c"`}, {"[a-z]"}}
+
+	for i, parse := range inputs {
+		var syntaxErr syntaxError
+		if !errors.As(parse(), &syntaxErr) {
+			t.Fatalf("expected error %d to satisfy the interface", i)
+		}
+		if offset, line, column := syntaxErr.Location(); offset <= 0 || line != 1 || column != offset+1 {
+			t.Fatalf("expected a location on line 1, got %d at %d:%d", offset, line, column)
+		}
+		if !slices.Equal(syntaxErr.ExpectedTokens(), tokens[i]) {
+			t.Fatalf("expected tokens %v, got %v", tokens[i], syntaxErr.ExpectedTokens())
+		}
+	}
+}
+
+func TestParseErrorDescribesWhatWasFound(t *testing.T) {
+	cases := map[string]string{
+		"pos-class: Abc_9 x":                    `"Abc_9"`,
+		"pos-class: %":                          `"%"`,
+		"pos-class: ":                           "<EOF>",
+		"pos-class: " + strings.Repeat("É", 30): `"` + strings.Repeat("É", 20) + `"`,
+	}
+	for input, found := range cases {
+		_, err := terminalsgoparser.Parse(input, nil, nil)
+
+		var parseErr *terminalsgoparser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("expected a ParseError for %q, got %v", input, err)
+		}
+		if parseErr.Found != found {
+			t.Fatalf("expected %q to report finding %s, got %s", input, found, parseErr.Found)
+		}
+		if !strings.HasSuffix(parseErr.Message, " but found "+found) {
+			t.Fatalf("expected the message to end with what was found, got %q", parseErr.Message)
+		}
+
+		_, err = terminalsgoparser.ParseReader(iotest.OneByteReader(strings.NewReader(input)), nil, nil)
+		if err == nil || err.Error() != parseErr.Message {
+			t.Fatalf("expected ParseReader to fail with %q, got %v", parseErr.Message, err)
+		}
+	}
+}
+
+func TestParseErrorClassifiesTheFailure(t *testing.T) {
+	cases := []struct {
+		input string
+		kind  terminalsgoparser.ErrorKind
+		end   int
+	}{
+		{"pos-class: A", terminalsgoparser.UnexpectedInput, 0},
+		{"pos-class: ", terminalsgoparser.UnexpectedEOF, 0},
+		{"", terminalsgoparser.UnexpectedEOF, 0},
+		{"any: \tx", terminalsgoparser.TrailingInput, 6},
+	}
+	for _, c := range cases {
+		_, err := terminalsgoparser.Parse(c.input, nil, nil)
+
+		var parseErr *terminalsgoparser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("expected a ParseError for %q, got %v", c.input, err)
+		}
+		if parseErr.Kind != c.kind || parseErr.End != c.end {
+			t.Fatalf("expected %q to fail with %v ending at %d, got %v ending at %d", c.input, c.kind, c.end, parseErr.Kind, parseErr.End)
+		}
+
+		_, err = terminalsgoparser.ParseReader(iotest.OneByteReader(strings.NewReader(c.input)), nil, nil)
+		if !errors.As(err, &parseErr) || parseErr.Kind != c.kind || parseErr.End != c.end {
+			t.Fatalf("expected ParseReader to fail on %q with %v ending at %d, got %v", c.input, c.kind, c.end, err)
+		}
+	}
+}
+
+func TestParseErrorReportsIncompleteInputAfterAMatch(t *testing.T) {
+	cases := []struct {
+		input string
+		kind  cutsgoparser.ErrorKind
+		end   int
+	}{
+		// The cut commits to the keyword, which the input ends inside.
+		{"if", cutsgoparser.UnexpectedEOF, 0},
+		// The root rule matched "abc;", but the next statement reached the
+		// end of the input.
+		{"abc;def", cutsgoparser.UnexpectedEOF, 4},
+		{"abc;{", cutsgoparser.TrailingInput, 4},
+		{"[1,", cutsgoparser.TrailingInput, 0},
+	}
+	for _, c := range cases {
+		_, err := cutsgoparser.Parse(c.input, nil, nil)
+
+		var parseErr *cutsgoparser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("expected a ParseError for %q, got %v", c.input, err)
+		}
+		if parseErr.Kind != c.kind || parseErr.End != c.end {
+			t.Fatalf("expected %q to fail with %v ending at %d, got %v ending at %d", c.input, c.kind, c.end, parseErr.Kind, parseErr.End)
+		}
+
+		stream := cutsgoparser.NewStream(nil)
+		stream.Feed([]byte(c.input))
+		_, err = stream.Close()
+		if !errors.As(err, &parseErr) || parseErr.Kind != c.kind || parseErr.End != c.end {
+			t.Fatalf("expected a stream to fail on %q with %v ending at %d, got %v", c.input, c.kind, c.end, err)
+		}
+	}
+}
@@ -4,20 +4,28 @@ go 1.22
 
 require (
 	choicesgoparser v0.0.0
+	cutsgoparser v0.0.0
 	extensionsgoparser v0.0.0
+	itemsgoparser v0.0.0
+	namesgoparser v0.0.0
 	nodeactionsgoparser v0.0.0
 	predicatesgoparser v0.0.0
 	quantifiersgoparser v0.0.0
 	sequencesgoparser v0.0.0
+	silentgoparser v0.0.0
 	terminalsgoparser v0.0.0
 )
 
 replace (
 	choicesgoparser => ../grammars/choices-go
+	cutsgoparser => ../grammars/cuts-go
 	extensionsgoparser => ../grammars/extensions-go
+	itemsgoparser => ../grammars/items-go
+	namesgoparser => ../grammars/names-go
 	nodeactionsgoparser => ../grammars/node_actions-go
 	predicatesgoparser => ../grammars/predicates-go
 	quantifiersgoparser => ../grammars/quantifiers-go
 	sequencesgoparser => ../grammars/sequences-go
+	silentgoparser => ../grammars/silent-go
 	terminalsgoparser => ../grammars/terminals-go
 )
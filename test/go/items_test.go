@@ -0,0 +1,155 @@
+//go:build go1.23
+
+package test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"cutsgoparser"
+	"itemsgoparser"
+)
+
+// collectItems runs Items to completion, describing each item it yields and
+// returning the error that ends it, if any.
+func collectItems(parser *itemsgoparser.ItemsGoParser) ([]string, error) {
+	var items []string
+	for item, err := range parser.Items() {
+		if err != nil {
+			return items, err
+		}
+		items = append(items, dumpTree(item))
+	}
+	return items, nil
+}
+
+func TestItemsYieldsTheItemsParseWould(t *testing.T) {
+	for _, input := range []string{"", "a:1\n", "ab:12\ncd:34\nef:56\n"} {
+		tree, err := itemsgoparser.Parse(input, nil, nil)
+		if err != nil {
+			t.Fatalf("Parse returned unexpected error for %q: %v", input, err)
+		}
+		items, err := collectItems(itemsgoparser.New(input, nil))
+		if err != nil {
+			t.Fatalf("Items returned unexpected error for %q: %v", input, err)
+		}
+
+		if len(items) != len(tree.Children()) {
+			t.Fatalf("expected %d items for %q, got %d", len(tree.Children()), input, len(items))
+		}
+		for i, child := range tree.Children() {
+			if items[i] != dumpTree(child) {
+				t.Fatalf("expected item %d of %q to be\n%s\ngot\n%s", i, input, dumpTree(child), items[i])
+			}
+		}
+	}
+}
+
+func TestItemsYieldsNodesWithLabels(t *testing.T) {
+	for item, err := range itemsgoparser.New("ab:12\n", nil).Items() {
+		if err != nil {
+			t.Fatalf("Items returned unexpected error: %v", err)
+		}
+		record := item.(*itemsgoparser.Node1)
+		if record.Name.Text() != "ab" || record.Value.Text() != "12" {
+			t.Fatalf("expected name ab and value 12, got %q and %q", record.Name.Text(), record.Value.Text())
+		}
+	}
+}
+
+func TestItemsEndsWithTheErrorParseReturns(t *testing.T) {
+	input := "ab:12\ncd:x\nef:56\n"
+	_, expected := itemsgoparser.Parse(input, nil, nil)
+
+	items, err := collectItems(itemsgoparser.New(input, nil))
+	if len(items) != 1 || items[0] != `("ab:12\n" 0-6 ("ab" 0-2 ("a" 0-1) ("b" 1-2)) (":" 2-3) ("12" 3-5 ("1" 3-4) ("2" 4-5)) ("\n" 5-6))` {
+		t.Fatalf("expected the first record before the error, got %v", items)
+	}
+	if err == nil || expected == nil || err.Error() != expected.Error() {
+		t.Fatalf("expected error %v, got %v", expected, err)
+	}
+}
+
+func TestItemsStopsWhenTheLoopBreaks(t *testing.T) {
+	count := 0
+	for _, err := range itemsgoparser.New("ab:12\ncd:x\n", nil).Items() {
+		if err != nil {
+			t.Fatalf("Items returned unexpected error: %v", err)
+		}
+		count++
+		break
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 item, got %d", count)
+	}
+}
+
+func TestItemsYieldsItemsBeforeReadingTheWholeInput(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&b, "item:%d\n", i)
+	}
+	input := b.String()
+	r := &countingReader{r: strings.NewReader(input)}
+
+	count := 0
+	for item, err := range itemsgoparser.NewReader(r, nil).Items() {
+		if err != nil {
+			t.Fatalf("Items returned unexpected error: %v", err)
+		}
+		if count == 0 && r.read >= len(input) {
+			t.Fatalf("expected the first item before the input was read, but read %d bytes", r.read)
+		}
+		if expected := fmt.Sprintf("item:%d\n", count); item.Text() != expected {
+			t.Fatalf("expected item %q, got %q", expected, item.Text())
+		}
+		count++
+	}
+	if count != 5000 {
+		t.Fatalf("expected 5000 items, got %d", count)
+	}
+}
+
+func TestItemsRejectsStreamParsers(t *testing.T) {
+	for _, err := range itemsgoparser.NewStream(nil).Items() {
+		if err == nil {
+			t.Fatalf("expected Items to fail for a parser created by NewStream")
+		}
+	}
+}
+
+func TestItemsKeepOnlyTheResultsForOneItem(t *testing.T) {
+	// Parse fails with this limit; see TestMaxMemoEntriesBoundsTheMemoTable.
+	limits := itemsgoparser.WithLimits(itemsgoparser.Limits{MaxMemoEntries: 50})
+	count := 0
+	for _, err := range itemsgoparser.New(manyRecords(1000), nil, limits).Items() {
+		if err != nil {
+			t.Fatalf("Items returned unexpected error: %v", err)
+		}
+		count++
+	}
+	if count != 1000 {
+		t.Fatalf("expected 1000 items, got %d", count)
+	}
+}
+
+func TestItemsEndWithTheErrorOfACutFailure(t *testing.T) {
+	input := "if [1];if {a:[1,x]};"
+	parseErr := cutsError(t, input)
+
+	var items []string
+	for item, err := range cutsgoparser.New(input, nil).Items() {
+		if err != nil {
+			if err.Error() != parseErr.Message {
+				t.Fatalf("expected Items to fail with %q, got %v", parseErr.Message, err)
+			}
+			break
+		}
+		items = append(items, item.Text())
+	}
+	if !slices.Equal(items, []string{"if [1];"}) {
+		t.Fatalf("expected Items to stop after the first statement, got %q", items)
+	}
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"itemsgoparser"
+)
+
+func nestedRecord(depth int) string {
+	return "a:" + strings.Repeat("(", depth) + "1" + strings.Repeat(")", depth) + "\n"
+}
+
+func assertLimitError(t *testing.T, err error, limit string) *itemsgoparser.LimitError {
+	t.Helper()
+
+	var limitErr *itemsgoparser.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != limit {
+		t.Fatalf("expected a LimitError for %s, got %v", limit, err)
+	}
+	return limitErr
+}
+
+func TestLimitsAllowParsesWithinThem(t *testing.T) {
+	input := manyRecords(50) + nestedRecord(20)
+	expected, _ := itemsgoparser.Parse(input, nil, nil)
+
+	limits := itemsgoparser.Limits{MaxDepth: 30, MaxSteps: 1000, MaxMemoEntries: 1000, MaxInputSize: len(input)}
+	tree, err := itemsgoparser.Parse(input, nil, nil, itemsgoparser.WithLimits(limits))
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error: %v", err)
+	}
+	if dumpTree(tree) != dumpTree(expected) {
+		t.Fatalf("expected the same tree as a parse without limits")
+	}
+}
+
+func TestMaxDepthStopsDeeplyNestedInput(t *testing.T) {
+	_, err := itemsgoparser.Parse(nestedRecord(10000), nil, nil, itemsgoparser.WithLimits(itemsgoparser.Limits{MaxDepth: 100}))
+	limitErr := assertLimitError(t, err, "MaxDepth")
+	if limitErr.Max != 100 || limitErr.Offset != 100 {
+		t.Fatalf("expected to stop at offset 100, got %v", limitErr)
+	}
+}
+
+func TestMaxStepsStopsLongParses(t *testing.T) {
+	_, err := itemsgoparser.Parse(manyRecords(1000), nil, nil, itemsgoparser.WithLimits(itemsgoparser.Limits{MaxSteps: 100}))
+	assertLimitError(t, err, "MaxSteps")
+}
+
+func TestMaxMemoEntriesBoundsTheMemoTable(t *testing.T) {
+	input := manyRecords(1000)
+	limits := itemsgoparser.WithLimits(itemsgoparser.Limits{MaxMemoEntries: 50})
+
+	_, err := itemsgoparser.Parse(input, nil, nil, limits)
+	assertLimitError(t, err, "MaxMemoEntries")
+}
+
+func TestMaxInputSizeRejectsLongInput(t *testing.T) {
+	input := manyRecords(1000)
+	limits := itemsgoparser.WithLimits(itemsgoparser.Limits{MaxInputSize: 100})
+
+	_, err := itemsgoparser.Parse(input, nil, nil, limits)
+	assertLimitError(t, err, "MaxInputSize")
+
+	r := &countingReader{r: strings.NewReader(input)}
+	_, err = itemsgoparser.NewReader(r, nil, limits).Parse()
+	assertLimitError(t, err, "MaxInputSize")
+	if r.read >= len(input) {
+		t.Fatalf("expected reading to stop at the limit, but read %d bytes", r.read)
+	}
+
+	parser := itemsgoparser.NewStream(nil, limits)
+	_, err = parser.Feed([]byte(input))
+	assertLimitError(t, err, "MaxInputSize")
+}
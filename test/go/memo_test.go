@@ -0,0 +1,93 @@
+package test
+
+import (
+	"slices"
+	"testing"
+
+	"quantifiersgoparser"
+)
+
+func parseQuantifierWith(t *testing.T, input string, opts ...quantifiersgoparser.Option) quantifiersgoparser.TreeNode {
+	t.Helper()
+
+	tree, err := quantifiersgoparser.Parse(input, nil, nil, opts...)
+	if err != nil {
+		t.Fatalf("parse(%q) returned unexpected error: %v", input, err)
+	}
+	return tree
+}
+
+func TestMemoOptionsDoNotChangeTheParseTree(t *testing.T) {
+	input := "color-ref: #abc123"
+	expected := node(
+		"#abc123",
+		11,
+		node("#", 11),
+		node(
+			"abc123",
+			12,
+			node("a", 12),
+			node("b", 13),
+			node("c", 14),
+			node("1", 15),
+			node("2", 16),
+			node("3", 17),
+		),
+	)
+
+	for _, opts := range [][]quantifiersgoparser.Option{
+		nil,
+		{quantifiersgoparser.WithoutMemo()},
+		{quantifiersgoparser.WithMemoRules("color_ref", "hex")},
+		{quantifiersgoparser.WithMemoProfile(&quantifiersgoparser.MemoProfile{})},
+	} {
+		tree := parseQuantifierWith(t, input, opts...)
+		assertNodeMatches(t, quantifiersNodeAccessors, expected, tree.Children()[1])
+	}
+}
+
+func TestMemoRulesRejectsUnknownRules(t *testing.T) {
+	_, err := quantifiersgoparser.Parse("maybe: 1", nil, nil, quantifiersgoparser.WithMemoRules("nope"))
+	if err == nil || err.Error() != `unknown rule "nope"` {
+		t.Fatalf("expected unknown rule error, got %v", err)
+	}
+}
+
+func TestMemoRulesCanBeCorrectedByALaterOption(t *testing.T) {
+	for _, fix := range []quantifiersgoparser.Option{
+		quantifiersgoparser.WithMemoRules("color_ref"),
+		quantifiersgoparser.WithoutMemo(),
+	} {
+		_, err := quantifiersgoparser.Parse("maybe: 1", nil, nil, quantifiersgoparser.WithMemoRules("nope"), fix)
+		if err != nil {
+			t.Fatalf("expected the later option to replace the unknown rule, got %v", err)
+		}
+	}
+}
+
+func TestMemoProfileReportsGrammarAnnotations(t *testing.T) {
+	profile := &quantifiersgoparser.MemoProfile{}
+	parseQuantifierWith(t, "color-ref: #abc123", quantifiersgoparser.WithMemoProfile(profile))
+
+	stats := profile.Stats()
+	index := slices.IndexFunc(stats, func(s quantifiersgoparser.MemoStats) bool { return s.Rule == "hex" })
+	if index < 0 {
+		t.Fatalf("expected stats for rule hex")
+	}
+	if hex := stats[index]; hex.Memoized || hex.Lookups != 7 || hex.Hits != 0 {
+		t.Fatalf("expected hex to be unmemoized with 7 lookups and no hits, got %+v", hex)
+	}
+}
+
+func TestMemoProfileSuggestsRulesWithMemoHits(t *testing.T) {
+	profile := &quantifiersgoparser.MemoProfile{}
+	parseQuantifierWith(t, "rep-1: abc", quantifiersgoparser.WithMemoProfile(profile))
+	parseQuantifierWith(t, "color-choice: #ab", quantifiersgoparser.WithMemoProfile(profile))
+
+	if suggested := profile.Suggest(0); !slices.Equal(suggested, []string{"test", "rep_1", "color_choice"}) {
+		t.Fatalf("expected every applied rule to be suggested at rate 0, got %v", suggested)
+	}
+	if suggested := profile.Suggest(0.01); len(suggested) != 0 {
+		t.Fatalf("expected no rule to be answered from the memo table, got %v", suggested)
+	}
+}
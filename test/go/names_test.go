@@ -0,0 +1,132 @@
+package test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"namesgoparser"
+	"silentgoparser"
+)
+
+func namesError(t *testing.T, input string) *namesgoparser.ParseError {
+	t.Helper()
+
+	_, err := namesgoparser.Parse(input, nil, nil)
+	var parseErr *namesgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError for %q, got %v", input, err)
+	}
+
+	_, err = namesgoparser.ParseReader(iotest.OneByteReader(strings.NewReader(input)), nil, nil)
+	if err == nil || err.Error() != parseErr.Message {
+		t.Fatalf("expected ParseReader to fail with %q, got %v", parseErr.Message, err)
+	}
+	return parseErr
+}
+
+func TestNamedRulesParseLikeOtherRules(t *testing.T) {
+	for _, input := range []string{"let x = -1.5;", "let list_2 = [1,2,3];"} {
+		if _, err := namesgoparser.Parse(input, nil, nil); err != nil {
+			t.Fatalf("expected %q to parse, got %v", input, err)
+		}
+	}
+}
+
+func TestRuleDisplayNameReplacesItsTerminals(t *testing.T) {
+	parseErr := namesError(t, "let 1 = 2;")
+
+	expected := []namesgoparser.Expectation{{Rule: "Names::identifier", Expected: "identifier", Offset: 4}}
+	if !slices.Equal(parseErr.Expected, expected) {
+		t.Fatalf("expected %v, got %v", expected, parseErr.Expected)
+	}
+	message := `parse error at line 1, column 5: expected identifier from Names::identifier but found "1"`
+	if parseErr.Message != message {
+		t.Fatalf("expected message %q, got %q", message, parseErr.Message)
+	}
+}
+
+func TestRuleDisplayNamesAreListedWithTerminals(t *testing.T) {
+	parseErr := namesError(t, "let x = ?;")
+
+	message := `parse error at line 1, column 9: expected "[" from Names::list or number from Names::number but found "?"`
+	if parseErr.Message != message {
+		t.Fatalf("expected message %q, got %q", message, parseErr.Message)
+	}
+	if tokens := parseErr.ExpectedTokens(); !slices.Equal(tokens, []string{`"["`, "number"}) {
+		t.Fatalf("expected tokens [\"[\" number], got %v", tokens)
+	}
+}
+
+func TestCustomMessageIsUsedWhenItsExpressionFails(t *testing.T) {
+	parseErr := namesError(t, "let x = 1")
+
+	expected := []namesgoparser.Expectation{
+		{Rule: "Names::statement", Expected: "missing ; after a statement", Offset: 9, Custom: true},
+	}
+	if !slices.Equal(parseErr.Expected, expected) {
+		t.Fatalf("expected %v, got %v", expected, parseErr.Expected)
+	}
+	message := "parse error at line 1, column 10: missing ; after a statement but found <EOF>"
+	if parseErr.Message != message {
+		t.Fatalf("expected message %q, got %q", message, parseErr.Message)
+	}
+	if tokens := parseErr.ExpectedTokens(); len(tokens) != 0 {
+		t.Fatalf("expected custom messages to be left out of the tokens, got %v", tokens)
+	}
+}
+
+func TestCustomMessagesComeBeforeOtherExpectations(t *testing.T) {
+	parseErr := namesError(t, "let x = [1,2")
+
+	message := `parse error at line 1, column 13: unclosed list; expected "," from Names::list but found <EOF>`
+	if parseErr.Message != message {
+		t.Fatalf("expected message %q, got %q", message, parseErr.Message)
+	}
+}
+
+func TestFailuresInsideNamedRulesAreNotReported(t *testing.T) {
+	// number fails to match a fraction after "1.", further on than the
+	// failure to find ";", but it still matches "1", so that is not reported.
+	parseErr := namesError(t, "let x = 1.;")
+
+	if parseErr.Offset != 9 || parseErr.Message != `parse error at line 1, column 10: missing ; after a statement but found "."` {
+		t.Fatalf("expected the missing semicolon to be reported, got %q", parseErr.Message)
+	}
+}
+
+func TestNamedRulesReportTheSameErrorsWithoutMemo(t *testing.T) {
+	// word is applied inside named, where its failures are not recorded,
+	// and then at the same offset by other, where they are.
+	cases := map[string]string{
+		"1":  `parse error at line 1, column 1: expected [a-z] from Silent::word or thing from Silent::named but found "1"`,
+		"ab": `parse error at line 1, column 3: expected "?" from Silent::other or [a-z] from Silent::word but found <EOF>`,
+	}
+	for input, message := range cases {
+		for _, opts := range [][]silentgoparser.Option{nil, {silentgoparser.WithoutMemo()}} {
+			_, err := silentgoparser.Parse(input, nil, nil, opts...)
+			if err == nil || err.Error() != message {
+				t.Fatalf("expected %q to fail with %q, got %v", input, message, err)
+			}
+		}
+	}
+}
+
+func TestNamedRulesAreMemoizedWhileSilenced(t *testing.T) {
+	// Every rule inside expression is silenced, and term is tried three
+	// times at each offset, so without the memo the parse takes time
+	// exponential in the nesting depth.
+	input := strings.Repeat("(", 20) + "1" + strings.Repeat(")", 20)
+	limits := silentgoparser.WithLimits(silentgoparser.Limits{MaxSteps: 4 * len(input)})
+	if _, err := silentgoparser.ParseRule(silentgoparser.RuleExpression, input, nil, nil, limits); err != nil {
+		t.Fatalf("expected %q to parse within %d steps, got %v", input, 4*len(input), err)
+	}
+
+	_, err := silentgoparser.ParseRule(silentgoparser.RuleExpression, "((1+)", nil, nil)
+	message := `parse error at line 1, column 1: expected expression from Silent::expression but found "("`
+	if err == nil || err.Error() != message {
+		t.Fatalf("expected %q, got %v", message, err)
+	}
+}
@@ -147,6 +147,22 @@ func (a testActions) MakeZero(input string, start, end int, elements []nodeactio
 	return a.make("zero", input, start, end, elements), nil
 }
 
+type embeddedNode struct {
+	nodeactionsgoparser.BaseNode
+}
+
+type embeddingActions struct {
+	testActions
+}
+
+func (embeddingActions) MakeStr(input string, start, end int, elements []nodeactionsgoparser.TreeNode) (nodeactionsgoparser.TreeNode, error) {
+	return &embeddedNode{}, nil
+}
+
+func (embeddingActions) MakeSeq(input string, start, end int, elements []nodeactionsgoparser.TreeNode) (nodeactionsgoparser.TreeNode, error) {
+	return elements[1], nil
+}
+
 func parseNodeActionsRoot(t *testing.T, input string, actions nodeactionsgoparser.Actions) nodeactionsgoparser.TreeNode {
 	t.Helper()
 
@@ -483,3 +499,27 @@ func TestNodeActionsAcceptsFalseyChoiceResults(t *testing.T) {
 		t.Fatalf("expected nil value, got %v", value)
 	}
 }
+
+func TestActionNodesEmbeddingBaseNodeGetTheActionSpan(t *testing.T) {
+	result := parseNodeActionsResult(t, "act-str: hello", embeddingActions{})
+
+	node, ok := result.(*embeddedNode)
+	if !ok {
+		t.Fatalf("expected *embeddedNode, got %T", result)
+	}
+	if span := node.Span(); span != (nodeactionsgoparser.Span{Start: 9, End: 14, ByteStart: 9, ByteEnd: 14}) {
+		t.Fatalf("unexpected span %+v", span)
+	}
+}
+
+func TestActionsReturningAnElementKeepItsSpan(t *testing.T) {
+	result := parseNodeActionsResult(t, "act-seq: xyz", embeddingActions{})
+
+	node, ok := result.(*nodeactionsgoparser.BaseNode)
+	if !ok {
+		t.Fatalf("expected *BaseNode, got %T", result)
+	}
+	if span := node.Span(); span != (nodeactionsgoparser.Span{Start: 10, End: 11, ByteStart: 10, ByteEnd: 11}) {
+		t.Fatalf("unexpected span %+v", span)
+	}
+}
@@ -59,3 +59,19 @@ func expectParseError[T any](t *testing.T, parse func(string) (T, error), isPars
 		t.Fatalf("parse(%q) expected parse error, got %T", input, err)
 	}
 }
+
+func assertSameAllocs[T any](t *testing.T, parse func(string) (T, error), baseline, input string) {
+	t.Helper()
+
+	allocs := func(input string) float64 {
+		return testing.AllocsPerRun(100, func() {
+			if _, err := parse(input); err != nil {
+				t.Fatalf("parse(%q) returned unexpected error: %v", input, err)
+			}
+		})
+	}
+
+	if want, got := allocs(baseline), allocs(input); got != want {
+		t.Fatalf("parse(%q) made %v allocations, parse(%q) made %v", input, got, baseline, want)
+	}
+}
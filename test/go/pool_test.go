@@ -0,0 +1,71 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"itemsgoparser"
+	"nodeactionsgoparser"
+)
+
+func TestParserMatchesParseAcrossConcurrentParses(t *testing.T) {
+	inputs := []string{manyRecords(10), nestedRecord(5), "a:1\nb:x\n", manyRecords(200), ""}
+	expected := make([]string, len(inputs))
+	for i, input := range inputs {
+		tree, err := itemsgoparser.Parse(input, nil, nil)
+		expected[i] = describeResult(dumpTree(tree), err)
+	}
+
+	parser := itemsgoparser.NewParser(nil, nil)
+	var wg sync.WaitGroup
+	errs := make(chan string, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				i := (g + n) % len(inputs)
+				tree, err := parser.Parse(inputs[i])
+				if actual := describeResult(dumpTree(tree), err); actual != expected[i] {
+					errs <- fmt.Sprintf("parse %d of %q returned\n%s\nexpected\n%s", n, inputs[i], actual, expected[i])
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestParserPassesActionsToEveryParse(t *testing.T) {
+	parser := nodeactionsgoparser.NewParser(testActions{}, nil)
+	for _, input := range []string{"act-str: hello", "act-rep: abc", "act-str: hello"} {
+		expected, _ := nodeactionsgoparser.Parse(input, testActions{}, nil)
+		tree, err := parser.Parse(input)
+		if err != nil {
+			t.Fatalf("Parse returned unexpected error for %q: %v", input, err)
+		}
+		if dumpTree(tree) != dumpTree(expected) {
+			t.Fatalf("expected\n%s\ngot\n%s", dumpTree(expected), dumpTree(tree))
+		}
+	}
+}
+
+func TestParserRecoversFromStoppedParses(t *testing.T) {
+	parser := itemsgoparser.NewParser(nil, nil, itemsgoparser.WithLimits(itemsgoparser.Limits{MaxDepth: 10}))
+
+	_, err := parser.Parse(nestedRecord(100))
+	assertLimitError(t, err, "MaxDepth")
+
+	if _, err := parser.Parse(nestedRecord(5)); err != nil {
+		t.Fatalf("Parse returned unexpected error: %v", err)
+	}
+	if _, err := parser.ParseReader(strings.NewReader(manyRecords(10))); err != nil {
+		t.Fatalf("ParseReader returned unexpected error: %v", err)
+	}
+}
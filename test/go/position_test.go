@@ -0,0 +1,68 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"terminalsgoparser"
+)
+
+func assertPosition(t *testing.T, expected, actual terminalsgoparser.Position) {
+	t.Helper()
+
+	if actual != expected {
+		t.Fatalf("expected position %+v, got %+v", expected, actual)
+	}
+}
+
+func TestPositionTreatsLFCRLFAndCRAsLineBreaks(t *testing.T) {
+	lines := terminalsgoparser.NewLineIndex("ab\r\ncd\ref\ngh")
+
+	assertPosition(t, terminalsgoparser.Position{Offset: 1, Line: 1, Column: 2, UTF16Column: 2}, lines.Position(1))
+	assertPosition(t, terminalsgoparser.Position{Offset: 3, Line: 1, Column: 4, UTF16Column: 4}, lines.Position(3))
+	assertPosition(t, terminalsgoparser.Position{Offset: 4, Line: 2, Column: 1, UTF16Column: 1}, lines.Position(4))
+	assertPosition(t, terminalsgoparser.Position{Offset: 7, Line: 3, Column: 1, UTF16Column: 1}, lines.Position(7))
+	assertPosition(t, terminalsgoparser.Position{Offset: 11, Line: 4, Column: 2, UTF16Column: 2}, lines.Position(11))
+}
+
+func TestPositionReportsUTF16Columns(t *testing.T) {
+	lines := terminalsgoparser.NewLineIndex("é\nx😀y")
+
+	assertPosition(t, terminalsgoparser.Position{Offset: 4, Line: 2, Column: 3, UTF16Column: 4}, lines.Position(4))
+}
+
+func TestPositionAcceptsByteOffsets(t *testing.T) {
+	lines := terminalsgoparser.NewLineIndex("é\nx😀y", terminalsgoparser.WithByteOffsets())
+
+	assertPosition(t, terminalsgoparser.Position{Offset: 8, Line: 2, Column: 3, UTF16Column: 4}, lines.Position(8))
+}
+
+func TestPositionClampsOffsetsToTheInput(t *testing.T) {
+	lines := terminalsgoparser.NewLineIndex("ab\ncd")
+
+	assertPosition(t, terminalsgoparser.Position{Offset: 5, Line: 2, Column: 3, UTF16Column: 3}, lines.Position(99))
+}
+
+func TestParserPositionLocatesNodes(t *testing.T) {
+	parser := terminalsgoparser.New("any: 😀", nil)
+	tree, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("parse returned unexpected error: %v", err)
+	}
+
+	char := tree.(*terminalsgoparser.Node1).AnyChar.(*terminalsgoparser.BaseNode)
+	assertPosition(t, terminalsgoparser.Position{Offset: 5, Line: 1, Column: 6, UTF16Column: 6}, parser.Position(char.Offset()))
+	assertPosition(t, terminalsgoparser.Position{Offset: 6, Line: 1, Column: 7, UTF16Column: 8}, parser.Position(char.End()))
+}
+
+func TestParseErrorsCountLoneCRAsALineBreak(t *testing.T) {
+	_, err := terminalsParse("any: \rx")
+
+	var parseErr *terminalsgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	if parseErr.Offset != 6 || parseErr.Line != 2 || parseErr.Column != 1 {
+		t.Fatalf("expected offset 6 at 2:1, got offset %d at %d:%d", parseErr.Offset, parseErr.Line, parseErr.Column)
+	}
+}
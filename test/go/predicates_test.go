@@ -2,6 +2,7 @@ package test
 
 import (
 	"errors"
+	"slices"
 	"testing"
 
 	"predicatesgoparser"
@@ -170,3 +171,60 @@ func TestNegativeLookaheadRejectsMatchingNegativePattern(t *testing.T) {
 	expectPredicateParseError(t, "neg-tail-class: words")
 	expectPredicateParseError(t, "neg-tail-any: word ")
 }
+
+func predicateParseError(t *testing.T, input string) *predicatesgoparser.ParseError {
+	t.Helper()
+
+	_, err := predicatesParse(input)
+	var parseErr *predicatesgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError for %q, got %v", input, err)
+	}
+	return parseErr
+}
+
+func TestNegativeLookaheadReportsWhatItFoundAsUnexpected(t *testing.T) {
+	parseErr := predicateParseError(t, "neg-tail-str: wordmore text")
+
+	expected := []predicatesgoparser.Expectation{
+		{Rule: "Predicates::neg_tail_str", Expected: `"more text"`, Offset: 18, Unexpected: true},
+	}
+	if !slices.Equal(parseErr.Expected, expected) {
+		t.Fatalf("expected %v, got %v", expected, parseErr.Expected)
+	}
+	message := `parse error at line 1, column 19: unexpected "more text" from Predicates::neg_tail_str but found "more"`
+	if parseErr.Message != message {
+		t.Fatalf("expected message %q, got %q", message, parseErr.Message)
+	}
+	if tokens := parseErr.ExpectedTokens(); len(tokens) != 0 {
+		t.Fatalf("expected no expected tokens, got %v", tokens)
+	}
+}
+
+func TestPositiveLookaheadReportsItsExpressionAsExpected(t *testing.T) {
+	// The lookahead fails at "1", but only its start is reported.
+	parseErr := predicateParseError(t, "pos-seq: <1>")
+
+	expected := []predicatesgoparser.Expectation{
+		{Rule: "Predicates::pos_seq", Expected: `("<" [a-z]+)`, Offset: 9},
+	}
+	if !slices.Equal(parseErr.Expected, expected) {
+		t.Fatalf("expected %v, got %v", expected, parseErr.Expected)
+	}
+	message := `parse error at line 1, column 10: expected ("<" [a-z]+) from Predicates::pos_seq but found "<"`
+	if parseErr.Message != message {
+		t.Fatalf("expected message %q, got %q", message, parseErr.Message)
+	}
+}
+
+func TestLookaheadFailuresAreNotExpected(t *testing.T) {
+	// The negative lookahead's [a-z] fails at the end of the input, where
+	// the root rule also expects <EOF>.
+	parseErr := predicateParseError(t, "neg-tail-class: word!")
+
+	for _, exp := range parseErr.Expected {
+		if exp.Expected == "[a-z]" {
+			t.Fatalf("expected the lookahead's failure not to be reported, got %v", parseErr.Expected)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"terminalsgoparser"
+)
+
+func TestParsePrefixStopsWhereTheRootRuleDoes(t *testing.T) {
+	tree, n, err := terminalsgoparser.ParsePrefix("str-1: oat}} and more", nil, nil)
+	if err != nil {
+		t.Fatalf("ParsePrefix returned unexpected error: %v", err)
+	}
+	if n != 10 || tree.Text() != "str-1: oat" {
+		t.Fatalf("expected to consume 10 characters of %q, got %d of %q", "str-1: oat", n, tree.Text())
+	}
+	assertTerminalMatches(t, node("oat", 7), tree.Children()[1])
+}
+
+func TestParsePrefixCountsCharactersConsumed(t *testing.T) {
+	_, n, err := terminalsgoparser.ParsePrefix("any: 😀😀", nil, nil)
+	if err != nil || n != 6 {
+		t.Fatalf("expected 6 characters, got %d, %v", n, err)
+	}
+
+	_, n, err = terminalsgoparser.ParsePrefix("any: 😀😀", nil, nil, terminalsgoparser.WithByteOffsets())
+	if err != nil || n != 9 {
+		t.Fatalf("expected 9 bytes, got %d, %v", n, err)
+	}
+}
+
+func TestParsePrefixReportsFailures(t *testing.T) {
+	_, _, err := terminalsgoparser.ParsePrefix("str-1: oak", nil, nil)
+
+	var parseErr *terminalsgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	if parseErr.Offset != 7 || !strings.HasPrefix(parseErr.Message, "parse error at line 1, column 8: ") {
+		t.Fatalf("expected a parse error at offset 7, got %q", parseErr.Message)
+	}
+}
+
+func TestParsePrefixReadsOnlyWhatItNeeds(t *testing.T) {
+	input := "str-1: oat" + strings.Repeat(" ", 10000)
+	r := &countingReader{r: strings.NewReader(input)}
+
+	_, n, err := terminalsgoparser.NewReader(r, nil).ParsePrefix()
+	if err != nil || n != 10 {
+		t.Fatalf("expected 10 characters, got %d, %v", n, err)
+	}
+	if r.read >= len(input) {
+		t.Fatalf("expected ParsePrefix to stop reading early, but read %d bytes", r.read)
+	}
+}
+
+func TestParseAtStartsAtTheOffset(t *testing.T) {
+	input := "{{ str-ci: OaT }} and {{ any: é }}"
+	parser := terminalsgoparser.New(input, nil)
+
+	tree, n, err := parser.ParseAt(3)
+	if err != nil || n != 11 {
+		t.Fatalf("expected 11 characters, got %d, %v", n, err)
+	}
+	assertTerminalMatches(t, node("OaT", 11), tree.Children()[1])
+
+	tree, n, err = parser.ParseAt(25)
+	if err != nil || n != 6 {
+		t.Fatalf("expected 6 characters, got %d, %v", n, err)
+	}
+	assertTerminalMatches(t, node("é", 30), tree.Children()[1])
+}
+
+func TestParseAtReportsTheSameErrorsAsANewParser(t *testing.T) {
+	input := "str-2: oat / str-2: oak"
+	_, _, expected := terminalsgoparser.ParseAt(input, 13, nil, nil)
+
+	parser := terminalsgoparser.New(input, nil)
+	if _, _, err := parser.ParseAt(0); err != nil {
+		t.Fatalf("ParseAt returned unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		_, _, err := parser.ParseAt(13)
+		if err == nil || expected == nil || err.Error() != expected.Error() {
+			t.Fatalf("expected error %v, got %v", expected, err)
+		}
+	}
+}
+
+func TestParseAtRejectsOffsetsOutsideTheInput(t *testing.T) {
+	for _, offset := range []int{-1, 13} {
+		if _, _, err := terminalsgoparser.ParseAt("str-1: oat", offset, nil, nil); err == nil {
+			t.Fatalf("expected ParseAt(%d) to fail", offset)
+		}
+	}
+}
+
+func TestParseSaysWhetherItFailedOrStoppedEarly(t *testing.T) {
+	for input, status := range map[string]string{
+		"str-1: oak":  "parse error at line 1, column 8: ",
+		"str-1: oatx": "parse stopped early at line 1, column 11: ",
+	} {
+		_, err := terminalsParse(input)
+		if err == nil || !strings.HasPrefix(err.Error(), status) {
+			t.Fatalf("expected Parse(%q) to fail with %q, got %v", input, status, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"terminalsgoparser"
+)
+
+func prettyError(t *testing.T, input string, opts terminalsgoparser.PrettyOptions) string {
+	t.Helper()
+
+	_, err := terminalsgoparser.Parse(input, nil, nil)
+	var parseErr *terminalsgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError for %q, got %v", input, err)
+	}
+	return parseErr.Pretty(opts)
+}
+
+func assertPretty(t *testing.T, expected []string, actual string) {
+	t.Helper()
+
+	if want := strings.Join(expected, "\n"); actual != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, actual)
+	}
+}
+
+func TestPrettyPointsAtTheFailure(t *testing.T) {
+	assertPretty(t, []string{
+		"parse error at line 1, column 12: expected [a-z] from Terminals::positive_class but found \"A\"",
+		"",
+		"     1 | pos-class: A",
+		"                    ^",
+	}, prettyError(t, "pos-class: A", terminalsgoparser.PrettyOptions{}))
+}
+
+func TestPrettyExpandsTabsAndWideCharacters(t *testing.T) {
+	assertPretty(t, []string{
+		"parse stopped early at line 1, column 7: expected <EOF> from Terminals but found \"x\"",
+		"",
+		"     1 | any:    x",
+		"                 ^",
+	}, prettyError(t, "any: \tx", terminalsgoparser.PrettyOptions{}))
+
+	assertPretty(t, []string{
+		"parse stopped early at line 1, column 7: expected <EOF> from Terminals but found \"x\"",
+		"",
+		"     1 | any:  x",
+		"               ^",
+	}, prettyError(t, "any: \tx", terminalsgoparser.PrettyOptions{TabWidth: 2}))
+
+	assertPretty(t, []string{
+		"parse stopped early at line 1, column 7: expected <EOF> from Terminals but found \"x\"",
+		"",
+		"     1 | any: 世x",
+		"                ^",
+	}, prettyError(t, "any: 世x", terminalsgoparser.PrettyOptions{}))
+}
+
+func TestPrettyShowsContextLines(t *testing.T) {
+	assertPretty(t, []string{
+		"parse stopped early at line 2, column 1: expected <EOF> from Terminals but found \"\\r\"",
+		"",
+		"     1 | any: ",
+		"     2 | ",
+		"         ^",
+		"     3 | x",
+	}, prettyError(t, "any: \n\r\nx", terminalsgoparser.PrettyOptions{Context: 1}))
+}
+
+func TestPrettyColorsTheMessageAndCaret(t *testing.T) {
+	actual := prettyError(t, "pos-class: A", terminalsgoparser.PrettyOptions{Color: true})
+	assertPretty(t, []string{
+		"\x1b[1mparse error at line 1, column 12: expected [a-z] from Terminals::positive_class but found \"A\"\x1b[0m",
+		"",
+		"\x1b[2m     1 | \x1b[0mpos-class: A",
+		"                    \x1b[1;31m^\x1b[0m",
+	}, actual)
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"terminalsgoparser"
+)
+
+// countingReader records how much of its input has been read.
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.read += n
+	return n, err
+}
+
+func parseTerminalReader(t *testing.T, r io.Reader) terminalsgoparser.TreeNode {
+	t.Helper()
+
+	tree, err := terminalsgoparser.ParseReader(r, nil, nil)
+	if err != nil {
+		t.Fatalf("ParseReader returned unexpected error: %v", err)
+	}
+	return tree.Children()[1]
+}
+
+func TestParseReaderParsesStreamedInput(t *testing.T) {
+	for _, input := range []string{"any: 😀", "neg-class: é", "str-2: oat", "str-ci: OAT"} {
+		expected := parseTerminal(t, input)
+		actual := parseTerminalReader(t, iotest.OneByteReader(strings.NewReader(input)))
+		assertTerminalMatches(t, node(expected.Text(), expected.Offset()), actual)
+	}
+}
+
+func TestParseReaderReportsErrorsAtRuneOffsets(t *testing.T) {
+	_, err := terminalsgoparser.ParseReader(iotest.OneByteReader(strings.NewReader("any: é\ré")), nil, nil)
+
+	var parseErr *terminalsgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	if parseErr.Offset != 6 || parseErr.Line != 1 || parseErr.Column != 7 {
+		t.Fatalf("expected offset 6 at 1:7, got offset %d at %d:%d", parseErr.Offset, parseErr.Line, parseErr.Column)
+	}
+}
+
+func TestParseReaderCountsCRLFSplitAcrossReadsAsOneLineBreak(t *testing.T) {
+	parser := terminalsgoparser.NewReader(iotest.OneByteReader(strings.NewReader("any: \r\n")), nil)
+	if _, err := parser.Parse(); err == nil {
+		t.Fatalf("expected parse error")
+	}
+
+	pos := parser.Position(7)
+	if pos.Line != 2 || pos.Column != 1 {
+		t.Fatalf("expected 2:1, got %d:%d", pos.Line, pos.Column)
+	}
+}
+
+func TestParseReaderReturnsReadErrors(t *testing.T) {
+	errRead := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader("str-1: o"), iotest.ErrReader(errRead))
+
+	_, err := terminalsgoparser.ParseReader(r, nil, nil)
+	if !errors.Is(err, errRead) {
+		t.Fatalf("expected read error, got %v", err)
+	}
+	var parseErr *terminalsgoparser.ParseError
+	if errors.As(err, &parseErr) {
+		t.Fatalf("expected read error not to be a parse error")
+	}
+}
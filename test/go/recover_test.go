@@ -0,0 +1,75 @@
+package test
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"extensionsgoparser"
+	"nodeactionsgoparser"
+)
+
+// panickingActions indexes past the end of the elements passed to make_seq.
+type panickingActions struct {
+	testActions
+}
+
+func (a panickingActions) MakeSeq(input string, start, end int, elements []nodeactionsgoparser.TreeNode) (nodeactionsgoparser.TreeNode, error) {
+	return elements[len(elements)], nil
+}
+
+func TestActionPanicsAreReturnedAsActionErrors(t *testing.T) {
+	_, err := nodeactionsgoparser.Parse("act-seq: xyz", panickingActions{}, nil)
+
+	var actionErr *nodeactionsgoparser.ActionError
+	if !errors.As(err, &actionErr) {
+		t.Fatalf("expected an ActionError, got %v", err)
+	}
+	if actionErr.Action != "make_seq" || actionErr.Extender || actionErr.Rule != nodeactionsgoparser.RuleActSeq {
+		t.Fatalf("expected make_seq in act_seq, got %s in %s", actionErr.Action, actionErr.Rule)
+	}
+	if actionErr.Start != 9 || actionErr.End != 12 || actionErr.Line != 1 || actionErr.Column != 10 {
+		t.Fatalf("expected offsets 9-12 at line 1, column 10, got %+v", actionErr)
+	}
+	var runtimeErr runtime.Error
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected the error to wrap the runtime error, got %v", actionErr.Value)
+	}
+	if !strings.Contains(string(actionErr.Stack), "MakeSeq") {
+		t.Fatalf("expected the stack to include the action, got\n%s", actionErr.Stack)
+	}
+	expected := "action make_seq in rule act_seq panicked at line 1, column 10: "
+	if !strings.HasPrefix(err.Error(), expected) {
+		t.Fatalf("expected message to start with %q, got %q", expected, err.Error())
+	}
+}
+
+func TestNodeExtenderPanicsAreReturnedAsActionErrors(t *testing.T) {
+	types := map[string]extensionsgoparser.NodeExtender{
+		"Ext": func(node extensionsgoparser.TreeNode) extensionsgoparser.TreeNode {
+			panic("bad node")
+		},
+	}
+	_, err := extensionsgoparser.Parse("ext-str: hello", nil, types)
+
+	var actionErr *extensionsgoparser.ActionError
+	if !errors.As(err, &actionErr) {
+		t.Fatalf("expected an ActionError, got %v", err)
+	}
+	if actionErr.Action != "Ext" || !actionErr.Extender || actionErr.Rule != extensionsgoparser.RuleExtStr {
+		t.Fatalf("expected Ext in ext_str, got %s in %s", actionErr.Action, actionErr.Rule)
+	}
+	if actionErr.Start != 9 || actionErr.End != 14 || actionErr.Value != "bad node" {
+		t.Fatalf("expected offsets 9-14 and the panic value, got %+v", actionErr)
+	}
+}
+
+func TestWithPanicsLetsPanicsPropagate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected the panic to propagate")
+		}
+	}()
+	nodeactionsgoparser.Parse("act-seq: xyz", panickingActions{}, nil, nodeactionsgoparser.WithPanics())
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"itemsgoparser"
+)
+
+// recoveredItems describes the children of a tree returned by
+// ParseWithRecovery, marking error nodes with "!".
+func recoveredItems(tree itemsgoparser.TreeNode) []string {
+	var items []string
+	for _, child := range tree.Children() {
+		if _, ok := child.(*itemsgoparser.ErrorNode); ok {
+			items = append(items, "!"+child.Text())
+		} else {
+			items = append(items, child.Text())
+		}
+	}
+	return items
+}
+
+func TestParseWithRecoveryMatchesParseOnValidInput(t *testing.T) {
+	for _, input := range []string{"", "a:1\n", manyRecords(20)} {
+		expected, _ := itemsgoparser.Parse(input, nil, nil)
+
+		tree, diagnostics, err := itemsgoparser.ParseWithRecovery(input, nil, nil)
+		if err != nil || len(diagnostics) != 0 {
+			t.Fatalf("expected %q to parse without errors, got %v and %v", input, diagnostics, err)
+		}
+		if dumpTree(tree) != dumpTree(expected) {
+			t.Fatalf("expected\n%s\ngot\n%s", dumpTree(expected), dumpTree(tree))
+		}
+	}
+}
+
+func TestParseWithRecoverySkipsToTheNextItem(t *testing.T) {
+	input := "a:1\nb:x\nc:2\n"
+	tree, diagnostics, err := itemsgoparser.ParseWithRecovery(input, nil, nil)
+	if err != nil {
+		t.Fatalf("ParseWithRecovery returned unexpected error: %v", err)
+	}
+	if expected := []string{"a:1\n", "!b:x\n", "c:2\n"}; !slices.Equal(recoveredItems(tree), expected) {
+		t.Fatalf("expected items %q, got %q", expected, recoveredItems(tree))
+	}
+	if tree.Text() != input {
+		t.Fatalf("expected the tree to cover the input, got %q", tree.Text())
+	}
+
+	errNode := tree.Children()[1].(*itemsgoparser.ErrorNode)
+	if errNode.Offset() != 4 || errNode.End() != 8 || len(diagnostics) != 1 || errNode.Err != diagnostics[0] {
+		t.Fatalf("expected an error node at 4-8 carrying the diagnostic, got %d-%d", errNode.Offset(), errNode.End())
+	}
+
+	_, parseErr := itemsgoparser.Parse(input, nil, nil)
+	var expected *itemsgoparser.ParseError
+	if !errors.As(parseErr, &expected) {
+		t.Fatalf("expected Parse to fail, got %v", parseErr)
+	}
+	if diagnostics[0].Offset != expected.Offset || !slices.Equal(diagnostics[0].Expected, expected.Expected) {
+		t.Fatalf("expected the first diagnostic to match Parse's error %v, got %v", expected, diagnostics[0])
+	}
+}
+
+func TestParseWithRecoveryReportsEveryError(t *testing.T) {
+	tree, diagnostics, err := itemsgoparser.ParseWithRecovery("a:1\nb:x\nc:2\nd:\ne:5\nzz", nil, nil)
+	if err != nil {
+		t.Fatalf("ParseWithRecovery returned unexpected error: %v", err)
+	}
+	expected := []string{"a:1\n", "!b:x\n", "c:2\n", "!d:\n", "e:5\n", "!zz"}
+	if !slices.Equal(recoveredItems(tree), expected) {
+		t.Fatalf("expected items %q, got %q", expected, recoveredItems(tree))
+	}
+
+	var offsets []int
+	for _, diagnostic := range diagnostics {
+		offsets = append(offsets, diagnostic.Offset)
+	}
+	if expected := []int{6, 14, 21}; !slices.Equal(offsets, expected) {
+		t.Fatalf("expected diagnostics at %v, got %v", expected, offsets)
+	}
+}
+
+func TestParseWithRecoveryStopsAtLimits(t *testing.T) {
+	limits := itemsgoparser.WithLimits(itemsgoparser.Limits{MaxDepth: 10})
+	_, _, err := itemsgoparser.ParseWithRecovery("a:1\nb:x\n"+nestedRecord(100), nil, nil, limits)
+	assertLimitError(t, err, "MaxDepth")
+}
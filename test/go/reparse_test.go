@@ -0,0 +1,255 @@
+package test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"choicesgoparser"
+	"nodeactionsgoparser"
+	"predicatesgoparser"
+	"quantifiersgoparser"
+	"sequencesgoparser"
+	"terminalsgoparser"
+)
+
+type dumpableNode[T any] interface {
+	Text() string
+	Offset() int
+	Children() []T
+}
+
+// dumpTree describes a tree in enough detail to compare the results of two
+// parses, including the end offsets that shifted nodes have to get right.
+func dumpTree[T dumpableNode[T]](node T) string {
+	if any(node) == nil {
+		return "nil"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "(%q %d", node.Text(), node.Offset())
+	if n, ok := any(node).(interface{ End() int }); ok {
+		fmt.Fprintf(&b, "-%d", n.End())
+	}
+	for _, child := range node.Children() {
+		b.WriteString(" " + dumpTree(child))
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+func describeResult(tree string, err error) string {
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return tree
+}
+
+// reparseCase parses with one of the test grammars, so that the randomized
+// test below can run against all of them.
+type reparseCase struct {
+	inputs   []string
+	alphabet string
+	parse    func(input string) string
+	reparser func(input string) func(start, oldEnd int, text string) string
+}
+
+func newReparseCase[T dumpableNode[T], P any, E any](
+	inputs []string,
+	alphabet string,
+	parse func(string) (T, error),
+	newParser func(string) P,
+	reparse func(P, E) (T, error),
+	edit func(start, oldEnd int, text string) E,
+) reparseCase {
+	return reparseCase{
+		inputs:   inputs,
+		alphabet: alphabet,
+		parse: func(input string) string {
+			tree, err := parse(input)
+			return describeResult(dumpTree(tree), err)
+		},
+		reparser: func(input string) func(start, oldEnd int, text string) string {
+			parser := newParser(input)
+			return func(start, oldEnd int, text string) string {
+				tree, err := reparse(parser, edit(start, oldEnd, text))
+				return describeResult(dumpTree(tree), err)
+			}
+		},
+	}
+}
+
+var reparseCases = map[string]reparseCase{
+	"choices": newReparseCase(
+		[]string{"choice-rep: abcabcabcabcabcabcabcabc", "choice-seq: repeat", "choice-bind: ef"},
+		"abcdefprt-: ",
+		choicesParse,
+		func(input string) *choicesgoparser.ChoicesGoParser {
+			parser := choicesgoparser.New(input, nil)
+			parser.Parse()
+			return parser
+		},
+		(*choicesgoparser.ChoicesGoParser).Reparse,
+		func(start, oldEnd int, text string) choicesgoparser.Edit {
+			return choicesgoparser.Edit{Start: start, OldEnd: oldEnd, NewText: text}
+		},
+	),
+	"quantifiers": newReparseCase(
+		[]string{"rep-0: abcdefabcdefabcdef", "color-choice: #09af09af", "rep-range: abcd", "greedy-1: abcdefgh"},
+		"abcdefxyz0129#-: ",
+		quantifiersParse,
+		func(input string) *quantifiersgoparser.QuantifiersGoParser {
+			parser := quantifiersgoparser.New(input, nil)
+			parser.Parse()
+			return parser
+		},
+		(*quantifiersgoparser.QuantifiersGoParser).Reparse,
+		func(start, oldEnd int, text string) quantifiersgoparser.Edit {
+			return quantifiersgoparser.Edit{Start: start, OldEnd: oldEnd, NewText: text}
+		},
+	),
+	"node actions": newReparseCase(
+		[]string{"act-falsey-rep: null0false[]''null", "act-choice: 1234", "act-rep-paren: ababab"},
+		"abnulfse0123[]'-: ",
+		func(input string) (nodeactionsgoparser.TreeNode, error) {
+			return nodeactionsgoparser.Parse(input, testActions{}, nil)
+		},
+		func(input string) *nodeactionsgoparser.NodeActionsGoParser {
+			parser := nodeactionsgoparser.New(input, testActions{})
+			parser.Parse()
+			return parser
+		},
+		(*nodeactionsgoparser.NodeActionsGoParser).Reparse,
+		func(start, oldEnd int, text string) nodeactionsgoparser.Edit {
+			return nodeactionsgoparser.Edit{Start: start, OldEnd: oldEnd, NewText: text}
+		},
+	),
+	"predicates": newReparseCase(
+		[]string{"pos-ref: abc1abc1abc1abc1abc1abc1", "pos-seq: <abc12>", "neg-tail-str: word"},
+		"abcdorwAB01<>-: ",
+		predicatesParse,
+		func(input string) *predicatesgoparser.PredicatesGoParser {
+			parser := predicatesgoparser.New(input, nil)
+			parser.Parse()
+			return parser
+		},
+		(*predicatesgoparser.PredicatesGoParser).Reparse,
+		func(start, oldEnd int, text string) predicatesgoparser.Edit {
+			return predicatesgoparser.Edit{Start: start, OldEnd: oldEnd, NewText: text}
+		},
+	),
+	"sequences": newReparseCase(
+		[]string{"seq-rep-subseq: ab1b2b3b4b5c", "seq-mute-1: abc:   123", "seq-label-subseq: v.AB.CD.EF"},
+		"abcdvz019.AB:- ",
+		sequencesParse,
+		func(input string) *sequencesgoparser.SequencesGoParser {
+			parser := sequencesgoparser.New(input, nil)
+			parser.Parse()
+			return parser
+		},
+		(*sequencesgoparser.SequencesGoParser).Reparse,
+		func(start, oldEnd int, text string) sequencesgoparser.Edit {
+			return sequencesgoparser.Edit{Start: start, OldEnd: oldEnd, NewText: text}
+		},
+	),
+	"terminals": newReparseCase(
+		[]string{"any: 😀", "str-ci: OaT", "neg-class: é"},
+		"oatOATé😀7-: ",
+		func(input string) (terminalsgoparser.TreeNode, error) {
+			return terminalsgoparser.Parse(input, nil, nil)
+		},
+		func(input string) *terminalsgoparser.TerminalsGoParser {
+			parser := terminalsgoparser.New(input, nil)
+			parser.Parse()
+			return parser
+		},
+		(*terminalsgoparser.TerminalsGoParser).Reparse,
+		func(start, oldEnd int, text string) terminalsgoparser.Edit {
+			return terminalsgoparser.Edit{Start: start, OldEnd: oldEnd, NewText: text}
+		},
+	),
+}
+
+func TestReparseMatchesAFullParse(t *testing.T) {
+	for name, c := range reparseCases {
+		random := rand.New(rand.NewSource(1))
+		alphabet := []rune(c.alphabet)
+		for _, input := range c.inputs {
+			reparse := c.reparser(input)
+			text := []rune(input)
+			// Most edits go after the prefix that selects the rule under
+			// test, so that the input keeps matching it some of the time.
+			body := len([]rune(input[:strings.Index(input, ": ")+2]))
+
+			for i := 0; i < 300; i++ {
+				start := random.Intn(len(text) + 1)
+				if random.Intn(5) > 0 && len(text) > body {
+					start = body + random.Intn(len(text)-body+1)
+				}
+				oldEnd := min(start+random.Intn(4), len(text))
+				inserted := make([]rune, random.Intn(4))
+				for j := range inserted {
+					inserted[j] = alphabet[random.Intn(len(alphabet))]
+				}
+				text = append(text[:start:start], append(inserted, text[oldEnd:]...)...)
+
+				actual := reparse(start, oldEnd, string(inserted))
+				expected := c.parse(string(text))
+				if actual != expected {
+					t.Fatalf(
+						"%s: after replacing %d-%d of the input with %q to get %q, Reparse returned\n%s\nbut Parse returned\n%s",
+						name, start, oldEnd, string(inserted), string(text), actual, expected,
+					)
+				}
+			}
+		}
+	}
+}
+
+func TestReparseReusesNodesAwayFromTheEdit(t *testing.T) {
+	input := "pos-ref: " + strings.Repeat("ab1", 40)
+	parser := predicatesgoparser.New(input, nil)
+	parser.Parse()
+
+	// The first edit records what later ones need to reuse results.
+	tree, err := parser.Reparse(predicatesgoparser.Edit{Start: 9, OldEnd: 10, NewText: "b"})
+	if err != nil {
+		t.Fatalf("Reparse returned unexpected error: %v", err)
+	}
+	before := tree.Children()[1].Children()[1].Children()
+	offsets := make([]int, len(before))
+	for i, n := range before {
+		offsets[i] = n.Offset()
+	}
+
+	tree, err = parser.Reparse(predicatesgoparser.Edit{Start: 70, OldEnd: 70, NewText: "xy"})
+	if err != nil {
+		t.Fatalf("Reparse returned unexpected error: %v", err)
+	}
+	after := tree.Children()[1].Children()[1].Children()
+
+	for i := 0; i < 30; i++ {
+		if after[i] != before[i] || after[i].Offset() != offsets[i] {
+			t.Fatalf("expected node %d before the edit to be reused at offset %d", i, offsets[i])
+		}
+	}
+	for i := 61; i < len(before); i++ {
+		if after[i+2] != before[i] || after[i+2].Offset() != offsets[i]+2 {
+			t.Fatalf("expected node %d after the edit to be reused at offset %d", i, offsets[i]+2)
+		}
+	}
+
+	expected, _ := predicatesParse(input[:9] + "b" + input[10:70] + "xy" + input[70:])
+	if dumpTree(tree) != dumpTree(expected) {
+		t.Fatalf("expected Reparse to return\n%s\ngot\n%s", dumpTree(expected), dumpTree(tree))
+	}
+}
+
+func TestReparseRejectsEditsOutsideTheInput(t *testing.T) {
+	parser := predicatesgoparser.New("pos-ref: abc", nil)
+	for _, edit := range []predicatesgoparser.Edit{{Start: -1, OldEnd: 0}, {Start: 5, OldEnd: 4}, {Start: 12, OldEnd: 13}} {
+		if _, err := parser.Reparse(edit); err == nil {
+			t.Fatalf("expected Reparse(%+v) to fail", edit)
+		}
+	}
+}
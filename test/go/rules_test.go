@@ -0,0 +1,83 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"terminalsgoparser"
+)
+
+func TestParseRuleStartsFromTheGivenRule(t *testing.T) {
+	tree, err := terminalsgoparser.ParseRule(terminalsgoparser.RuleDoubleQuotedString, "oat", nil, nil)
+	if err != nil {
+		t.Fatalf("ParseRule returned unexpected error: %v", err)
+	}
+	assertTerminalMatches(t, node("oat", 0), tree)
+
+	tree, err = terminalsgoparser.New("é", nil).ParseRule(terminalsgoparser.RuleNegativeClass)
+	if err != nil {
+		t.Fatalf("ParseRule returned unexpected error: %v", err)
+	}
+	assertTerminalMatches(t, node("é", 0), tree)
+}
+
+func TestParseRuleReportsFailuresFromTheGivenRule(t *testing.T) {
+	_, err := terminalsgoparser.ParseRule(terminalsgoparser.RulePositiveClass, "7", nil, nil)
+	if err == nil || !strings.HasSuffix(err.Error(), `expected [a-z] from Terminals::positive_class but found "7"`) {
+		t.Fatalf("expected a failure from positive_class, got %v", err)
+	}
+}
+
+func TestParseRuleReportsTrailingInputRelativeToTheGivenRule(t *testing.T) {
+	_, err := terminalsgoparser.ParseRule(terminalsgoparser.RuleSingleQuotedString, "oatx", nil, nil)
+	expected := `parse stopped early at line 1, column 4: expected <EOF> from Terminals::single_quoted_string but found "x"`
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected %q, got %v", expected, err)
+	}
+
+	_, err = terminalsParse("str-1: oatx")
+	expected = `parse stopped early at line 1, column 11: expected <EOF> from Terminals but found "x"`
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestAParserCanParseMoreThanOnce(t *testing.T) {
+	_, expected := terminalsParse("str-1: oak")
+	parser := terminalsgoparser.New("str-1: oak", nil)
+	for i := 0; i < 2; i++ {
+		if _, err := parser.Parse(); err == nil || expected == nil || err.Error() != expected.Error() {
+			t.Fatalf("expected error %v, got %v", expected, err)
+		}
+	}
+
+	parser = terminalsgoparser.New("oat", nil)
+	for i := 0; i < 2; i++ {
+		tree, err := parser.ParseRule(terminalsgoparser.RuleSingleQuotedString)
+		if err != nil {
+			t.Fatalf("ParseRule returned unexpected error: %v", err)
+		}
+		assertTerminalMatches(t, node("oat", 0), tree)
+	}
+
+	parser = terminalsgoparser.New("str-1: oat / str-1: oat", nil)
+	for i := 0; i < 2; i++ {
+		if _, n, err := parser.ParsePrefix(); err != nil || n != 10 {
+			t.Fatalf("expected 10 characters, got %d, %v", n, err)
+		}
+	}
+}
+
+func TestParseRuleRejectsUnknownRules(t *testing.T) {
+	for _, rule := range []terminalsgoparser.Rule{-1, 100} {
+		if _, err := terminalsgoparser.ParseRule(rule, "oat", nil, nil); err == nil {
+			t.Fatalf("expected ParseRule(%d) to fail", rule)
+		}
+	}
+}
+
+func TestRuleStringReturnsTheRuleName(t *testing.T) {
+	if name := terminalsgoparser.RuleUncasedString.String(); name != "uncased_string" {
+		t.Fatalf("expected uncased_string, got %q", name)
+	}
+}
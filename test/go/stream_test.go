@@ -0,0 +1,88 @@
+package test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"terminalsgoparser"
+)
+
+func TestFeedParsesInputSplitIntoChunks(t *testing.T) {
+	for _, input := range []string{"any: 😀", "neg-class: é", "str-2: oat", "str-ci: OAT"} {
+		parser := terminalsgoparser.NewStream(nil)
+		for i := 0; i < len(input); i++ {
+			if _, err := parser.Feed([]byte(input[i : i+1])); !errors.Is(err, terminalsgoparser.ErrIncomplete) {
+				t.Fatalf("Feed(%q) after %q returned %v, expected ErrIncomplete", input[i:i+1], input[:i], err)
+			}
+		}
+		tree, err := parser.Close()
+		if err != nil {
+			t.Fatalf("Close returned unexpected error for %q: %v", input, err)
+		}
+		expected := parseTerminal(t, input)
+		assertTerminalMatches(t, node(expected.Text(), expected.Offset()), tree.Children()[1])
+	}
+}
+
+func TestFeedReturnsErrorsBeforeClose(t *testing.T) {
+	parser := terminalsgoparser.NewStream(nil)
+
+	_, err := parser.Feed([]byte("pos-class: 7"))
+	var parseErr *terminalsgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	if parseErr.Offset != 11 {
+		t.Fatalf("expected offset 11, got %d", parseErr.Offset)
+	}
+	if _, closeErr := parser.Close(); closeErr != err {
+		t.Fatalf("expected Close to return the same error, got %v", closeErr)
+	}
+}
+
+func TestFeedReportsTheSameErrorsAsParse(t *testing.T) {
+	for _, input := range []string{"nothing", "str-1: oak", "any: ", "pos-class: 7"} {
+		_, expected := terminalsgoparser.Parse(input, nil, nil)
+
+		for _, size := range []int{1, 3, len(input)} {
+			parser := terminalsgoparser.NewStream(nil)
+			for i := 0; i < len(input); i += size {
+				parser.Feed([]byte(input[i:min(i+size, len(input))]))
+			}
+			_, err := parser.Close()
+
+			if err == nil || err.Error() != expected.Error() {
+				t.Fatalf("expected %q fed in chunks of %d to fail with %v, got %v", input, size, expected, err)
+			}
+		}
+	}
+}
+
+func TestFeedDescribesOnlyTheInputFedSoFar(t *testing.T) {
+	// Feed fails as soon as "7" has been fed, before the rest of the word.
+	_, err := terminalsgoparser.NewStream(nil).Feed([]byte("pos-class: 7"))
+	var fed *terminalsgoparser.ParseError
+	if !errors.As(err, &fed) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	_, err = terminalsgoparser.Parse("pos-class: 7ab", nil, nil)
+	var parsed *terminalsgoparser.ParseError
+	if !errors.As(err, &parsed) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+
+	if fed.Found != `"7"` || parsed.Found != `"7ab"` {
+		t.Fatalf("expected Found to be \"7\" and \"7ab\", got %s and %s", fed.Found, parsed.Found)
+	}
+	if fed.Offset != parsed.Offset || fed.Kind != parsed.Kind || !slices.Equal(fed.Expected, parsed.Expected) {
+		t.Fatalf("expected the errors to differ only in Found, got %#v and %#v", fed, parsed)
+	}
+}
+
+func TestFeedRequiresAParserFromNewStream(t *testing.T) {
+	_, err := terminalsgoparser.New("", nil).Feed([]byte("any: a"))
+	if err == nil || errors.Is(err, terminalsgoparser.ErrIncomplete) {
+		t.Fatalf("expected an error, got %v", err)
+	}
+}
@@ -52,6 +52,11 @@ func TestAnyCharParsesAnySingleCharacter(t *testing.T) {
 	assertTerminalMatches(t, node("!", 5), parseTerminal(t, "any: !"))
 }
 
+func TestAnyCharParsesMultiByteCharacters(t *testing.T) {
+	assertTerminalMatches(t, node("é", 5), parseTerminal(t, "any: é"))
+	assertTerminalMatches(t, node("😀", 5), parseTerminal(t, "any: 😀"))
+}
+
 func TestAnyCharRejectsTheEmptyString(t *testing.T) {
 	expectTerminalParseError(t, "any: ")
 }
@@ -80,6 +85,11 @@ func TestNegativeCharClassRejectsCharactersWithinTheClass(t *testing.T) {
 	expectTerminalParseError(t, "neg-class: x")
 }
 
+func TestNegativeCharClassParsesNonASCIICharacters(t *testing.T) {
+	assertTerminalMatches(t, node("é", 11), parseTerminal(t, "neg-class: é"))
+	assertTerminalMatches(t, node("😀", 11), parseTerminal(t, "neg-class: 😀"))
+}
+
 func TestSingleQuotedStringParsesExactString(t *testing.T) {
 	assertTerminalMatches(t, node("oat", 7), parseTerminal(t, "str-1: oat"))
 }
@@ -151,3 +161,54 @@ func TestCaseInsensitiveStringRejectsTheEmptyString(t *testing.T) {
 func TestCaseInsensitiveStringRejectsPrefixes(t *testing.T) {
 	expectTerminalParseError(t, "str-ci: oa")
 }
+
+func TestParseBytesParsesAByteSlice(t *testing.T) {
+	tree, err := terminalsgoparser.ParseBytes([]byte("str-1: oat"), nil, nil)
+	if err != nil {
+		t.Fatalf("ParseBytes returned unexpected error: %v", err)
+	}
+	assertTerminalMatches(t, node("oat", 7), tree.Children()[1])
+}
+
+func TestParseErrorsReportRuneOffsets(t *testing.T) {
+	_, err := terminalsParse("any: éé")
+
+	var parseErr *terminalsgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	if parseErr.Offset != 6 || parseErr.Column != 7 {
+		t.Fatalf("expected offset 6 and column 7, got offset %d and column %d", parseErr.Offset, parseErr.Column)
+	}
+}
+
+func TestByteOffsetsReportOffsetsInBytes(t *testing.T) {
+	_, err := terminalsgoparser.Parse("any: éé", nil, nil, terminalsgoparser.WithByteOffsets())
+
+	var parseErr *terminalsgoparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	if parseErr.Offset != 7 || parseErr.Column != 7 {
+		t.Fatalf("expected offset 7 and column 7, got offset %d and column %d", parseErr.Offset, parseErr.Column)
+	}
+}
+
+func TestNodesReportTheirSpan(t *testing.T) {
+	tree, err := terminalsParse("any: é")
+	if err != nil {
+		t.Fatalf("parse returned unexpected error: %v", err)
+	}
+
+	root := tree.(*terminalsgoparser.Node1)
+	if span := root.Span(); span != (terminalsgoparser.Span{Start: 0, End: 6, ByteStart: 0, ByteEnd: 7}) {
+		t.Fatalf("unexpected root span %+v", span)
+	}
+	char := root.AnyChar.(*terminalsgoparser.BaseNode)
+	if span := char.Span(); span != (terminalsgoparser.Span{Start: 5, End: 6, ByteStart: 5, ByteEnd: 7}) {
+		t.Fatalf("unexpected node span %+v", span)
+	}
+	if char.End() != 6 {
+		t.Fatalf("expected end 6, got %d", char.End())
+	}
+}